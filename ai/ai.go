@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+const (
+	defaultBaseURL = "https://api.openai.com/v1"
+	defaultModel   = "gpt-4o-mini"
+
+	// maxTags caps how many suggested tags are kept from a response.
+	maxTags = 5
+
+	tagPrompt = "Suggest 3 to 5 short, lowercase tags for the following note. " +
+		"Respond with only a JSON array of strings, for example [\"work\", \"meeting\"]."
+)
+
+// TagSuggester suggests tags for a note's content.
+type TagSuggester interface {
+	SuggestTags(content string) ([]string, error)
+}
+
+// OpenAIClient suggests tags using the OpenAI chat completions API.
+type OpenAIClient struct {
+	APIKey     string
+	Model      string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewOpenAIClient creates a client with the default model and endpoint.
+func NewOpenAIClient(apiKey string) *OpenAIClient {
+	return &OpenAIClient{
+		APIKey:     apiKey,
+		Model:      defaultModel,
+		BaseURL:    defaultBaseURL,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// SuggestTags asks the model for tags describing the content.
+func (c *OpenAIClient) SuggestTags(content string) ([]string, error) {
+	body, err := json.Marshal(chatRequest{
+		Model: c.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: tagPrompt},
+			{Role: "user", Content: content},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.BaseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		logging.Errorf("Failed to call OpenAI API")
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("openai API returned status %s", resp.Status)
+	}
+
+	var parsed chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		logging.Errorf("Failed to decode OpenAI response")
+		return nil, err
+	}
+	if len(parsed.Choices) == 0 {
+		return nil, errors.New("openai API returned no choices")
+	}
+
+	return parseTags(parsed.Choices[0].Message.Content)
+}
+
+// parseTags reads tags from a JSON array reply, falling back to a comma-separated list.
+func parseTags(reply string) ([]string, error) {
+	var raw []string
+	if err := json.Unmarshal([]byte(strings.TrimSpace(reply)), &raw); err != nil {
+		raw = strings.Split(reply, ",")
+	}
+
+	var tags []string
+	for _, tag := range raw {
+		tag = strings.ToLower(strings.Trim(strings.TrimSpace(tag), `"'[]`))
+		if tag == "" {
+			continue
+		}
+		tags = append(tags, tag)
+		if len(tags) == maxTags {
+			break
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil, errors.New("no tags in OpenAI response")
+	}
+	return tags, nil
+}
@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func newTestServer(t *testing.T, status int, reply string) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+			t.Errorf("Unexpected Authorization header %q", auth)
+		}
+
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		if len(req.Messages) != 2 || req.Messages[1].Content != "Note content" {
+			t.Errorf("Expected note content as the user message, got %+v", req.Messages)
+		}
+
+		w.WriteHeader(status)
+		resp := chatResponse{}
+		resp.Choices = append(resp.Choices, struct {
+			Message chatMessage `json:"message"`
+		}{Message: chatMessage{Role: "assistant", Content: reply}})
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestSuggestTags(t *testing.T) {
+	server := newTestServer(t, http.StatusOK, `["Work", "meeting", "planning"]`)
+	defer server.Close()
+
+	client := NewOpenAIClient("test-key")
+	client.BaseURL = server.URL
+
+	tags, err := client.SuggestTags("Note content")
+	if err != nil {
+		t.Fatalf("SuggestTags failed: %v", err)
+	}
+
+	expected := []string{"work", "meeting", "planning"}
+	if !slices.Equal(tags, expected) {
+		t.Errorf("Expected tags %v, got %v", expected, tags)
+	}
+}
+
+func TestSuggestTags_ErrorStatus(t *testing.T) {
+	server := newTestServer(t, http.StatusUnauthorized, "")
+	defer server.Close()
+
+	client := NewOpenAIClient("test-key")
+	client.BaseURL = server.URL
+
+	if _, err := client.SuggestTags("Note content"); err == nil {
+		t.Error("Expected error for unauthorized response, got none")
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		reply    string
+		expected []string
+	}{
+		{reply: `["golang", "testing"]`, expected: []string{"golang", "testing"}},
+		{reply: "golang, Testing , ", expected: []string{"golang", "testing"}},
+		{reply: `["a", "b", "c", "d", "e", "f"]`, expected: []string{"a", "b", "c", "d", "e"}},
+	}
+
+	for _, tt := range tests {
+		tags, err := parseTags(tt.reply)
+		if err != nil {
+			t.Errorf("parseTags(%q) failed: %v", tt.reply, err)
+			continue
+		}
+		if !slices.Equal(tags, tt.expected) {
+			t.Errorf("parseTags(%q) = %v, expected %v", tt.reply, tags, tt.expected)
+		}
+	}
+
+	if _, err := parseTags("  "); err == nil {
+		t.Error("Expected error for empty reply, got none")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// archiveTimeLayout names archive files; colons are avoided so names are valid on every platform.
+const archiveTimeLayout = "2006-01-02T15-04-05"
+
+// archiveNow is the clock used to name buffer archives, replaced in tests.
+var archiveNow = time.Now
+
+// archiveBuffer writes the processed buffer contents to a timestamped file in dir
+// and returns its path, creating them with filePerm and dirPerm. Archives made
+// within the same second share a file.
+func archiveBuffer(fs notes.FileSystem, dir string, data []byte, filePerm, dirPerm os.FileMode) (string, error) {
+	exists, err := fs.Exists(dir)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		if err := fs.MkdirAll(dir, dirPerm); err != nil {
+			return "", fmt.Errorf("creating archive directory: %w", err)
+		}
+	}
+
+	path := filepath.Join(dir, archiveNow().Format(archiveTimeLayout)+".md")
+	if err := fs.AppendToFile(path, string(data), filePerm); err != nil {
+		return "", fmt.Errorf("writing archive %s: %w", path, err)
+	}
+
+	logging.Infof("Buffer archived to %s\n", path)
+	return path, nil
+}
+
+// appendArchive adds the processed buffer contents to the end of the archive
+// file at path, creating it and its directory with filePerm and dirPerm. Each
+// buffer ends with a newline so the next one starts on its own line.
+func appendArchive(fs notes.FileSystem, path string, data []byte, filePerm, dirPerm os.FileMode) error {
+	dir := filepath.Dir(path)
+	exists, err := fs.Exists(dir)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := fs.MkdirAll(dir, dirPerm); err != nil {
+			return fmt.Errorf("creating archive directory: %w", err)
+		}
+	}
+
+	archived := string(data)
+	if !strings.HasSuffix(archived, "\n") {
+		archived += "\n"
+	}
+	if err := fs.AppendToFile(path, archived, filePerm); err != nil {
+		return fmt.Errorf("writing archive %s: %w", path, err)
+	}
+
+	logging.Infof("Buffer appended to %s\n", path)
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// failingArchiveFileSystem rejects writes into the archive directory.
+type failingArchiveFileSystem struct {
+	notes.OSFileSystem
+	archiveDir string
+}
+
+func (fs failingArchiveFileSystem) AppendToFile(path string, data string, perm os.FileMode) error {
+	if strings.HasPrefix(path, fs.archiveDir) {
+		return errors.New("disk full")
+	}
+	return fs.OSFileSystem.AppendToFile(path, data, perm)
+}
+
+func setArchiveNow(t *testing.T, now time.Time) {
+	original := archiveNow
+	archiveNow = func() time.Time { return now }
+	t.Cleanup(func() { archiveNow = original })
+}
+
+func TestRunProcess_ArchiveBuffer(t *testing.T) {
+	setArchiveNow(t, time.Date(2024, 9, 12, 15, 4, 5, 0, time.UTC))
+
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	buffer := "---\ntitle: Archived\ndate: 2023-10-01\n---\nContent.\n"
+	if err := os.WriteFile(bufferFile, []byte(buffer), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: filepath.Join(tempDir, "notes"), ClearBuffer: true, ArchiveBuffer: true}
+	if err := runProcess(cfg, notes.OSFileSystem{}, nil); err != nil {
+		t.Fatalf("runProcess failed: %v", err)
+	}
+
+	archived, err := os.ReadFile(filepath.Join(tempDir, "buffer-archive", "2024-09-12T15-04-05.md"))
+	if err != nil {
+		t.Fatalf("Expected the buffer to be archived: %v", err)
+	}
+	if string(archived) != buffer {
+		t.Errorf("Expected the archive to hold the buffer contents, got:\n%s", archived)
+	}
+
+	data, err := os.ReadFile(bufferFile)
+	if err != nil {
+		t.Fatalf("Failed to read buffer file: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Expected the buffer to be cleared after archiving, got:\n%s", data)
+	}
+}
+
+func TestRunProcess_ArchiveFailureKeepsBuffer(t *testing.T) {
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	archiveDir := filepath.Join(tempDir, "archive")
+	buffer := "---\ntitle: Kept\ndate: 2023-10-01\n---\nContent.\n"
+	if err := os.WriteFile(bufferFile, []byte(buffer), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	fs := failingArchiveFileSystem{archiveDir: archiveDir}
+	cfg := &config.Config{
+		BufferFile:    bufferFile,
+		NotesDir:      filepath.Join(tempDir, "notes"),
+		ClearBuffer:   true,
+		ArchiveBuffer: true,
+		ArchiveDir:    archiveDir,
+	}
+	if err := runProcess(cfg, fs, nil); err == nil {
+		t.Fatal("Expected an error when archiving fails")
+	}
+
+	data, err := os.ReadFile(bufferFile)
+	if err != nil {
+		t.Fatalf("Failed to read buffer file: %v", err)
+	}
+	if string(data) != buffer {
+		t.Errorf("Expected the buffer to be kept when archiving fails, got:\n%s", data)
+	}
+}
+
+func TestRunProcess_ArchiveFile(t *testing.T) {
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	archiveFile := filepath.Join(tempDir, "buffer-archive.md")
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: filepath.Join(tempDir, "notes"), ClearBuffer: true, ArchiveFile: "buffer-archive.md"}
+
+	// The second buffer doesn't end in a newline, which must not run into the next one
+	buffers := []string{
+		"---\ntitle: First\ndate: 2023-10-01\n---\nCaptured first.\n",
+		"---\ntitle: Second\ndate: 2023-10-02\n---\nCaptured second.",
+		"---\ntitle: Third\ndate: 2023-10-03\n---\nCaptured third.\n",
+	}
+	var expected string
+	for _, buffer := range buffers {
+		if err := os.WriteFile(bufferFile, []byte(buffer), 0o644); err != nil {
+			t.Fatalf("Failed to write buffer file: %v", err)
+		}
+		if err := runProcess(cfg, notes.OSFileSystem{}, nil); err != nil {
+			t.Fatalf("runProcess failed: %v", err)
+		}
+
+		expected += buffer
+		if !strings.HasSuffix(expected, "\n") {
+			expected += "\n"
+		}
+		archived, err := os.ReadFile(archiveFile)
+		if err != nil {
+			t.Fatalf("Expected the buffer to be archived: %v", err)
+		}
+		if string(archived) != expected {
+			t.Errorf("Expected the archive to grow with each buffer.\nExpected:\n%q\nGot:\n%q", expected, archived)
+		}
+		if data, err := os.ReadFile(bufferFile); err != nil || len(data) != 0 {
+			t.Errorf("Expected the buffer to be cleared after archiving, got %q, %v", data, err)
+		}
+	}
+
+	saved, err := notes.SplitNotesFromFile(expected)
+	if err != nil || len(saved) != 3 {
+		t.Errorf("Expected the archive to parse as the three captured notes, got %v, %v", saved, err)
+	}
+}
+
+func TestRunProcess_ArchiveFileFailureKeepsBuffer(t *testing.T) {
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	archiveDir := filepath.Join(tempDir, "archive")
+	buffer := "---\ntitle: Kept\ndate: 2023-10-01\n---\nContent.\n"
+	if err := os.WriteFile(bufferFile, []byte(buffer), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	fs := failingArchiveFileSystem{archiveDir: archiveDir}
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: filepath.Join(tempDir, "notes"), ClearBuffer: true, ArchiveFile: filepath.Join(archiveDir, "log.md")}
+	if err := runProcess(cfg, fs, nil); err == nil {
+		t.Fatal("Expected an error when archiving fails")
+	}
+	if data, err := os.ReadFile(bufferFile); err != nil || string(data) != buffer {
+		t.Errorf("Expected the buffer to be kept when archiving fails, got %q, %v", data, err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/jasonmichels/chrononoteai/git"
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+// gitRunner runs git for --git-commit; tests replace it to avoid a real repository.
+var gitRunner git.Runner = git.ExecRunner
+
+// commitNotes commits all changes in the notes directory with a message
+// naming the range of note dates that were written.
+func commitNotes(notesDir string, dates []string) error {
+	repo := git.Repo{Dir: notesDir, Run: gitRunner}
+	committed, err := repo.CommitAll(commitMessage(dates))
+	if err != nil {
+		logging.Errorf("Error committing notes: %v", err)
+		return err
+	}
+	if committed {
+		logging.Infof("Committed notes in %s", notesDir)
+	}
+	return nil
+}
+
+// commitMessage describes the dates of the written notes, e.g.
+// "notes: 2024-09-12" or "notes: 2024-09-10 to 2024-09-12". Undated drafts
+// and inbox notes are left out of the range.
+func commitMessage(dates []string) string {
+	dates = slices.DeleteFunc(slices.Clone(dates), func(date string) bool { return date == "" })
+	if len(dates) == 0 {
+		return "notes: update"
+	}
+	first, last := slices.Min(dates), slices.Max(dates)
+	if first == last {
+		return "notes: " + first
+	}
+	return "notes: " + first + " to " + last
+}
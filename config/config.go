@@ -1,67 +1,288 @@
 package config
 
 import (
+	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+	"gopkg.in/yaml.v3"
 )
 
 // string constant for chrononoteai
 const dirName = "chrononoteai"
 
+// Environment variables that override the config file. Command-line flags still win.
+const (
+	envConfigFile = "CHRONONOTEAI_CONFIG"
+	envBufferFile = "CHRONONOTEAI_BUFFER"
+	envNotesDir   = "CHRONONOTEAI_NOTES"
+)
+
+// Older environment variable names, still read when the newer ones are unset.
+const (
+	legacyEnvConfigFile = "CHRONONOTE_CONFIG"
+	legacyEnvBufferFile = "CHRONONOTE_BUFFER"
+	legacyEnvNotesDir   = "CHRONONOTE_NOTES"
+)
+
+// Supported config file formats, selected by the config file extension.
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
 type Config struct {
-	BufferFile string `json:"buffer_file"`
-	NotesDir   string `json:"notes_dir"`
-	ConfigFile string // Path to the config file (not saved in JSON)
+	BufferFile string `json:"buffer_file" yaml:"buffer_file" toml:"buffer_file"`
+	NotesDir   string `json:"notes_dir" yaml:"notes_dir" toml:"notes_dir"`
+
+	// BufferFiles are more buffers processed after BufferFile in the same run, e.g. one per device
+	BufferFiles []string `json:"buffer_files,omitempty" yaml:"buffer_files,omitempty" toml:"buffer_files,omitempty"`
+	// BufferGlob is a glob or directory whose matching files are each processed as a separate buffer, e.g. "~/inbox/*.md"
+	BufferGlob string `json:"buffer_glob,omitempty" yaml:"buffer_glob,omitempty" toml:"buffer_glob,omitempty"`
+	// DateLayouts are the Go time layouts tried in order when parsing note dates
+	DateLayouts []string `json:"date_layouts,omitempty" yaml:"date_layouts,omitempty" toml:"date_layouts,omitempty"`
+	// PathTemplate gives a note's path within NotesDir, as a Go time layout or a Go template; see notes.RenderPath
+	PathTemplate string `json:"path_template" yaml:"path_template" toml:"path_template"`
+	// InlineSingleTag writes a lone tag as "tags: [tag]" instead of a block list
+	InlineSingleTag bool `json:"inline_single_tag" yaml:"inline_single_tag" toml:"inline_single_tag"`
+	// NoteSeparator is a text/template written between notes in the same file, e.g. "***" or "## {{.Time}}"
+	NoteSeparator string `json:"note_separator,omitempty" yaml:"note_separator,omitempty" toml:"note_separator,omitempty"`
+	// Template is a Go template for the front matter new adds, e.g. "---\ntitle: {{yaml .Title}}\ndate: {{.Date}}\n---\n\n"; see notes.RenderSkeleton
+	Template string `json:"template,omitempty" yaml:"template,omitempty" toml:"template,omitempty"`
+	// NoFrontMatter writes notes as plain Markdown with a title heading instead of YAML front matter
+	NoFrontMatter bool `json:"no_front_matter,omitempty" yaml:"no_front_matter,omitempty" toml:"no_front_matter,omitempty"`
+	// SingleFrontMatterPerFile writes front matter only for the first note in a file and a "## Title" section for the rest
+	SingleFrontMatterPerFile bool `json:"single_front_matter_per_file,omitempty" yaml:"single_front_matter_per_file,omitempty" toml:"single_front_matter_per_file,omitempty"`
+	// DefaultToday dates notes that have no date with the current day instead of rejecting them
+	DefaultToday bool `json:"default_today,omitempty" yaml:"default_today,omitempty" toml:"default_today,omitempty"`
+	// UndatedPolicy is what happens to notes that have no date: error, today, or inbox; overrides DefaultToday when set
+	UndatedPolicy string `json:"undated_policy,omitempty" yaml:"undated_policy,omitempty" toml:"undated_policy,omitempty"`
+	// Strict rejects notes with unknown front matter keys instead of warning about them
+	Strict bool `json:"strict,omitempty" yaml:"strict,omitempty" toml:"strict,omitempty"`
+	// RequireContent rejects notes whose content is empty or only whitespace
+	RequireContent bool `json:"require_content,omitempty" yaml:"require_content,omitempty" toml:"require_content,omitempty"`
+	// UniqueTitlePerDay rejects a batch in which two notes share a date and title
+	UniqueTitlePerDay bool `json:"unique_title_per_day,omitempty" yaml:"unique_title_per_day,omitempty" toml:"unique_title_per_day,omitempty"`
+	// TagPattern is a regular expression every tag must match; there is no default, and empty allows any tag
+	TagPattern string `json:"tag_pattern" yaml:"tag_pattern" toml:"tag_pattern"`
+	// LowercaseTags lowercases tags when notes are saved; duplicate tags are dropped either way
+	LowercaseTags bool `json:"lowercase_tags" yaml:"lowercase_tags" toml:"lowercase_tags"`
+	// SanitizeTags quotes tags with characters that break plain YAML, such as a leading "-", instead of rejecting them
+	SanitizeTags bool `json:"sanitize_tags,omitempty" yaml:"sanitize_tags,omitempty" toml:"sanitize_tags,omitempty"`
+	// DefaultTags are added to every processed note; --default-tag adds more for one run
+	DefaultTags []string `json:"default_tags,omitempty" yaml:"default_tags,omitempty" toml:"default_tags,omitempty"`
+	// ClearBuffer empties the buffer after its notes are saved; --no-clear turns it off for one run
+	ClearBuffer bool `json:"clear_buffer" yaml:"clear_buffer" toml:"clear_buffer"`
+	// Backup copies each existing notes file to a .bak file before notes are added to it
+	Backup bool `json:"backup,omitempty" yaml:"backup,omitempty" toml:"backup,omitempty"`
+	// ArchiveBuffer copies the processed buffer into ArchiveDir before it is cleared
+	ArchiveBuffer bool `json:"archive_buffer,omitempty" yaml:"archive_buffer,omitempty" toml:"archive_buffer,omitempty"`
+	// ArchiveDir holds buffer archives; a buffer-archive directory next to the buffer file is used when unset
+	ArchiveDir string `json:"archive_dir,omitempty" yaml:"archive_dir,omitempty" toml:"archive_dir,omitempty"`
+	// ArchiveFile is a single file, e.g. buffer-archive.md, the processed buffer is appended to before it is cleared; relative to the buffer file's directory
+	ArchiveFile string `json:"archive_file,omitempty" yaml:"archive_file,omitempty" toml:"archive_file,omitempty"`
+	// WordsPerMinute is the reading speed used to compute reading_minutes for saved notes
+	WordsPerMinute int `json:"words_per_minute" yaml:"words_per_minute" toml:"words_per_minute"`
+	// MinYear and MaxYear bound the years notes may be dated in; 1900 and next year when unset
+	MinYear int `json:"min_year,omitempty" yaml:"min_year,omitempty" toml:"min_year,omitzero"`
+	MaxYear int `json:"max_year,omitempty" yaml:"max_year,omitempty" toml:"max_year,omitzero"`
+	// FilePerm and DirPerm are octal permissions for new files and directories, e.g. "0600" and "0700"
+	FilePerm string `json:"file_perm,omitempty" yaml:"file_perm,omitempty" toml:"file_perm,omitempty"`
+	DirPerm  string `json:"dir_perm,omitempty" yaml:"dir_perm,omitempty" toml:"dir_perm,omitempty"`
+	// Concurrency is how many note files are written at once; GOMAXPROCS when unset
+	Concurrency int `json:"concurrency,omitempty" yaml:"concurrency,omitempty" toml:"concurrency,omitzero"`
+	// LogLevel is one of error, warn, info, or debug; info when unset
+	LogLevel string `json:"log_level,omitempty" yaml:"log_level,omitempty" toml:"log_level,omitempty"`
+	// LogFormat is text or json; text when unset
+	LogFormat string `json:"log_format,omitempty" yaml:"log_format,omitempty" toml:"log_format,omitempty"`
+	// Encrypt stores note content encrypted with a passphrase; front matter stays readable
+	Encrypt bool `json:"encrypt,omitempty" yaml:"encrypt,omitempty" toml:"encrypt,omitempty"`
+	// OpenAIAPIKey is used for tag suggestions; OPENAI_API_KEY is used when unset
+	OpenAIAPIKey string `json:"openai_api_key,omitempty" yaml:"openai_api_key,omitempty" toml:"openai_api_key,omitempty"`
+
+	// Runtime settings (not saved in the config file)
+	ConfigFile string   `json:"-" yaml:"-" toml:"-"` // Path to the config file
+	DryRun     bool     `json:"-" yaml:"-" toml:"-"` // Preview note placement without writing
+	Force      bool     `json:"-" yaml:"-" toml:"-"` // Save notes even if an identical note already exists
+	GitCommit  bool     `json:"-" yaml:"-" toml:"-"` // Commit changes in the notes directory after processing
+	AITags     bool     `json:"-" yaml:"-" toml:"-"` // Suggest tags with OpenAI for untagged notes
+	Args       []string `json:"-" yaml:"-" toml:"-"` // Positional arguments left after flags, starting with the command
+	Version    bool     `json:"-" yaml:"-" toml:"-"` // Print the version and exit; no other settings are loaded
+	Watch      bool     `json:"-" yaml:"-" toml:"-"` // Process the buffer each time it changes until interrupted
+	Quiet      bool     `json:"-" yaml:"-" toml:"-"` // Log only errors, whatever the log level
+
+	Logger logging.Logger `json:"-" yaml:"-" toml:"-"` // Optional; receives config messages instead of the default logger
 }
 
 // InitializeWithArgs Modify Initialize to accept a FlagSet and arguments
 func InitializeWithArgs(args []string) (*Config, error) {
+	return InitializeWithLogger(args, nil)
+}
+
+// InitializeWithLogger is InitializeWithArgs logging to logger, which is kept
+// as the returned config's Logger. A nil logger means logging.Default.
+func InitializeWithLogger(args []string, logger logging.Logger) (*Config, error) {
+	log := logging.OrDefault(logger)
 	fs := flag.NewFlagSet(dirName, flag.ContinueOnError)
 
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		log.Println("Failed to get user home directory")
+		log.Errorf("Failed to get user home directory")
 		return nil, err
 	}
 	defaultConfigPath := filepath.Join(homeDir, ".config", "chrononoteai", "config.json")
+	if envConfigPath := getenv(envConfigFile, legacyEnvConfigFile); envConfigPath != "" {
+		defaultConfigPath = envConfigPath
+	}
 
 	configPath := fs.String("config", defaultConfigPath, "Path to the configuration file")
-	bufferFile := fs.String("buffer", "", "Path to the buffer file")
+	var bufferFiles StringList
+	fs.Var(&bufferFiles, "buffer", "Path to the buffer file; repeat to process more buffers after it")
 	notesDir := fs.String("notes", "", "Path to the notes directory")
+	bufferGlob := fs.String("buffer-glob", "", "Glob or directory of files to process as separate buffers, e.g. '~/inbox/*.md'")
+	dryRun := fs.Bool("dry-run", false, "Preview where notes would be written without writing them")
+	force := fs.Bool("force", false, "Save notes even if an identical note already exists")
+	aiTags := fs.Bool("ai-tags", false, "Suggest tags with OpenAI for notes that have none")
+	noClear := fs.Bool("no-clear", false, "Keep the buffer contents after processing")
+	gitCommit := fs.Bool("git-commit", false, "Commit changes in the notes directory with git after processing")
+	defaultToday := fs.Bool("default-today", false, "Date notes that have no date with today's date")
+	noFrontMatter := fs.Bool("no-frontmatter", false, "Write notes as plain Markdown without YAML front matter")
+	backup := fs.Bool("backup", false, "Copy each notes file to a .bak file before adding notes to it")
+	strict := fs.Bool("strict", false, "Reject notes with unknown front matter keys")
+	encrypt := fs.Bool("encrypt", false, "Encrypt note content with a passphrase from CHRONONOTEAI_PASSPHRASE or a prompt")
+	var defaultTags StringList
+	fs.Var(&defaultTags, "default-tag", "Tag to add to every note; may be repeated")
+	noteTemplate := fs.String("template", "", "Go template for the front matter new adds, using {{.Title}}, {{.Date}}, and {{.Tags}}")
+	logLevel := fs.String("log-level", "", "How much to log: error, warn, info, or debug")
+	logFormat := fs.String("log-format", "", "How to write logs: text, or json for one object per line")
+	verbose := fs.Bool("verbose", false, "Log everything; same as --log-level debug")
+	quiet := fs.Bool("quiet", false, "Log only errors; same as --log-level error")
+	watch := fs.Bool("watch", false, "Keep running and process the buffer whenever it is saved")
+	version := fs.Bool("version", false, "Print the version and exit")
 
 	if err := fs.Parse(args); err != nil {
-		log.Println("Failed to parse command-line arguments")
+		log.Errorf("Failed to parse command-line arguments")
 		return nil, err
 	}
 
-	cfg, err := LoadConfig(*configPath)
+	// Return before loading the config so --version never creates files
+	if *version {
+		return &Config{Version: true}, nil
+	}
+
+	cfg, err := LoadConfigWithLogger(*configPath, logger)
 	if err != nil {
-		log.Println("Failed to load config")
+		log.Errorf("Failed to load config")
 		return nil, err
 	}
 
+	cfg.applyEnv()
+
 	// Override with command-line arguments
 	updated := false
-	if *bufferFile != "" {
-		cfg.BufferFile = *bufferFile
+	if len(bufferFiles) > 0 {
+		cfg.BufferFile, cfg.BufferFiles = bufferFiles[0], bufferFiles[1:]
 		updated = true
 	}
 	if *notesDir != "" {
 		cfg.NotesDir = *notesDir
 		updated = true
 	}
+	if *bufferGlob != "" {
+		cfg.BufferGlob = *bufferGlob
+		updated = true
+	}
 
 	// Save updated configuration
 	if updated {
 		if err := cfg.Save(); err != nil {
-			log.Println("Failed to save config")
+			log.Errorf("Failed to save config")
 			return nil, err
 		}
 	}
 
+	cfg.DryRun = *dryRun
+	cfg.Force = *force
+	cfg.GitCommit = *gitCommit
+	if *noClear {
+		cfg.ClearBuffer = false
+	}
+	if *defaultToday {
+		cfg.DefaultToday = true
+		cfg.UndatedPolicy = string(notes.UndatedToday)
+	}
+	if *noFrontMatter {
+		cfg.NoFrontMatter = true
+	}
+	if *backup {
+		cfg.Backup = true
+	}
+	if *strict {
+		cfg.Strict = true
+	}
+	if *encrypt {
+		cfg.Encrypt = true
+	}
+	cfg.DefaultTags = append(cfg.DefaultTags, defaultTags...)
+	if *noteTemplate != "" {
+		if err := notes.ValidateNoteTemplate(*noteTemplate); err != nil {
+			log.Errorf("Invalid --template: %v", err)
+			return nil, err
+		}
+		cfg.Template = *noteTemplate
+	}
+	if *logLevel != "" {
+		cfg.LogLevel = *logLevel
+	}
+	if *logFormat != "" {
+		cfg.LogFormat = *logFormat
+	}
+	if *verbose && *quiet {
+		log.Errorf("--verbose and --quiet can't be used together")
+		return nil, errors.New("conflicting flags --verbose and --quiet")
+	}
+	if *verbose {
+		cfg.LogLevel = logging.LevelDebug.String()
+	}
+	if *quiet {
+		cfg.Quiet = true
+		cfg.LogLevel = logging.LevelError.String()
+	}
+	cfg.AITags = *aiTags
+	cfg.Watch = *watch
+	cfg.Args = fs.Args()
+
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Errorf("Invalid log level: %v", err)
+		return nil, err
+	}
+	logging.SetLevel(level)
+
+	format, err := logging.ParseFormat(cfg.LogFormat)
+	if err != nil {
+		log.Errorf("Invalid log format: %v", err)
+		return nil, err
+	}
+	// JSON logs replace the default logger unless the caller supplied one
+	if format == logging.FormatJSON && logger == nil {
+		logging.SetDefault(logging.NewLogger(format, os.Stderr))
+	}
+
 	err = cfg.CreateBufferFileIfNeeded()
 	if err != nil {
 		return nil, err
@@ -72,36 +293,215 @@ func InitializeWithArgs(args []string) (*Config, error) {
 	return cfg, nil
 }
 
+// applyEnv overrides config file values with any environment variables that are set.
+func (c *Config) applyEnv() {
+	if bufferFile := getenv(envBufferFile, legacyEnvBufferFile); bufferFile != "" {
+		c.BufferFile = bufferFile
+	}
+	if notesDir := getenv(envNotesDir, legacyEnvNotesDir); notesDir != "" {
+		c.NotesDir = notesDir
+	}
+}
+
+// getenv returns the first of the named environment variables with a
+// non-empty value. A variable set to an empty string counts as unset.
+func getenv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
 // Initialize function calls InitializeWithArgs with os.Args[1:]
 func Initialize() (*Config, error) {
 	return InitializeWithArgs(os.Args[1:])
 }
 
+// logConfiguration logs the resolved settings, and at debug level where each
+// one can be set.
 func logConfiguration(cfg *Config) {
-	log.Println("Configuration:")
-	log.Printf("  Config File: %s\n", cfg.ConfigFile)
-	log.Printf("  Buffer File: %s\n", cfg.BufferFile)
-	log.Printf("  Notes Dir:   %s\n", cfg.NotesDir)
-	log.Println("You can modify these settings in the config file or via command-line flags.")
+	for _, line := range cfg.Summary() {
+		cfg.logger().Infof("%s", line)
+	}
+	cfg.logger().Debugf("Settings are taken from command-line flags first, then CHRONONOTEAI_CONFIG, CHRONONOTEAI_BUFFER,")
+	cfg.logger().Debugf("and CHRONONOTEAI_NOTES environment variables, then the config file, then defaults.")
+	cfg.logger().Debugf("You can modify these settings in the config file or via command-line flags.")
+}
+
+// logger returns the Logger config messages go to.
+func (c *Config) logger() logging.Logger {
+	return logging.OrDefault(c.Logger)
+}
+
+// Summary describes the resolved configuration, one line per setting.
+func (c *Config) Summary() []string {
+	lines := []string{
+		"Configuration:",
+		"  Config File: " + c.ConfigFile,
+		"  Buffer File: " + c.BufferFile,
+	}
+	if len(c.BufferFiles) > 0 {
+		lines = append(lines, "  More Buffers: "+strings.Join(c.BufferFiles, ", "))
+	}
+	if c.BufferGlob != "" {
+		lines = append(lines, "  Buffer Glob: "+c.BufferGlob)
+	}
+	lines = append(lines,
+		"  Notes Dir:   "+c.NotesDir,
+		"  Date Layouts: "+strings.Join(c.DateLayouts, ", "),
+		"  Path Template: "+c.PathTemplate,
+	)
+	if c.DryRun {
+		lines = append(lines, "  Dry Run:     enabled, no files will be written")
+	}
+	return lines
+}
+
+// Buffers returns BufferFile followed by BufferFiles in the order listed,
+// without empty or repeated paths.
+func (c *Config) Buffers() []string {
+	var buffers []string
+	for _, path := range append([]string{c.BufferFile}, c.BufferFiles...) {
+		if path != "" && !slices.Contains(buffers, path) {
+			buffers = append(buffers, path)
+		}
+	}
+	return buffers
+}
+
+// BufferGlobFiles returns the files matching BufferGlob in sorted filename
+// order, leaving out directories and the paths Buffers returns. A leading ~
+// is the home directory, and a directory matches the .md files in it.
+func (c *Config) BufferGlobFiles() ([]string, error) {
+	if c.BufferGlob == "" {
+		return nil, nil
+	}
+
+	pattern := c.BufferGlob
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			c.logger().Errorf("Failed to get user home directory")
+			return nil, err
+		}
+		pattern = filepath.Join(homeDir, pattern[1:])
+	}
+	if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+		pattern = filepath.Join(pattern, "*.md")
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		c.logger().Errorf("Invalid buffer_glob %q: %v", c.BufferGlob, err)
+		return nil, err
+	}
+
+	buffers := c.Buffers()
+	var files []string
+	for _, path := range matches {
+		if info, err := os.Stat(path); err != nil || info.IsDir() || slices.Contains(buffers, path) {
+			continue
+		}
+		files = append(files, path)
+	}
+	slices.SortFunc(files, func(a, b string) int {
+		return cmp.Or(strings.Compare(filepath.Base(a), filepath.Base(b)), strings.Compare(a, b))
+	})
+	return files, nil
+}
+
+// ResolveOpenAIAPIKey returns the configured OpenAI API key, falling back to
+// the OPENAI_API_KEY environment variable.
+func (c *Config) ResolveOpenAIAPIKey() string {
+	if c.OpenAIAPIKey != "" {
+		return c.OpenAIAPIKey
+	}
+	return os.Getenv("OPENAI_API_KEY")
+}
+
+// ResolveArchiveDir returns the configured archive directory, falling back to
+// a buffer-archive directory next to the buffer file.
+func (c *Config) ResolveArchiveDir() string {
+	if c.ArchiveDir != "" {
+		return c.ArchiveDir
+	}
+	return filepath.Join(filepath.Dir(c.BufferFile), "buffer-archive")
+}
+
+// ResolveArchiveFile returns the configured archive file, resolving a relative
+// path against the buffer file's directory. It is empty when unset.
+func (c *Config) ResolveArchiveFile() string {
+	if c.ArchiveFile == "" || filepath.IsAbs(c.ArchiveFile) {
+		return c.ArchiveFile
+	}
+	return filepath.Join(filepath.Dir(c.BufferFile), c.ArchiveFile)
+}
+
+// TagRegexp compiles TagPattern, returning nil when it is empty.
+func (c *Config) TagRegexp() (*regexp.Regexp, error) {
+	if c.TagPattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(c.TagPattern)
+}
+
+// Permissions parses FilePerm and DirPerm, falling back to notes.DefaultFilePerm
+// and notes.DefaultDirPerm when they are unset.
+func (c *Config) Permissions() (file, dir os.FileMode, err error) {
+	file, err = parsePerm(c.FilePerm, notes.DefaultFilePerm)
+	if err != nil {
+		return 0, 0, fmt.Errorf("file_perm: %w", err)
+	}
+	dir, err = parsePerm(c.DirPerm, notes.DefaultDirPerm)
+	if err != nil {
+		return 0, 0, fmt.Errorf("dir_perm: %w", err)
+	}
+	return file, dir, nil
+}
+
+// parsePerm parses an octal permission string such as "0600" or "600".
+func parsePerm(value string, fallback os.FileMode) (os.FileMode, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	perm, err := strconv.ParseUint(strings.TrimPrefix(value, "0o"), 8, 32)
+	if err != nil || perm == 0 || perm > 0o777 {
+		return 0, fmt.Errorf("invalid permissions %q, expected octal such as 0600", value)
+	}
+	return os.FileMode(perm), nil
 }
 
 // LoadConfig loads the configuration from the given path or initializes it with defaults.
+// Settings missing from an existing config file take their default values.
 func LoadConfig(configPath string) (*Config, error) {
+	return LoadConfigWithLogger(configPath, nil)
+}
+
+// LoadConfigWithLogger is LoadConfig logging to logger, which is kept as the
+// returned config's Logger. A nil logger means logging.Default.
+func LoadConfigWithLogger(configPath string, logger logging.Logger) (*Config, error) {
 	config := &Config{
 		ConfigFile: configPath,
+		Logger:     logger,
+	}
+	log := config.logger()
+
+	// Start from defaults so settings missing from an existing file keep their default values
+	if err := config.setDefaults(); err != nil {
+		return nil, err
 	}
 
 	// Check if config file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		// Use default values if config file doesn't exist
-		defaultErr := config.setDefaults()
-		if defaultErr != nil {
-			return nil, defaultErr
+		_, dirPerm, err := config.Permissions()
+		if err != nil {
+			return nil, err
 		}
-
-		mkDirErr := os.MkdirAll(filepath.Dir(configPath), os.ModePerm)
-		if mkDirErr != nil {
-			return nil, mkDirErr
+		if err := os.MkdirAll(filepath.Dir(configPath), dirPerm); err != nil {
+			log.Errorf("Failed to create config directory")
+			return nil, err
 		}
 
 		if err := config.Save(); err != nil {
@@ -112,24 +512,97 @@ func LoadConfig(configPath string) (*Config, error) {
 
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		log.Println("Failed to read config file")
+		log.Errorf("Failed to read config file")
 		return nil, err
 	}
 
-	if err := json.Unmarshal(data, config); err != nil {
-		log.Println("Failed to parse config file")
+	if err := unmarshalConfig(configFormat(configPath), data, config); err != nil {
+		log.Errorf("Failed to parse config file")
+		return nil, err
+	}
+
+	if err := notes.ValidatePathTemplate(config.PathTemplate); err != nil {
+		log.Errorf("Invalid path_template in config file")
+		return nil, err
+	}
+	if _, err := regexp.Compile(config.TagPattern); err != nil {
+		log.Errorf("Invalid tag_pattern in config file")
+		return nil, err
+	}
+	if _, _, err := config.Permissions(); err != nil {
+		log.Errorf("Invalid permissions in config file")
+		return nil, err
+	}
+	if _, err := logging.ParseLevel(config.LogLevel); err != nil {
+		log.Errorf("Invalid log_level in config file")
+		return nil, err
+	}
+	if _, err := logging.ParseFormat(config.LogFormat); err != nil {
+		log.Errorf("Invalid log_format in config file")
+		return nil, err
+	}
+	if _, err := template.New("note_separator").Parse(config.NoteSeparator); err != nil {
+		log.Errorf("Invalid note_separator in config file")
+		return nil, err
+	}
+	if _, err := notes.ParseUndatedPolicy(config.UndatedPolicy); err != nil {
+		log.Errorf("Invalid undated_policy in config file")
+		return nil, err
+	}
+	if err := notes.ValidateNoteTemplate(config.Template); err != nil {
+		log.Errorf("Invalid template in config file")
 		return nil, err
 	}
 
 	return config, nil
 }
 
+// configFormat detects the config file format from its extension, falling back to JSON.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	default:
+		return formatJSON
+	}
+}
+
+// unmarshalConfig decodes data in the given format into the config.
+func unmarshalConfig(format string, data []byte, c *Config) error {
+	switch format {
+	case formatYAML:
+		return yaml.Unmarshal(data, c)
+	case formatTOML:
+		return toml.Unmarshal(data, c)
+	default:
+		return json.Unmarshal(data, c)
+	}
+}
+
+// marshalConfig encodes the config in the given format.
+func marshalConfig(format string, c *Config) ([]byte, error) {
+	switch format {
+	case formatYAML:
+		return yaml.Marshal(c)
+	case formatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(c); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return json.MarshalIndent(c, "", "  ")
+	}
+}
+
 // CreateBufferFileIfNeeded checks if buffer file exists and if not it creates it
 func (c *Config) CreateBufferFileIfNeeded() error {
 	if _, err := os.Stat(c.BufferFile); os.IsNotExist(err) {
 		bufferFile, err := os.Create(c.BufferFile)
 		if err != nil {
-			log.Println("Failed to create buffer file")
+			c.logger().Errorf("Failed to create buffer file")
 			return err
 		}
 
@@ -142,16 +615,21 @@ func (c *Config) CreateBufferFileIfNeeded() error {
 	return nil
 }
 
-// Save writes the configuration to the config file.
+// Save writes the configuration to the config file in the format matching its extension.
 func (c *Config) Save() error {
-	data, err := json.MarshalIndent(c, "", "  ")
+	data, err := marshalConfig(configFormat(c.ConfigFile), c)
 	if err != nil {
-		log.Println("Failed to serialize config")
+		c.logger().Errorf("Failed to serialize config")
 		return err
 	}
 
-	if err := os.WriteFile(c.ConfigFile, data, 0o644); err != nil {
-		log.Println("Failed to write config file")
+	filePerm, _, err := c.Permissions()
+	if err != nil {
+		c.logger().Errorf("Invalid permissions in config")
+		return err
+	}
+	if err := os.WriteFile(c.ConfigFile, data, filePerm); err != nil {
+		c.logger().Errorf("Failed to write config file")
 		return err
 	}
 
@@ -165,5 +643,10 @@ func (c *Config) setDefaults() error {
 	}
 	c.BufferFile = filepath.Join(homeDir, ".config", dirName, "note.md")
 	c.NotesDir = filepath.Join(homeDir, ".config", dirName, "notes")
+	c.DateLayouts = []string{"2006-01-02"}
+	c.PathTemplate = notes.DefaultPathTemplate
+	c.InlineSingleTag = true
+	c.ClearBuffer = true
+	c.WordsPerMinute = 200
 	return nil
 }
@@ -1,11 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
 )
 
 func TestInitializeWithArgs_Defaults(t *testing.T) {
@@ -251,3 +258,715 @@ func TestSetDefaults(t *testing.T) {
 		t.Errorf("Expected NotesDir %s, got %s", expectedNotesDir, cfg.NotesDir)
 	}
 }
+
+func TestLoadConfig_Formats(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		contents string
+	}{
+		{
+			name:     "json",
+			fileName: "config.json",
+			contents: `{"buffer_file": "/tmp/test_buffer.md", "notes_dir": "/tmp/test_notes"}`,
+		},
+		{
+			name:     "yaml",
+			fileName: "config.yaml",
+			contents: "buffer_file: /tmp/test_buffer.md\nnotes_dir: /tmp/test_notes\n",
+		},
+		{
+			name:     "yml",
+			fileName: "config.yml",
+			contents: "buffer_file: /tmp/test_buffer.md\nnotes_dir: /tmp/test_notes\n",
+		},
+		{
+			name:     "toml",
+			fileName: "config.toml",
+			contents: "buffer_file = \"/tmp/test_buffer.md\"\nnotes_dir = \"/tmp/test_notes\"\n",
+		},
+		{
+			name:     "unknown extension falls back to json",
+			fileName: "config.conf",
+			contents: `{"buffer_file": "/tmp/test_buffer.md", "notes_dir": "/tmp/test_notes"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(configPath, []byte(tt.contents), 0644); err != nil {
+				t.Fatalf("Failed to write sample config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+
+			if cfg.BufferFile != "/tmp/test_buffer.md" {
+				t.Errorf("Expected BufferFile /tmp/test_buffer.md, got %s", cfg.BufferFile)
+			}
+			if cfg.NotesDir != "/tmp/test_notes" {
+				t.Errorf("Expected NotesDir /tmp/test_notes, got %s", cfg.NotesDir)
+			}
+		})
+	}
+}
+
+func TestSave_RoundTripsFormat(t *testing.T) {
+	tests := []struct {
+		fileName string
+		expected string
+	}{
+		{fileName: "config.toml", expected: "buffer_file = \"/tmp/test_buffer.md\"\nnotes_dir = \"/tmp/test_notes\"\npath_template = \"2006/01/02.md\"\ninline_single_tag = false\ntag_pattern = \"\"\nlowercase_tags = false\nclear_buffer = false\nwords_per_minute = 0\n"},
+		{fileName: "config.yaml", expected: "buffer_file: /tmp/test_buffer.md\nnotes_dir: /tmp/test_notes\npath_template: 2006/01/02.md\ninline_single_tag: false\ntag_pattern: \"\"\nlowercase_tags: false\nclear_buffer: false\nwords_per_minute: 0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), tt.fileName)
+			cfg := &Config{
+				BufferFile:   "/tmp/test_buffer.md",
+				NotesDir:     "/tmp/test_notes",
+				PathTemplate: "2006/01/02.md",
+				ConfigFile:   configPath,
+			}
+
+			if err := cfg.Save(); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			data, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatalf("Failed to read config file: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Config file content mismatch.\nExpected:\n%s\nGot:\n%s", tt.expected, string(data))
+			}
+
+			loaded, err := LoadConfig(configPath)
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if loaded.BufferFile != cfg.BufferFile || loaded.NotesDir != cfg.NotesDir {
+				t.Errorf("Round trip mismatch: got %+v", loaded)
+			}
+		})
+	}
+}
+
+func TestInitializeWithArgs_DryRun(t *testing.T) {
+	// Suppress log output during testing
+	log.SetOutput(os.Stdout)
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+
+	args := []string{
+		"--config", configPath,
+		"--buffer", filepath.Join(tempDir, "buffer.md"),
+		"--dry-run",
+		"--force",
+		"--default-today",
+		"--git-commit",
+		"--no-clear",
+		"--strict",
+		"--default-tag", "acme",
+		"--default-tag", "q4",
+	}
+
+	cfg, err := InitializeWithArgs(args)
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+
+	if !cfg.DryRun {
+		t.Error("Expected DryRun to be enabled")
+	}
+	if !cfg.Force {
+		t.Error("Expected Force to be enabled")
+	}
+	if !cfg.DefaultToday {
+		t.Error("Expected DefaultToday to be enabled")
+	}
+	if !cfg.GitCommit {
+		t.Error("Expected GitCommit to be enabled")
+	}
+	if cfg.ClearBuffer {
+		t.Error("Expected --no-clear to disable ClearBuffer")
+	}
+	if !cfg.Strict {
+		t.Error("Expected Strict to be enabled")
+	}
+	if !slices.Equal(cfg.DefaultTags, []string{"acme", "q4"}) {
+		t.Errorf("Expected default tags from the flags, got %v", cfg.DefaultTags)
+	}
+
+	// Dry run is a per-invocation setting and must not be persisted
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config file: %v", err)
+	}
+	saved := map[string]any{}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse config file: %v", err)
+	}
+	for key := range saved {
+		if strings.Contains(strings.ToLower(key), "dry") || strings.Contains(strings.ToLower(key), "force") ||
+			strings.Contains(strings.ToLower(key), "today") || strings.Contains(strings.ToLower(key), "git") ||
+			strings.Contains(strings.ToLower(key), "strict") || key == "default_tags" {
+			t.Errorf("Expected per-run flags not to be saved, got key %q", key)
+		}
+	}
+}
+
+func TestResolveOpenAIAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "env-key")
+
+	cfg := &Config{}
+	if key := cfg.ResolveOpenAIAPIKey(); key != "env-key" {
+		t.Errorf("Expected key from environment, got %q", key)
+	}
+
+	cfg.OpenAIAPIKey = "config-key"
+	if key := cfg.ResolveOpenAIAPIKey(); key != "config-key" {
+		t.Errorf("Expected configured key to win, got %q", key)
+	}
+}
+
+func TestInitializeWithArgs_EnvPrecedence(t *testing.T) {
+	// Suppress log output during testing
+	log.SetOutput(os.Stdout)
+
+	tempDir := t.TempDir()
+	fileConfigPath := filepath.Join(tempDir, "config.json")
+	envConfigPath := filepath.Join(tempDir, "env-config.json")
+	fileBuffer := filepath.Join(tempDir, "file-buffer.md")
+	envBuffer := filepath.Join(tempDir, "env-buffer.md")
+	flagBuffer := filepath.Join(tempDir, "flag-buffer.md")
+	envNotes := filepath.Join(tempDir, "env-notes")
+
+	sampleConfig := `{"buffer_file": "` + fileBuffer + `", "notes_dir": "/tmp/file-notes"}`
+	for _, path := range []string{fileConfigPath, envConfigPath} {
+		if err := os.WriteFile(path, []byte(sampleConfig), 0644); err != nil {
+			t.Fatalf("Failed to write sample config file: %v", err)
+		}
+	}
+
+	t.Setenv("CHRONONOTE_CONFIG", envConfigPath)
+	t.Setenv("CHRONONOTE_BUFFER", envBuffer)
+	t.Setenv("CHRONONOTE_NOTES", envNotes)
+
+	// Env overrides the config file, and the env config path is used without --config
+	cfg, err := InitializeWithArgs(nil)
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if cfg.ConfigFile != envConfigPath {
+		t.Errorf("Expected ConfigFile %s, got %s", envConfigPath, cfg.ConfigFile)
+	}
+	if cfg.BufferFile != envBuffer {
+		t.Errorf("Expected BufferFile %s, got %s", envBuffer, cfg.BufferFile)
+	}
+	if cfg.NotesDir != envNotes {
+		t.Errorf("Expected NotesDir %s, got %s", envNotes, cfg.NotesDir)
+	}
+
+	// Env values alone are not persisted
+	loaded, err := LoadConfig(envConfigPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if loaded.BufferFile != fileBuffer {
+		t.Errorf("Expected config file to keep BufferFile %s, got %s", fileBuffer, loaded.BufferFile)
+	}
+
+	// Flags override env
+	cfg, err = InitializeWithArgs([]string{"--config", fileConfigPath, "--buffer", flagBuffer})
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if cfg.ConfigFile != fileConfigPath {
+		t.Errorf("Expected ConfigFile %s, got %s", fileConfigPath, cfg.ConfigFile)
+	}
+	if cfg.BufferFile != flagBuffer {
+		t.Errorf("Expected BufferFile %s, got %s", flagBuffer, cfg.BufferFile)
+	}
+	if cfg.NotesDir != envNotes {
+		t.Errorf("Expected NotesDir %s, got %s", envNotes, cfg.NotesDir)
+	}
+}
+
+func TestLoadConfig_MissingSettingsUseDefaults(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	sampleConfig := `{"buffer_file": "/tmp/test_buffer.md", "notes_dir": "/tmp/test_notes"}`
+	if err := os.WriteFile(configPath, []byte(sampleConfig), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if !cfg.InlineSingleTag {
+		t.Error("Expected InlineSingleTag to default to true")
+	}
+	if len(cfg.DateLayouts) != 1 || cfg.DateLayouts[0] != "2006-01-02" {
+		t.Errorf("Expected default DateLayouts, got %v", cfg.DateLayouts)
+	}
+	if !cfg.ClearBuffer {
+		t.Error("Expected ClearBuffer to default to true")
+	}
+	if cfg.LowercaseTags {
+		t.Error("Expected LowercaseTags to default to false")
+	}
+	if cfg.TagPattern != "" {
+		t.Errorf("Expected TagPattern to default to empty, got %q", cfg.TagPattern)
+	}
+	if cfg.WordsPerMinute != 200 {
+		t.Errorf("Expected WordsPerMinute to default to 200, got %d", cfg.WordsPerMinute)
+	}
+}
+
+func TestLoadConfig_InlineSingleTagOptOut(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	sampleConfig := "buffer_file: /tmp/test_buffer.md\nnotes_dir: /tmp/test_notes\ninline_single_tag: false\n"
+	if err := os.WriteFile(configPath, []byte(sampleConfig), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if cfg.InlineSingleTag {
+		t.Error("Expected InlineSingleTag to be disabled by the config file")
+	}
+}
+
+func TestLoadConfig_DefaultToday(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("default_today: true\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !cfg.DefaultToday {
+		t.Error("Expected DefaultToday to be enabled by the config file")
+	}
+}
+
+func TestLoadConfig_UndatedPolicy(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("undated_policy: inbox\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.UndatedPolicy != "inbox" {
+		t.Errorf("Expected UndatedPolicy inbox, got %q", cfg.UndatedPolicy)
+	}
+
+	// --default-today wins over the config file for the run
+	cfg, err = InitializeWithArgs([]string{"--config", configPath, "--default-today"})
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if cfg.UndatedPolicy != "today" {
+		t.Errorf("Expected --default-today to select today, got %q", cfg.UndatedPolicy)
+	}
+
+	if err := os.WriteFile(configPath, []byte("undated_policy: later\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected error for an unknown undated_policy, got none")
+	}
+}
+
+func TestLoadConfig_PathTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		valid    bool
+	}{
+		{template: "2006-01-02.md", valid: true},
+		{template: "2006/01-January/02.md", valid: true},
+		{template: "2006/01.md", valid: true},
+		{template: "{{.Year}}/{{.Month}}/{{.Day}}-{{.TitleSlug}}.md", valid: true},
+		{template: "{{.Year}/{{.Day}}.md", valid: false},
+		{template: "notes.md", valid: false},
+		{template: "2006/01/02.txt", valid: false},
+		{template: "/2006/01/02.md", valid: false},
+		{template: "../2006/01/02.md", valid: false},
+		{template: "", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			sampleConfig := `{"buffer_file": "/tmp/test_buffer.md", "notes_dir": "/tmp/test_notes", "path_template": "` + tt.template + `"}`
+			if err := os.WriteFile(configPath, []byte(sampleConfig), 0644); err != nil {
+				t.Fatalf("Failed to write sample config file: %v", err)
+			}
+
+			cfg, err := LoadConfig(configPath)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("LoadConfig failed: %v", err)
+				}
+				if cfg.PathTemplate != tt.template {
+					t.Errorf("Expected PathTemplate %q, got %q", tt.template, cfg.PathTemplate)
+				}
+			} else if err == nil {
+				t.Errorf("Expected error for path_template %q, got none", tt.template)
+			}
+		})
+	}
+}
+
+func TestInitializeWithArgs_EnvNames(t *testing.T) {
+	// Suppress log output during testing
+	log.SetOutput(os.Stdout)
+
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "config.json")
+	newBuffer := filepath.Join(tempDir, "new-buffer.md")
+	legacyBuffer := filepath.Join(tempDir, "legacy-buffer.md")
+	legacyNotes := filepath.Join(tempDir, "legacy-notes")
+
+	t.Setenv("CHRONONOTEAI_CONFIG", configPath)
+	t.Setenv("CHRONONOTEAI_BUFFER", newBuffer)
+	t.Setenv("CHRONONOTE_BUFFER", legacyBuffer)
+	// An empty value is treated as unset, so the legacy name is used
+	t.Setenv("CHRONONOTEAI_NOTES", "")
+	t.Setenv("CHRONONOTE_NOTES", legacyNotes)
+
+	cfg, err := InitializeWithArgs(nil)
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if cfg.ConfigFile != configPath {
+		t.Errorf("Expected ConfigFile %s, got %s", configPath, cfg.ConfigFile)
+	}
+	if cfg.BufferFile != newBuffer {
+		t.Errorf("Expected CHRONONOTEAI_BUFFER to win, got %s", cfg.BufferFile)
+	}
+	if cfg.NotesDir != legacyNotes {
+		t.Errorf("Expected NotesDir %s, got %s", legacyNotes, cfg.NotesDir)
+	}
+
+	// Empty values everywhere leave the config file settings alone
+	t.Setenv("CHRONONOTEAI_BUFFER", "")
+	t.Setenv("CHRONONOTE_BUFFER", "")
+	cfg, err = InitializeWithArgs(nil)
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	homeDir, _ := os.UserHomeDir()
+	if expected := filepath.Join(homeDir, ".config", dirName, "note.md"); cfg.BufferFile != expected {
+		t.Errorf("Expected BufferFile %s, got %s", expected, cfg.BufferFile)
+	}
+}
+
+func TestInitializeWithArgs_VersionCreatesNothing(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "fresh", "config.json")
+
+	cfg, err := InitializeWithArgs([]string{"--config", configPath, "--version"})
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if !cfg.Version {
+		t.Error("Expected Version to be set")
+	}
+	if _, err := os.Stat(filepath.Dir(configPath)); !os.IsNotExist(err) {
+		t.Errorf("Expected --version not to create %s", filepath.Dir(configPath))
+	}
+}
+
+func TestLoadConfig_NoteSeparator(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	sampleConfig := "note_separator: \"## {{.Time}}\"\n"
+	if err := os.WriteFile(configPath, []byte(sampleConfig), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.NoteSeparator != "## {{.Time}}" {
+		t.Errorf("Expected NoteSeparator %q, got %q", "## {{.Time}}", cfg.NoteSeparator)
+	}
+
+	if err := os.WriteFile(configPath, []byte("note_separator: \"## {{.Time\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected error for an invalid note_separator template, got none")
+	}
+}
+
+func TestLoadConfig_Template(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	sampleConfig := "template: \"---\\ntitle: {{yaml .Title}}\\ndate: {{.Date}}\\n---\\n\\n\"\n"
+	if err := os.WriteFile(configPath, []byte(sampleConfig), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if expected := "---\ntitle: {{yaml .Title}}\ndate: {{.Date}}\n---\n\n"; cfg.Template != expected {
+		t.Errorf("Expected Template %q, got %q", expected, cfg.Template)
+	}
+
+	for _, invalid := range []string{"title: {{.Title\n", "title: {{.Name}}\n"} {
+		if err := os.WriteFile(configPath, []byte("template: "+strconv.Quote(invalid)+"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write sample config file: %v", err)
+		}
+		if _, err := LoadConfig(configPath); err == nil {
+			t.Errorf("Expected error for the invalid template %q, got none", invalid)
+		}
+	}
+
+	if _, err := InitializeWithArgs([]string{"--config", configPath, "--template", "{{.Title"}); err == nil {
+		t.Error("Expected error for an invalid --template, got none")
+	}
+}
+
+func TestLoadConfig_TagPattern(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("tag_pattern: \"[a-z\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected error for an invalid tag_pattern, got none")
+	}
+
+	// A pattern is only applied once tag_pattern is set
+	if err := os.WriteFile(configPath, []byte("tag_pattern: '^[\\p{L}\\p{N}_/-]+$'\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if re, err := cfg.TagRegexp(); err != nil || re == nil || re.MatchString("project x") {
+		t.Errorf("Expected the configured pattern to reject spaces, got %v, %v", re, err)
+	}
+
+	// An empty pattern allows any tag
+	cfg = &Config{}
+	if re, err := cfg.TagRegexp(); re != nil || err != nil {
+		t.Errorf("Expected no pattern for an empty tag_pattern, got %v, %v", re, err)
+	}
+}
+
+func TestConfig_Permissions(t *testing.T) {
+	cfg := &Config{}
+	file, dir, err := cfg.Permissions()
+	if err != nil || file != notes.DefaultFilePerm || dir != notes.DefaultDirPerm {
+		t.Errorf("Expected default permissions, got %o, %o, %v", file, dir, err)
+	}
+
+	cfg = &Config{FilePerm: "0600", DirPerm: "700"}
+	file, dir, err = cfg.Permissions()
+	if err != nil || file != 0o600 || dir != 0o700 {
+		t.Errorf("Expected 0600 and 0700, got %o, %o, %v", file, dir, err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"file_perm": "rw-r--r--"}`), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected error for an invalid file_perm, got none")
+	}
+}
+
+func TestLoadConfigWithLogger(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("log_level: loud\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+
+	var logs bytes.Buffer
+	if _, err := LoadConfigWithLogger(configPath, logging.StdLogger{Log: log.New(&logs, "", 0)}); err == nil {
+		t.Fatal("Expected error for an invalid log_level, got none")
+	}
+	if !strings.Contains(logs.String(), "Invalid log_level in config file") {
+		t.Errorf("Expected the error on the given logger, got:\n%s", logs.String())
+	}
+}
+
+func TestInitializeWithArgs_LogFormat(t *testing.T) {
+	log.SetOutput(os.Stdout)
+	t.Cleanup(func() { logging.SetDefault(nil) })
+
+	tempDir := t.TempDir()
+	args := []string{
+		"--config", filepath.Join(tempDir, "config.json"),
+		"--buffer", filepath.Join(tempDir, "buffer.md"),
+	}
+
+	cfg, err := InitializeWithArgs(append(args, "--log-format", "json"))
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if _, ok := logging.Default().(*logging.JSONLogger); cfg.LogFormat != "json" || !ok {
+		t.Errorf("Expected --log-format json to install a JSON logger, got %q and %T", cfg.LogFormat, logging.Default())
+	}
+
+	if _, err := InitializeWithArgs(append(args, "--log-format", "xml")); err == nil {
+		t.Error("Expected an error for an unknown log format")
+	}
+
+	configPath := filepath.Join(tempDir, "bad.yaml")
+	if err := os.WriteFile(configPath, []byte("log_format: xml\n"), 0644); err != nil {
+		t.Fatalf("Failed to write sample config file: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected error for an invalid log_format, got none")
+	}
+}
+
+func TestResolveArchiveDir(t *testing.T) {
+	cfg := &Config{BufferFile: filepath.Join("home", "note.md")}
+	if dir := cfg.ResolveArchiveDir(); dir != filepath.Join("home", "buffer-archive") {
+		t.Errorf("Expected archives next to the buffer file, got %q", dir)
+	}
+
+	cfg.ArchiveDir = "archives"
+	if dir := cfg.ResolveArchiveDir(); dir != "archives" {
+		t.Errorf("Expected the configured archive directory, got %q", dir)
+	}
+}
+
+func TestInitializeWithArgs_LogLevel(t *testing.T) {
+	log.SetOutput(os.Stdout)
+	t.Cleanup(func() { logging.SetLevel(logging.LevelInfo) })
+
+	tempDir := t.TempDir()
+	args := []string{
+		"--config", filepath.Join(tempDir, "config.json"),
+		"--buffer", filepath.Join(tempDir, "buffer.md"),
+	}
+
+	cfg, err := InitializeWithArgs(append(args, "--log-level", "error"))
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if cfg.LogLevel != "error" || logging.Enabled(logging.LevelWarn) {
+		t.Errorf("Expected --log-level error to hide warnings, got LogLevel %q", cfg.LogLevel)
+	}
+
+	if _, err := InitializeWithArgs(append(args, "--verbose")); err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if !logging.Enabled(logging.LevelDebug) {
+		t.Error("Expected --verbose to enable debug logging")
+	}
+
+	if _, err := InitializeWithArgs(append(args, "--log-level", "loud")); err == nil {
+		t.Error("Expected an error for an unknown log level")
+	}
+
+	cfg, err = InitializeWithArgs(append(args, "--log-level", "debug", "--quiet"))
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if !cfg.Quiet || logging.Enabled(logging.LevelInfo) || !logging.Enabled(logging.LevelError) {
+		t.Errorf("Expected --quiet to keep only errors, got LogLevel %q", cfg.LogLevel)
+	}
+
+	if _, err := InitializeWithArgs(append(args, "--quiet", "--verbose")); err == nil {
+		t.Error("Expected an error for --quiet with --verbose")
+	}
+}
+
+func TestInitializeWithArgs_MultipleBuffers(t *testing.T) {
+	log.SetOutput(os.Stdout)
+
+	tempDir := t.TempDir()
+	laptop := filepath.Join(tempDir, "laptop.md")
+	phone := filepath.Join(tempDir, "phone.md")
+	cfg, err := InitializeWithArgs([]string{
+		"--config", filepath.Join(tempDir, "config.json"),
+		"--buffer", laptop,
+		"--buffer", phone,
+		"--buffer", laptop,
+	})
+	if err != nil {
+		t.Fatalf("InitializeWithArgs failed: %v", err)
+	}
+	if cfg.BufferFile != laptop || !slices.Equal(cfg.Buffers(), []string{laptop, phone}) {
+		t.Errorf("Expected the buffers in the order listed without repeats, got %q and %v", cfg.BufferFile, cfg.Buffers())
+	}
+
+	// The list is saved like a single --buffer
+	saved, err := LoadConfig(filepath.Join(tempDir, "config.json"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if !slices.Equal(saved.Buffers(), []string{laptop, phone}) {
+		t.Errorf("Expected the buffers to be saved, got %v", saved.Buffers())
+	}
+}
+
+func TestBufferGlobFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, name := range []string{"b.md", "a.md", "notes.txt", "buffer.md"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), nil, 0o644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "dir.md"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	// A directory matches its .md files, leaving out directories and the buffer itself
+	cfg := &Config{BufferFile: filepath.Join(tempDir, "buffer.md"), BufferGlob: tempDir}
+	files, err := cfg.BufferGlobFiles()
+	if err != nil {
+		t.Fatalf("BufferGlobFiles failed: %v", err)
+	}
+	want := []string{filepath.Join(tempDir, "a.md"), filepath.Join(tempDir, "b.md")}
+	if !slices.Equal(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+
+	cfg.BufferGlob = filepath.Join(tempDir, "*.txt")
+	if files, err := cfg.BufferGlobFiles(); err != nil || !slices.Equal(files, []string{filepath.Join(tempDir, "notes.txt")}) {
+		t.Errorf("Expected the glob's matches, got %v, %v", files, err)
+	}
+
+	t.Setenv("HOME", tempDir)
+	cfg.BufferGlob = "~/a.*"
+	if files, err := cfg.BufferGlobFiles(); err != nil || !slices.Equal(files, []string{filepath.Join(tempDir, "a.md")}) {
+		t.Errorf("Expected ~ to expand to the home directory, got %v, %v", files, err)
+	}
+}
+
+func TestLogConfiguration_Levels(t *testing.T) {
+	logging.SetLevel(logging.LevelInfo)
+	var logs bytes.Buffer
+	logConfiguration(&Config{NotesDir: "/notes", Logger: logging.StdLogger{Log: log.New(&logs, "", 0)}})
+
+	// The summary is shown by default, where settings come from only with debug
+	if !strings.Contains(logs.String(), "Notes Dir:   /notes") {
+		t.Errorf("Expected the summary at info level, got:\n%s", logs.String())
+	}
+	if strings.Contains(logs.String(), "Settings are taken") {
+		t.Errorf("Expected the precedence details only at debug level, got:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,15 @@
+package config
+
+import "strings"
+
+// StringList is a flag.Value that collects every occurrence of a repeatable flag.
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *StringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"flag"
+	"slices"
+	"testing"
+)
+
+func TestStringList(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var tags StringList
+	fs.Var(&tags, "tag", "Tag to match")
+
+	if err := fs.Parse([]string{"--tag", "golang", "--tag", "work"}); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if !slices.Equal(tags, StringList{"golang", "work"}) {
+		t.Errorf("Expected both tags to be collected, got %v", tags)
+	}
+	if tags.String() != "golang,work" {
+		t.Errorf("Expected String to join values, got %q", tags.String())
+	}
+}
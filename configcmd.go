@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runConfig prints the resolved configuration with "show" or the config file
+// path with "path".
+func runConfig(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	return printConfig(os.Stdout, cfg, args)
+}
+
+// printConfig writes the output of the config action named in args to w.
+func printConfig(w io.Writer, cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		logging.Errorf("Usage: chrononoteai config show|path")
+		return errors.New("config requires one action: show or path")
+	}
+
+	switch args[0] {
+	case "show":
+		for _, line := range cfg.Summary() {
+			fmt.Fprintln(w, line)
+		}
+	case "path":
+		fmt.Fprintln(w, cfg.ConfigFile)
+	default:
+		logging.Errorf("Unknown config action: %s", args[0])
+		return fmt.Errorf("unknown config action %q", args[0])
+	}
+	return nil
+}
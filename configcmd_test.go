@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/config"
+)
+
+func TestResolveCommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		watch    bool
+		expected string
+		rest     []string
+	}{
+		{expected: "process"},
+		{args: []string{"config", "show"}, expected: "config", rest: []string{"show"}},
+		{args: []string{"config", "path"}, expected: "config", rest: []string{"path"}},
+		{args: []string{"list"}, expected: "list", rest: []string{}},
+		{watch: true, expected: "watch"},
+	}
+
+	for _, tt := range tests {
+		run, rest, err := resolveCommand(&config.Config{Args: tt.args, Watch: tt.watch})
+		if err != nil {
+			t.Fatalf("resolveCommand(%v) failed: %v", tt.args, err)
+		}
+		if reflect.ValueOf(run).Pointer() != reflect.ValueOf(commands[tt.expected]).Pointer() {
+			t.Errorf("resolveCommand(%v): expected the %s command", tt.args, tt.expected)
+		}
+		if len(rest) != 0 || len(tt.rest) != 0 {
+			if !reflect.DeepEqual(rest, tt.rest) {
+				t.Errorf("resolveCommand(%v): expected arguments %v, got %v", tt.args, tt.rest, rest)
+			}
+		}
+	}
+
+	if _, _, err := resolveCommand(&config.Config{Args: []string{"frobnicate"}}); err == nil {
+		t.Error("Expected an error for an unknown command")
+	}
+	if _, _, err := resolveCommand(&config.Config{Args: []string{"list"}, Watch: true}); err == nil {
+		t.Error("Expected an error for --watch with another command")
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	cfg := &config.Config{ConfigFile: "/home/me/.config/chrononoteai/config.json", BufferFile: "/tmp/buffer.md", NotesDir: "/notes"}
+
+	var out strings.Builder
+	if err := printConfig(&out, cfg, []string{"path"}); err != nil {
+		t.Fatalf("printConfig failed: %v", err)
+	}
+	if out.String() != cfg.ConfigFile+"\n" {
+		t.Errorf("Expected only the config path, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := printConfig(&out, cfg, []string{"show"}); err != nil {
+		t.Fatalf("printConfig failed: %v", err)
+	}
+	for _, want := range []string{"Config File: " + cfg.ConfigFile, "Buffer File: /tmp/buffer.md", "Notes Dir:   /notes"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the configuration, got:\n%s", want, out.String())
+		}
+	}
+
+	for _, args := range [][]string{nil, {"edit"}, {"show", "extra"}} {
+		if err := printConfig(&out, cfg, args); err == nil {
+			t.Errorf("Expected an error for config %v", args)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// defaultEditor is used when $EDITOR is not set.
+const defaultEditor = "vi"
+
+// runEdit opens the buffer in $EDITOR and processes it once the editor exits.
+// If the editor exits with an error the buffer is left as-is and nothing is processed.
+func runEdit(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	if err := launchEditor(os.Getenv("EDITOR"), cfg.BufferFile); err != nil {
+		logging.Errorf("Editor exited with an error, buffer left unprocessed: %v", err)
+		return err
+	}
+
+	return runProcess(cfg, fs, nil)
+}
+
+// launchEditor runs the editor on path and waits for it to exit.
+func launchEditor(editor, path string) error {
+	args := editorArgs(editor, path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// editorArgs splits an editor setting such as "code --wait" into a command
+// line that opens path, falling back to vi when the setting is empty.
+func editorArgs(editor, path string) []string {
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		fields = []string{defaultEditor}
+	}
+	return append(fields, path)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestEditorArgs(t *testing.T) {
+	tests := []struct {
+		editor   string
+		expected []string
+	}{
+		{editor: "", expected: []string{"vi", "/tmp/note.md"}},
+		{editor: "nano", expected: []string{"nano", "/tmp/note.md"}},
+		{editor: "code --wait", expected: []string{"code", "--wait", "/tmp/note.md"}},
+	}
+
+	for _, tt := range tests {
+		if args := editorArgs(tt.editor, "/tmp/note.md"); !slices.Equal(args, tt.expected) {
+			t.Errorf("editorArgs(%q) = %v, expected %v", tt.editor, args, tt.expected)
+		}
+	}
+}
+
+func TestRunEdit_EditorFailureKeepsBuffer(t *testing.T) {
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	buffer := "---\ntitle: Pending\ndate: 2023-10-01\n---\nNot processed yet.\n"
+	if err := os.WriteFile(bufferFile, []byte(buffer), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	t.Setenv("EDITOR", "false")
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: filepath.Join(tempDir, "notes"), ClearBuffer: true}
+
+	if err := runEdit(cfg, notes.OSFileSystem{}, nil); err == nil {
+		t.Fatal("Expected error when the editor exits non-zero, got none")
+	}
+
+	data, err := os.ReadFile(bufferFile)
+	if err != nil {
+		t.Fatalf("Failed to read buffer file: %v", err)
+	}
+	if string(data) != buffer {
+		t.Errorf("Expected buffer to be left intact, got:\n%s", data)
+	}
+	if _, err := os.Stat(cfg.NotesDir); !os.IsNotExist(err) {
+		t.Errorf("Expected no notes to be written, stat returned: %v", err)
+	}
+}
+
+func TestRunEdit_ProcessesAfterEditorExits(t *testing.T) {
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	buffer := "---\ntitle: Edited\ndate: 2023-10-01\n---\nWritten in the editor.\n"
+	if err := os.WriteFile(bufferFile, []byte(buffer), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	t.Setenv("EDITOR", "true")
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: filepath.Join(tempDir, "notes"), ClearBuffer: true}
+
+	if err := runEdit(cfg, notes.OSFileSystem{}, nil); err != nil {
+		t.Fatalf("runEdit failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.NotesDir, "2023", "10", "01.md")); err != nil {
+		t.Errorf("Expected note to be filed: %v", err)
+	}
+	data, _ := os.ReadFile(bufferFile)
+	if len(data) != 0 {
+		t.Errorf("Expected buffer to be cleared, got:\n%s", data)
+	}
+}
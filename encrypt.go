@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+	"golang.org/x/term"
+)
+
+// envPassphrase holds the passphrase for --encrypt so it isn't prompted for.
+const envPassphrase = "CHRONONOTEAI_PASSPHRASE"
+
+// passphraseInput and passphrasePrompt are where the passphrase prompt reads
+// and writes; tests replace them.
+var (
+	passphraseInput  io.Reader = os.Stdin
+	passphrasePrompt io.Writer = os.Stderr
+)
+
+// readPassphraseLine reads the passphrase without echoing it when the input is
+// a terminal, and as a plain line otherwise, such as when it is piped in.
+func readPassphraseLine() (string, error) {
+	if file, ok := passphraseInput.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		passphrase, err := term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(passphrasePrompt)
+		return string(passphrase), err
+	}
+
+	line, err := bufio.NewReader(passphraseInput).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return line, nil
+}
+
+// encryptedFileSystem wraps fs so notes in the notes directory are encrypted.
+func encryptedFileSystem(cfg *config.Config, fs notes.FileSystem) (notes.FileSystem, error) {
+	passphrase, err := readPassphrase()
+	if err != nil {
+		logging.Errorf("Error reading passphrase: %v", err)
+		return nil, err
+	}
+	return notes.NewEncryptedFileSystem(fs, cfg.NotesDir, passphrase)
+}
+
+// readPassphrase returns CHRONONOTEAI_PASSPHRASE, or asks for the passphrase
+// when it is unset.
+func readPassphrase() (string, error) {
+	if passphrase := os.Getenv(envPassphrase); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fmt.Fprint(passphrasePrompt, "Passphrase: ")
+	line, err := readPassphraseLine()
+	if err != nil {
+		return "", err
+	}
+
+	passphrase := strings.TrimRight(line, "\r\n")
+	if passphrase == "" {
+		return "", fmt.Errorf("no passphrase given; set %s or enter one at the prompt", envPassphrase)
+	}
+	return passphrase, nil
+}
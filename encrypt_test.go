@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadPassphrase(t *testing.T) {
+	t.Setenv(envPassphrase, "from-env")
+	if passphrase, err := readPassphrase(); err != nil || passphrase != "from-env" {
+		t.Errorf("Expected the passphrase from %s, got %q (%v)", envPassphrase, passphrase, err)
+	}
+
+	t.Setenv(envPassphrase, "")
+	defer func(input io.Reader, prompt io.Writer) {
+		passphraseInput, passphrasePrompt = input, prompt
+	}(passphraseInput, passphrasePrompt)
+	passphraseInput, passphrasePrompt = strings.NewReader("typed in\n"), io.Discard
+
+	if passphrase, err := readPassphrase(); err != nil || passphrase != "typed in" {
+		t.Errorf("Expected the prompted passphrase, got %q (%v)", passphrase, err)
+	}
+
+	passphraseInput = strings.NewReader("\n")
+	if _, err := readPassphrase(); err == nil {
+		t.Error("Expected an error for an empty passphrase")
+	}
+}
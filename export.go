@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runExport combines a month of saved notes into one Markdown file, or prints
+// it when --out is not given. With --format json or jsonl (--json is short
+// for --format json) every note is exported as JSON and --month is optional.
+func runExport(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	monthFlag := flags.String("month", "", "Month to export (YYYY-MM)")
+	out := flags.String("out", "", "File to write the export to; printed when unset")
+	format := flags.String("format", "markdown", "Export format: markdown, json (one array), or jsonl (one note per line)")
+	asJSON := flags.Bool("json", false, "Same as --format json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *asJSON {
+		*format = notes.FormatJSON
+	}
+	if *format != "markdown" && *format != notes.FormatJSON && *format != notes.FormatJSONL {
+		logging.Errorf("Unknown export format: %s", *format)
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	if *monthFlag == "" && *format == "markdown" {
+		logging.Errorf("export requires --month YYYY-MM")
+		return errors.New("missing --month")
+	}
+	var month time.Time
+	if *monthFlag != "" {
+		parsed, err := time.Parse("2006-01", *monthFlag)
+		if err != nil {
+			logging.Errorf("Invalid --month: %s", *monthFlag)
+			return err
+		}
+		month = parsed
+	}
+
+	var doc string
+	var count int
+	if *format != "markdown" {
+		data, n, err := notes.ExportJSON(fs, cfg.NotesDir, month, *format)
+		if err != nil {
+			logging.Errorf("Error exporting notes: %v", err)
+			return err
+		}
+		doc, count = string(data), n
+	} else {
+		md, n, err := notes.ExportMonth(fs, cfg.NotesDir, month)
+		if err != nil {
+			logging.Errorf("Error exporting notes: %v", err)
+			return err
+		}
+		doc, count = md, n
+		if count == 0 {
+			logging.Infof("No notes found for %s, the export only has a title.", *monthFlag)
+		}
+	}
+
+	if *out == "" {
+		fmt.Print(doc)
+		return nil
+	}
+
+	filePerm, _, err := cfg.Permissions()
+	if err != nil {
+		logging.Errorf("Invalid permissions: %v", err)
+		return err
+	}
+	if err := fs.WriteFile(*out, []byte(doc), filePerm); err != nil {
+		logging.Errorf("Error writing export to %s: %v", *out, err)
+		return err
+	}
+	logging.Infof("Exported %d note(s) to %s", count, *out)
+	return nil
+}
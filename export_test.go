@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestRunExport(t *testing.T) {
+	tempDir := t.TempDir()
+	notesDir := filepath.Join(tempDir, "notes")
+	for path, content := range map[string]string{
+		"2023/10/02.md": "---\ntitle: Second Day\ndate: 2023-10-02\n---\nLater.\n",
+		"2023/10/01.md": "---\ntitle: First Day\ndate: 2023-10-01\n---\nEarlier.\n",
+	} {
+		path = filepath.Join(notesDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create notes dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write note: %v", err)
+		}
+	}
+
+	out := filepath.Join(tempDir, "october.md")
+	cfg := &config.Config{NotesDir: notesDir}
+	if err := runExport(cfg, notes.OSFileSystem{}, []string{"--month", "2023-10", "--out", out}); err != nil {
+		t.Fatalf("runExport failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	first, second := strings.Index(string(data), "## First Day"), strings.Index(string(data), "## Second Day")
+	if first < 0 || second < first {
+		t.Errorf("Expected both days in date order, got:\n%s", data)
+	}
+
+	// An empty month still writes a valid file
+	if err := runExport(cfg, notes.OSFileSystem{}, []string{"--month", "2023-11", "--out", out}); err != nil {
+		t.Fatalf("runExport failed: %v", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "# Notes for November 2023\n" {
+		t.Errorf("Expected an empty export, got:\n%s", data)
+	}
+
+	if err := runExport(cfg, notes.OSFileSystem{}, nil); err == nil {
+		t.Error("Expected an error without --month")
+	}
+}
+
+func TestRunExport_JSON(t *testing.T) {
+	tempDir := t.TempDir()
+	notesDir := filepath.Join(tempDir, "notes")
+	path := filepath.Join(notesDir, "2023", "10", "01.md")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create notes dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("---\ntitle: First Day\ndate: 2023-10-01\ntags:\n  - work\n---\nEarlier.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	out := filepath.Join(tempDir, "notes.json")
+	cfg := &config.Config{NotesDir: notesDir}
+	if err := runExport(cfg, notes.OSFileSystem{}, []string{"--json", "--out", out}); err != nil {
+		t.Fatalf("runExport failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	var exported []map[string]any
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, data)
+	}
+	if len(exported) != 1 {
+		t.Fatalf("Expected 1 note, got %d", len(exported))
+	}
+	for _, key := range []string{"title", "date", "tags", "content", "path"} {
+		if _, ok := exported[0][key]; !ok {
+			t.Errorf("Expected %q in the exported note, got %v", key, exported[0])
+		}
+	}
+	if exported[0]["path"] != path {
+		t.Errorf("Expected path %q, got %v", path, exported[0]["path"])
+	}
+}
+
+func TestRunExport_Format(t *testing.T) {
+	tempDir := t.TempDir()
+	notesDir := filepath.Join(tempDir, "notes")
+	path := filepath.Join(notesDir, "2023", "10", "01.md")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create notes dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("---\ntitle: One\ndate: 2023-10-01\n---\nFirst.\n\n---\ntitle: Two\ndate: 2023-10-01\n---\nSecond.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	out := filepath.Join(tempDir, "notes.jsonl")
+	cfg := &config.Config{NotesDir: notesDir}
+	if err := runExport(cfg, notes.OSFileSystem{}, []string{"--format", "jsonl", "--out", out}); err != nil {
+		t.Fatalf("runExport failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected one line per note, got:\n%s", data)
+	}
+	for _, line := range lines {
+		var note map[string]any
+		if err := json.Unmarshal([]byte(line), &note); err != nil {
+			t.Errorf("Expected a JSON object per line, got %v for %q", err, line)
+		}
+	}
+
+	if err := runExport(cfg, notes.OSFileSystem{}, []string{"--format", "csv"}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,72 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+// Runner runs git with args in dir and returns its standard output. Failures
+// include git's standard error in the returned error.
+type Runner func(dir string, args ...string) (string, error)
+
+// ExecRunner runs the git binary on the PATH.
+func ExecRunner(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// Repo is a git working tree.
+type Repo struct {
+	Dir string
+	Run Runner // Defaults to ExecRunner
+}
+
+// NewRepo returns a Repo for dir that runs the git binary.
+func NewRepo(dir string) Repo {
+	return Repo{Dir: dir, Run: ExecRunner}
+}
+
+// CommitAll stages every change under Dir and commits it with message.
+// Changes elsewhere in the working tree, such as when Dir is inside a larger
+// repository, are left alone. It reports whether a commit was made; nothing is
+// committed when there are no changes under Dir.
+func (r Repo) CommitAll(message string) (bool, error) {
+	run := r.Run
+	if run == nil {
+		run = ExecRunner
+	}
+
+	if _, err := run(r.Dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		logging.Errorf("%s is not a git repository\n", r.Dir)
+		return false, err
+	}
+
+	status, err := run(r.Dir, "status", "--porcelain", "--", ".")
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(status) == "" {
+		logging.Infof("No changes in %s, skipping commit\n", r.Dir)
+		return false, nil
+	}
+
+	if _, err := run(r.Dir, "add", "-A", "--", "."); err != nil {
+		return false, err
+	}
+	if _, err := run(r.Dir, "commit", "-m", message, "--", "."); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,150 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeGit records git invocations and returns canned output per subcommand.
+type fakeGit struct {
+	calls   []string
+	outputs map[string]string
+	errs    map[string]error
+}
+
+func (f *fakeGit) run(dir string, args ...string) (string, error) {
+	f.calls = append(f.calls, dir+": git "+strings.Join(args, " "))
+	return f.outputs[args[0]], f.errs[args[0]]
+}
+
+func TestCommitAll(t *testing.T) {
+	fake := &fakeGit{outputs: map[string]string{"status": " M 2023/10/01.md\n"}}
+	repo := Repo{Dir: "/notes", Run: fake.run}
+
+	committed, err := repo.CommitAll("notes: 2023-10-01")
+	if err != nil {
+		t.Fatalf("CommitAll failed: %v", err)
+	}
+	if !committed {
+		t.Error("Expected a commit to be made")
+	}
+
+	expected := []string{
+		"/notes: git rev-parse --is-inside-work-tree",
+		"/notes: git status --porcelain -- .",
+		"/notes: git add -A -- .",
+		"/notes: git commit -m notes: 2023-10-01 -- .",
+	}
+	if !slices.Equal(fake.calls, expected) {
+		t.Errorf("Expected calls %v, got %v", expected, fake.calls)
+	}
+}
+
+func TestCommitAll_NoChanges(t *testing.T) {
+	fake := &fakeGit{}
+	repo := Repo{Dir: "/notes", Run: fake.run}
+
+	committed, err := repo.CommitAll("notes: 2023-10-01")
+	if err != nil {
+		t.Fatalf("CommitAll failed: %v", err)
+	}
+	if committed {
+		t.Error("Expected no commit without changes")
+	}
+	if len(fake.calls) != 2 {
+		t.Errorf("Expected only the repo and status checks, got %v", fake.calls)
+	}
+}
+
+func TestCommitAll_NotARepo(t *testing.T) {
+	notRepo := errors.New("fatal: not a git repository")
+	fake := &fakeGit{errs: map[string]error{"rev-parse": notRepo}}
+	repo := Repo{Dir: "/notes", Run: fake.run}
+
+	if _, err := repo.CommitAll("notes: 2023-10-01"); !errors.Is(err, notRepo) {
+		t.Errorf("Expected the rev-parse error, got: %v", err)
+	}
+	if len(fake.calls) != 1 {
+		t.Errorf("Expected nothing to run after the repo check, got %v", fake.calls)
+	}
+}
+
+func TestCommitAll_OnlyCommitsDir(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// The notes directory sits inside a larger repository, like a dotfiles repo
+	root := t.TempDir()
+	notesDir := filepath.Join(root, "notes")
+	dotfile := filepath.Join(root, ".bashrc")
+	note := filepath.Join(notesDir, "01.md")
+	if err := os.MkdirAll(notesDir, 0o755); err != nil {
+		t.Fatalf("Failed to create notes dir: %v", err)
+	}
+	for _, path := range []string{dotfile, note} {
+		if err := os.WriteFile(path, []byte("original\n"), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "-A"}, {"commit", "-q", "-m", "initial"}} {
+		if _, err := ExecRunner(root, args...); err != nil {
+			t.Fatalf("Failed to set up repository: %v", err)
+		}
+	}
+
+	// An unrelated change is staged and another left unstaged outside the notes directory
+	if err := os.WriteFile(dotfile, []byte("edited\n"), 0o644); err != nil {
+		t.Fatalf("Failed to edit %s: %v", dotfile, err)
+	}
+	staged := filepath.Join(root, "staged.txt")
+	if err := os.WriteFile(staged, []byte("staged\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", staged, err)
+	}
+	if _, err := ExecRunner(root, "add", "staged.txt"); err != nil {
+		t.Fatalf("Failed to stage %s: %v", staged, err)
+	}
+	if err := os.WriteFile(note, []byte("original\nadded\n"), 0o644); err != nil {
+		t.Fatalf("Failed to edit %s: %v", note, err)
+	}
+
+	committed, err := NewRepo(notesDir).CommitAll("notes: 2023-10-01")
+	if err != nil || !committed {
+		t.Fatalf("Expected a commit, got %v, %v", committed, err)
+	}
+
+	files, err := ExecRunner(root, "show", "--name-only", "--format=", "HEAD")
+	if err != nil {
+		t.Fatalf("git show failed: %v", err)
+	}
+	if strings.TrimSpace(files) != "notes/01.md" {
+		t.Errorf("Expected only the note to be committed, got:\n%s", files)
+	}
+	status, err := ExecRunner(root, "status", "--porcelain")
+	if err != nil {
+		t.Fatalf("git status failed: %v", err)
+	}
+	if !strings.Contains(status, " M .bashrc") || !strings.Contains(status, "A  staged.txt") {
+		t.Errorf("Expected the unrelated changes left as they were, got:\n%s", status)
+	}
+}
+
+func TestExecRunner_IncludesStderr(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	_, err := ExecRunner(t.TempDir(), "definitely-not-a-git-command")
+	if err == nil || !strings.Contains(err.Error(), "is not a git command") {
+		t.Errorf("Expected git's stderr in the error, got: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runImport saves the notes in a JSON or JSON Lines file, such as one written
+// by export, into the notes directory. With --file it instead reads a Markdown
+// file of ---delimited notes, such as an old journal, and files its notes the
+// way the buffer's are, leaving the file and the buffer untouched.
+func runImport(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("file", "", "Markdown file of notes to file into the notes directory, e.g. journal.md")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+
+	if *file != "" {
+		if len(args) > 0 {
+			logging.Errorf("import takes either --file or a JSON file, not both")
+			return errors.New("too many import files")
+		}
+		return importMarkdown(cfg, fs, *file)
+	}
+	if len(args) != 1 {
+		logging.Errorf("import requires one file, e.g. chrononoteai import notes.jsonl or chrononoteai import --file journal.md")
+		return errors.New("missing import file")
+	}
+
+	data, err := fs.ReadFile(args[0])
+	if err != nil {
+		logging.Errorf("Error reading %s: %v", args[0], err)
+		return err
+	}
+
+	opts, err := processOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	imported := 0
+	opts.OnWrite = func(path string, note notes.Note) {
+		imported++
+	}
+
+	if err := notes.ImportNotes(fs, data, opts); err != nil {
+		logging.Errorf("Error importing notes: %v", err)
+		return err
+	}
+	logging.Infof("Imported %d note(s) from %s", imported, args[0])
+	return nil
+}
+
+// importMarkdown files the notes in the Markdown file at path.
+func importMarkdown(cfg *config.Config, fs notes.FileSystem, path string) error {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		logging.Errorf("Error reading %s: %v", path, err)
+		return err
+	}
+
+	opts, err := processOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	summary, err := notes.ProcessNotesWithOptions(string(data), fs, opts)
+	if err != nil {
+		logging.Errorf("Error importing notes from %s: %v", path, err)
+		return err
+	}
+	logging.Infof("%s", summaryMessage(summary))
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestRunImport(t *testing.T) {
+	tempDir := t.TempDir()
+	source := filepath.Join(tempDir, "notes.jsonl")
+	if err := os.WriteFile(source, []byte(`{"title":"Migrated","date":"2023-10-01","tags":["old-tool"],"content":"From elsewhere."}`+"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write import file: %v", err)
+	}
+
+	notesDir := filepath.Join(tempDir, "notes")
+	cfg := &config.Config{NotesDir: notesDir}
+	if err := runImport(cfg, notes.OSFileSystem{}, []string{source}); err != nil {
+		t.Fatalf("runImport failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(notesDir, "2023", "10", "01.md"))
+	if err != nil {
+		t.Fatalf("Expected the imported note to be saved: %v", err)
+	}
+	if !strings.Contains(string(data), "title: Migrated") || !strings.Contains(string(data), "From elsewhere.") {
+		t.Errorf("Unexpected imported note:\n%s", data)
+	}
+
+	if err := runImport(cfg, notes.OSFileSystem{}, nil); err == nil {
+		t.Error("Expected an error without a file")
+	}
+}
+
+func TestRunImport_MarkdownFile(t *testing.T) {
+	tempDir := t.TempDir()
+	journal := filepath.Join(tempDir, "journal.md")
+	entries := `---
+title: New Year
+date: 2022-01-01
+---
+Resolutions.
+---
+title: Spring
+date: 2022-03-20
+tags: [garden]
+---
+Planted tomatoes.
+---
+title: Harvest
+date: 2022-03-20
+---
+Too early.
+`
+	if err := os.WriteFile(journal, []byte(entries), 0o644); err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	notesDir := filepath.Join(tempDir, "notes")
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: notesDir, ClearBuffer: true}
+	if err := runImport(cfg, notes.OSFileSystem{}, []string{"--file", journal}); err != nil {
+		t.Fatalf("runImport failed: %v", err)
+	}
+
+	expected := map[string][]string{
+		filepath.Join(notesDir, "2022", "01", "01.md"): {"New Year"},
+		filepath.Join(notesDir, "2022", "03", "20.md"): {"Spring", "Harvest"},
+	}
+	for path, titles := range expected {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Expected %s to be created: %v", path, err)
+		}
+		saved, err := notes.SplitNotesFromFile(string(data))
+		if err != nil || len(saved) != len(titles) {
+			t.Fatalf("Expected %d notes in %s, got %v (%v)", len(titles), path, saved, err)
+		}
+		for i, title := range titles {
+			if saved[i].Title != title {
+				t.Errorf("Expected %q in %s, got %q", title, path, saved[i].Title)
+			}
+		}
+	}
+
+	// The journal is left as it was and the buffer isn't touched
+	if data, err := os.ReadFile(journal); err != nil || string(data) != entries {
+		t.Errorf("Expected the journal to be left alone, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(bufferFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the buffer not to be created, stat returned: %v", err)
+	}
+
+	if err := runImport(cfg, notes.OSFileSystem{}, []string{"--file", journal, "notes.jsonl"}); err == nil {
+		t.Error("Expected an error with both --file and a JSON file")
+	}
+}
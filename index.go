@@ -0,0 +1,24 @@
+package main
+
+import (
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runIndex rebuilds the notes directory's index from every saved note, for
+// when notes were changed outside chrononoteai. Processing only updates the
+// entries for the files it writes.
+func runIndex(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	opts, err := processOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := notes.RebuildIndexWithOptions(fs, cfg.NotesDir, opts); err != nil {
+		logging.Errorf("Error rebuilding note index: %v", err)
+		return err
+	}
+	logging.Infof("Rebuilt %s in %s", notes.IndexFileName, cfg.NotesDir)
+	return nil
+}
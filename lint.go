@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// errLintIssues is returned by lint when any saved note has a problem, so the
+// command exits non-zero.
+var errLintIssues = errors.New("notes have problems")
+
+// runLint checks every saved note with the same validation used when the
+// buffer is processed and prints each problem found.
+func runLint(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	return lintNotes(os.Stdout, cfg, fs)
+}
+
+// lintNotes writes each problem with the notes under cfg.NotesDir to w as
+// path:line: title: problem, returning errLintIssues if there were any.
+func lintNotes(w io.Writer, cfg *config.Config, fs notes.FileSystem) error {
+	opts, err := processOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	issues, err := notes.LintNotes(fs, cfg.NotesDir, opts)
+	if err != nil {
+		logging.Errorf("Error checking notes: %v", err)
+		return err
+	}
+	for _, issue := range issues {
+		fmt.Fprintln(w, issue)
+	}
+
+	if len(issues) > 0 {
+		logging.Errorf("Found %d %s in %s", len(issues), plural(len(issues), "problem"), cfg.NotesDir)
+		return errLintIssues
+	}
+	logging.Infof("No problems found in %s", cfg.NotesDir)
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestLintNotesCommand(t *testing.T) {
+	notesDir := t.TempDir()
+	path := filepath.Join(notesDir, "2023", "10", "01.md")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create notes dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("---\ntitle: Fine\ndate: 2023-10-01\n---\nOK.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	cfg := &config.Config{NotesDir: notesDir}
+	var out strings.Builder
+	if err := lintNotes(&out, cfg, notes.OSFileSystem{}); err != nil {
+		t.Fatalf("lintNotes failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing printed for clean notes, got:\n%s", out.String())
+	}
+
+	// A manual edit drops the title
+	if err := os.WriteFile(path, []byte("---\ntitle: Fine\ndate: 2023-10-01\n---\nOK.\n---\ndate: 2023-10-01\n---\nEdited by hand.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+	out.Reset()
+	if err := lintNotes(&out, cfg, notes.OSFileSystem{}); !errors.Is(err, errLintIssues) {
+		t.Errorf("Expected errLintIssues, got %v", err)
+	}
+	if expected := path + ":6: missing title\n"; out.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runList prints a table of every saved note sorted by date.
+func runList(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	stored, err := notes.ListNotes(fs, cfg.NotesDir)
+	if err != nil {
+		logging.Errorf("Error listing notes: %v", err)
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tTITLE\tTAGS")
+	for _, note := range stored {
+		if note.Err != nil {
+			fmt.Fprintf(w, "?\t?\twarning: %s: %v\n", note.Path, note.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", note.Date, note.Title, strings.Join(note.Tags, ", "))
+	}
+
+	return w.Flush()
+}
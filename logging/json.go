@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSONLogger is a Logger writing each message as a JSON object on its own
+// line, with time, level, and msg fields plus any fields added with With.
+// It is gated by the level set with SetLevel, like StdLogger.
+type JSONLogger struct {
+	w      io.Writer
+	mu     *sync.Mutex // Shared with loggers made by With so lines never interleave
+	fields []any
+}
+
+// NewJSONLogger returns a JSONLogger writing to w.
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{w: w, mu: new(sync.Mutex)}
+}
+
+// With returns a logger that adds the key-value pairs in args to every message.
+func (l *JSONLogger) With(args ...any) Logger {
+	return &JSONLogger{w: l.w, mu: l.mu, fields: append(l.fields[:len(l.fields):len(l.fields)], args...)}
+}
+
+// Errorf logs an error. Errors are logged at every level.
+func (l *JSONLogger) Errorf(format string, args ...any) {
+	l.output(LevelError, format, args...)
+}
+
+// Warnf logs a problem that doesn't stop processing.
+func (l *JSONLogger) Warnf(format string, args ...any) {
+	if Enabled(LevelWarn) {
+		l.output(LevelWarn, format, args...)
+	}
+}
+
+// Infof logs routine progress.
+func (l *JSONLogger) Infof(format string, args ...any) {
+	if Enabled(LevelInfo) {
+		l.output(LevelInfo, format, args...)
+	}
+}
+
+// Debugf logs details that are only useful when investigating a problem.
+func (l *JSONLogger) Debugf(format string, args ...any) {
+	if Enabled(LevelDebug) {
+		l.output(LevelDebug, format, args...)
+	}
+}
+
+func (l *JSONLogger) output(level Level, format string, args ...any) {
+	entry := make(map[string]any, 3+len(l.fields)/2)
+	for i := 0; i+1 < len(l.fields); i += 2 {
+		entry[fmt.Sprint(l.fields[i])] = l.fields[i+1]
+	}
+	entry["time"] = time.Now().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = strings.TrimSpace(fmt.Sprintf(format, args...))
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]string{"level": LevelError.String(), "msg": "failed to encode log entry: " + err.Error()})
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w.Write(append(line, '\n'))
+}
+
+// With returns a logger that adds the key-value pairs in args, such as
+// "title", note.Title, to every message, when l supports fields. Other
+// loggers are returned unchanged.
+func With(l Logger, args ...any) Logger {
+	if fl, ok := l.(interface{ With(args ...any) Logger }); ok {
+		return fl.With(args...)
+	}
+	return l
+}
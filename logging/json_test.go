@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONLogger(t *testing.T) {
+	captureLogs(t, LevelInfo)
+	var logs bytes.Buffer
+	logger := NewJSONLogger(&logs)
+
+	With(logger, "title", "Standup", "path", "2024/09/12.md").Infof("Wrote note to file %s\n", "2024/09/12.md")
+	logger.Debugf("Reordering notes by time")
+	logger.Errorf("Failed to read file")
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines with debug hidden, got:\n%s", logs.String())
+	}
+
+	var entry map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Expected a JSON object, got %q: %v", lines[0], err)
+	}
+	expected := map[string]string{"level": "info", "msg": "Wrote note to file 2024/09/12.md", "title": "Standup", "path": "2024/09/12.md"}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s %q, got %q", key, value, entry[key])
+		}
+	}
+	if _, err := time.Parse(time.RFC3339Nano, entry["time"]); err != nil {
+		t.Errorf("Expected an RFC 3339 time field, got %q", entry["time"])
+	}
+
+	// Fields added with With don't leak into the parent logger
+	if err := json.Unmarshal([]byte(lines[1]), &entry); err != nil {
+		t.Fatalf("Expected a JSON object, got %q: %v", lines[1], err)
+	}
+	if entry["level"] != "error" || strings.Contains(lines[1], "title") {
+		t.Errorf("Expected a plain error entry, got %s", lines[1])
+	}
+}
+
+func TestWithIgnoresStdLogger(t *testing.T) {
+	logs := captureLogs(t, LevelInfo)
+
+	With(StdLogger{}, "title", "Standup").Infof("Wrote note")
+	if !strings.HasSuffix(logs.String(), "Wrote note\n") {
+		t.Errorf("Expected text messages without fields, got %q", logs.String())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := map[string]Format{"": FormatText, "text": FormatText, "JSON": FormatJSON}
+	for name, expected := range tests {
+		if format, err := ParseFormat(name); err != nil || format != expected {
+			t.Errorf("ParseFormat(%q): expected %s, got %s, %v", name, expected, format, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
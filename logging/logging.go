@@ -0,0 +1,195 @@
+// Package logging gates the standard log package by level so routine
+// progress messages can be silenced or expanded without touching errors. The
+// Logger interface lets callers capture or redirect what a package logs.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is how much is logged; each level includes the ones before it.
+type Level int32
+
+const (
+	LevelError Level = iota // Only errors, which are always logged
+	LevelWarn               // Errors and warnings
+	LevelInfo               // Progress messages as well; the default
+	LevelDebug              // Everything, including per-step details
+)
+
+var levelNames = map[Level]string{
+	LevelError: "error",
+	LevelWarn:  "warn",
+	LevelInfo:  "info",
+	LevelDebug: "debug",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int32(l))
+}
+
+// ParseLevel returns the level with the given name, ignoring case. An empty
+// name is the default, info.
+func ParseLevel(name string) (Level, error) {
+	if name == "" {
+		return LevelInfo, nil
+	}
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q, expected error, warn, info, or debug", name)
+}
+
+var current atomic.Int32
+
+func init() {
+	current.Store(int32(LevelInfo))
+}
+
+// SetLevel changes the level for all later log calls.
+func SetLevel(level Level) {
+	current.Store(int32(level))
+}
+
+// Enabled reports whether messages at level are currently logged.
+func Enabled(level Level) bool {
+	return level <= Level(current.Load())
+}
+
+// Logger receives leveled log messages. Packages that log take one so callers
+// can capture or redirect their output; a nil Logger means Default.
+type Logger interface {
+	Errorf(format string, args ...any)
+	Warnf(format string, args ...any)
+	Infof(format string, args ...any)
+	Debugf(format string, args ...any)
+}
+
+// StdLogger is a Logger writing through a standard library logger, gated by the
+// level set with SetLevel. A nil Log writes through the standard logger, so its
+// flags and writer still apply.
+type StdLogger struct {
+	Log *log.Logger
+}
+
+// Errorf logs an error. Errors are logged at every level.
+func (l StdLogger) Errorf(format string, args ...any) {
+	l.output(format, args...)
+}
+
+// Warnf logs a problem that doesn't stop processing.
+func (l StdLogger) Warnf(format string, args ...any) {
+	if Enabled(LevelWarn) {
+		l.output(format, args...)
+	}
+}
+
+// Infof logs routine progress.
+func (l StdLogger) Infof(format string, args ...any) {
+	if Enabled(LevelInfo) {
+		l.output(format, args...)
+	}
+}
+
+// Debugf logs details that are only useful when investigating a problem.
+func (l StdLogger) Debugf(format string, args ...any) {
+	if Enabled(LevelDebug) {
+		l.output(format, args...)
+	}
+}
+
+func (l StdLogger) output(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if l.Log == nil {
+		log.Output(3, msg)
+		return
+	}
+	l.Log.Output(3, msg)
+}
+
+// defaultLogger holds the Logger used by the package-level functions.
+var defaultLogger atomic.Pointer[Logger]
+
+// Default returns the Logger used by the package-level functions, a StdLogger
+// unless SetDefault replaced it.
+func Default() Logger {
+	if l := defaultLogger.Load(); l != nil {
+		return *l
+	}
+	return StdLogger{}
+}
+
+// SetDefault replaces the Logger used by the package-level functions. A nil
+// Logger restores the StdLogger.
+func SetDefault(l Logger) {
+	if l == nil {
+		defaultLogger.Store(nil)
+		return
+	}
+	defaultLogger.Store(&l)
+}
+
+// Format is how log messages are written.
+type Format string
+
+const (
+	FormatText Format = "text" // Plain lines through the standard logger; the default
+	FormatJSON Format = "json" // One JSON object per line, see JSONLogger
+)
+
+// ParseFormat returns the format with the given name, ignoring case. An empty
+// name is the default, text.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(strings.ToLower(name)); format {
+	case "":
+		return FormatText, nil
+	case FormatText, FormatJSON:
+		return format, nil
+	}
+	return FormatText, fmt.Errorf("unknown log format %q, expected text or json", name)
+}
+
+// NewLogger returns a Logger writing messages in format to w.
+func NewLogger(format Format, w io.Writer) Logger {
+	if format == FormatJSON {
+		return NewJSONLogger(w)
+	}
+	return StdLogger{Log: log.New(w, "", log.LstdFlags)}
+}
+
+// OrDefault returns l, or Default when l is nil.
+func OrDefault(l Logger) Logger {
+	if l == nil {
+		return Default()
+	}
+	return l
+}
+
+// Errorf logs an error through the default Logger.
+func Errorf(format string, args ...any) {
+	Default().Errorf(format, args...)
+}
+
+// Warnf logs a warning through the default Logger.
+func Warnf(format string, args ...any) {
+	Default().Warnf(format, args...)
+}
+
+// Infof logs routine progress through the default Logger.
+func Infof(format string, args ...any) {
+	Default().Infof(format, args...)
+}
+
+// Debugf logs details through the default Logger.
+func Debugf(format string, args ...any) {
+	Default().Debugf(format, args...)
+}
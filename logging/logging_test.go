@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureLogs sets the level and records everything logged until the test ends.
+func captureLogs(t *testing.T, level Level) *bytes.Buffer {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	SetLevel(level)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		SetLevel(LevelInfo)
+	})
+	return &logs
+}
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		level    Level
+		expected []string
+	}{
+		{level: LevelError, expected: []string{"error"}},
+		{level: LevelWarn, expected: []string{"error", "warn"}},
+		{level: LevelInfo, expected: []string{"error", "warn", "info"}},
+		{level: LevelDebug, expected: []string{"error", "warn", "info", "debug"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			logs := captureLogs(t, tt.level)
+
+			Errorf("error message")
+			Warnf("warn message")
+			Infof("info message")
+			Debugf("debug message")
+
+			for _, name := range []string{"error", "warn", "info", "debug"} {
+				shown := strings.Contains(logs.String(), name+" message")
+				expected := strings.Contains(strings.Join(tt.expected, " "), name)
+				if shown != expected {
+					t.Errorf("At level %s: expected %s message shown=%v, got:\n%s", tt.level, name, expected, logs.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDebugHiddenAtInfo(t *testing.T) {
+	logs := captureLogs(t, LevelInfo)
+
+	Debugf("Reordering notes by time in %s", "01.md")
+	Infof("Wrote note to file %s", "01.md")
+
+	if strings.Contains(logs.String(), "Reordering") {
+		t.Errorf("Expected debug messages to be hidden at info level, got:\n%s", logs.String())
+	}
+	if !strings.Contains(logs.String(), "Wrote note to file 01.md") {
+		t.Errorf("Expected info messages to be shown, got:\n%s", logs.String())
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]Level{
+		"":      LevelInfo,
+		"error": LevelError,
+		"WARN":  LevelWarn,
+		"info":  LevelInfo,
+		"Debug": LevelDebug,
+	}
+	for name, expected := range tests {
+		level, err := ParseLevel(name)
+		if err != nil || level != expected {
+			t.Errorf("ParseLevel(%q): expected %s, got %s, %v", name, expected, level, err)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestStdLoggerWritesToItsLogger(t *testing.T) {
+	global := captureLogs(t, LevelInfo)
+	var logs bytes.Buffer
+	logger := StdLogger{Log: log.New(&logs, "", 0)}
+
+	logger.Infof("Wrote note to file %s", "01.md")
+	logger.Debugf("Reordering notes by time in %s", "01.md")
+
+	if logs.String() != "Wrote note to file 01.md\n" {
+		t.Errorf("Expected only the info message, got:\n%s", logs.String())
+	}
+	if global.Len() != 0 {
+		t.Errorf("Expected nothing on the standard logger, got:\n%s", global.String())
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	global := captureLogs(t, LevelInfo)
+	var logs bytes.Buffer
+	SetDefault(StdLogger{Log: log.New(&logs, "", 0)})
+	t.Cleanup(func() { SetDefault(nil) })
+
+	Errorf("Failed to read file %s", "01.md")
+	if logs.String() != "Failed to read file 01.md\n" || global.Len() != 0 {
+		t.Errorf("Expected package functions to use the new default, got %q and %q", logs.String(), global.String())
+	}
+
+	SetDefault(nil)
+	Errorf("Failed again")
+	if !strings.Contains(global.String(), "Failed again") {
+		t.Errorf("Expected a nil default to restore the standard logger, got:\n%s", global.String())
+	}
+}
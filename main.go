@@ -1,38 +1,390 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
+	"github.com/jasonmichels/chrononoteai/ai"
 	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
 	"github.com/jasonmichels/chrononoteai/notes"
 )
 
+// command runs a subcommand with the arguments that follow its name.
+type command func(cfg *config.Config, fs notes.FileSystem, args []string) error
+
+// commands maps subcommand names to their implementations.
+var commands = map[string]command{
+	"process":   runProcess,
+	"list":      runList,
+	"search":    runSearch,
+	"list-tags": runListTags,
+	"tags":      runTags,
+	"edit":      runEdit,
+	"stats":     runStats,
+	"export":    runExport,
+	"today":     runToday,
+	"publish":   runPublish,
+	"watch":     runWatch,
+	"config":    runConfig,
+	"import":    runImport,
+	"new":       runNew,
+	"resolve":   runResolve,
+	"merge":     runMerge,
+	"lint":      runLint,
+	"index":     runIndex,
+}
+
 func main() {
 	cfg, err := config.Initialize()
 	if err != nil {
 		log.Fatalf("Error initializing configuration: %v", err)
 	}
 
-	fs := notes.OSFileSystem{}
+	if cfg.Version {
+		fmt.Println(versionString())
+		return
+	}
 
-	data, err := fs.ReadFile(cfg.BufferFile)
+	run, args, err := resolveCommand(cfg)
 	if err != nil {
-		log.Printf("Error reading buffer file: %v", err)
-		return
+		logging.Errorf("%v", err)
+		os.Exit(1)
+	}
+
+	var fs notes.FileSystem = notes.OSFileSystem{}
+	if cfg.Encrypt {
+		fs, err = encryptedFileSystem(cfg, fs)
+		if err != nil {
+			logging.Errorf("Error setting up encryption: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	// Logged through the configured logger so --log-format and --quiet apply
+	if err := run(cfg, fs, args); err != nil {
+		logging.Errorf("%v", err)
+		os.Exit(1)
+	}
+}
+
+// resolveCommand picks the command named by the first positional argument and
+// returns it with the arguments that follow. Processing the buffer is the
+// default when no command is given, and --watch selects the watch command.
+func resolveCommand(cfg *config.Config) (command, []string, error) {
+	name, args := "process", []string(nil)
+	if len(cfg.Args) > 0 {
+		name, args = cfg.Args[0], cfg.Args[1:]
 	}
 
-	err = notes.ProcessNotes(string(data), cfg.NotesDir, fs)
+	// --watch is the watch command under another name
+	if cfg.Watch {
+		if name != "process" && name != "watch" {
+			return nil, nil, fmt.Errorf("--watch can't be used with the %s command", name)
+		}
+		name = "watch"
+	}
+
+	run, ok := commands[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown command: %s", name)
+	}
+	return run, args, nil
+}
+
+// runProcess files the notes in the buffer and clears it.
+func runProcess(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	return processBuffer(context.Background(), cfg, fs)
+}
+
+// processBuffer files the notes in the buffers and clears them, stopping
+// between notes if ctx is canceled. Every buffer is processed together in the
+// order listed, as though they were one file. Files matching BufferGlob are
+// then each processed on their own and removed once cleared.
+func processBuffer(ctx context.Context, cfg *config.Config, fs notes.FileSystem) error {
+	buffers, err := readBuffers(fs, cfg.Buffers())
 	if err != nil {
-		log.Printf("Error processing notes: %v", err)
-		return
+		return err
+	}
+
+	opts, err := processOptions(cfg)
+	if err != nil {
+		return err
 	}
 
-	log.Println("Notes processed successfully.")
+	var written []string
+	opts.OnWrite = func(path string, note notes.Note) {
+		written = append(written, note.Date.String())
+	}
 
-	err = fs.WriteFile(cfg.BufferFile, []byte(""), 0o644)
+	if err := saveBuffers(ctx, cfg, fs, opts, buffers, clearBuffer); err != nil {
+		return err
+	}
+	if err := processBufferGlob(ctx, cfg, fs, opts); err != nil {
+		return err
+	}
+
+	if cfg.GitCommit {
+		if len(written) == 0 {
+			logging.Infof("No notes written, skipping git commit.")
+			return nil
+		}
+		return commitNotes(cfg.NotesDir, written)
+	}
+
+	return nil
+}
+
+// saveBuffers processes the buffers as one file, then archives them and
+// empties each with clear unless the config keeps them.
+func saveBuffers(ctx context.Context, cfg *config.Config, fs notes.FileSystem, opts notes.Options, buffers []buffer, clear func(fs notes.FileSystem, path string, data []byte)) error {
+	data := joinBuffers(buffers)
+	if bufferEmpty(data) {
+		logging.Infof("Buffer empty, nothing to process.")
+		return nil
+	}
+
+	summary, err := notes.ProcessNotesWithOptionsContext(ctx, string(data), fs, opts)
 	if err != nil {
-		log.Printf("Error clearing buffer file: %v", err)
+		logging.Errorf("Error processing notes: %v", err)
+		return err
+	}
+
+	if cfg.DryRun {
+		logging.Infof("Dry run complete, buffer file left untouched.")
+		return nil
+	}
+
+	logging.Infof("%s", summaryMessage(summary))
+
+	if cfg.ClearBuffer {
+		if cfg.ArchiveBuffer {
+			if _, err := archiveBuffer(fs, cfg.ResolveArchiveDir(), data, opts.FilePerm, opts.DirPerm); err != nil {
+				logging.Errorf("Error archiving buffer, leaving it untouched: %v", err)
+				return err
+			}
+		}
+		if archiveFile := cfg.ResolveArchiveFile(); archiveFile != "" {
+			if err := appendArchive(fs, archiveFile, data, opts.FilePerm, opts.DirPerm); err != nil {
+				logging.Errorf("Error archiving buffer, leaving it untouched: %v", err)
+				return err
+			}
+		}
+		for _, buf := range buffers {
+			clear(fs, buf.path, buf.data)
+		}
 	} else {
-		log.Println("Buffer file cleared successfully.")
+		logging.Infof("Buffer file preserved, --no-clear or clear_buffer: false is set.")
+	}
+
+	return nil
+}
+
+// processBufferGlob processes each file matching BufferGlob as a separate
+// buffer in sorted filename order. A file that fails is left in place and the
+// rest are still processed; the failures are reported together at the end.
+func processBufferGlob(ctx context.Context, cfg *config.Config, fs notes.FileSystem, opts notes.Options) error {
+	files, err := cfg.BufferGlobFiles()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := fs.ReadFile(path)
+		if err != nil {
+			logging.Errorf("Error reading buffer file %s: %v", path, err)
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		// An empty capture has nothing to process, so saveBuffers wouldn't remove it
+		if bufferEmpty(data) {
+			if cfg.ClearBuffer && !cfg.DryRun {
+				removeBuffer(fs, path, data)
+			}
+			continue
+		}
+
+		logging.Infof("Processing buffer file %s.", path)
+		if err := saveBuffers(ctx, cfg, fs, opts, []buffer{{path: path, data: data}}, removeBuffer); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		logging.Errorf("Failed to process %d of %d buffer %s matching %s.", len(errs), len(files), plural(len(files), "file"), cfg.BufferGlob)
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// processOptions builds the note processing options from the configuration.
+func processOptions(cfg *config.Config) (notes.Options, error) {
+	opts := notes.Options{
+		NotesDir:    cfg.NotesDir,
+		DateLayouts: cfg.DateLayouts,
+		DryRun:      cfg.DryRun,
+		Force:       cfg.Force,
+		Backup:      cfg.Backup,
+
+		DefaultToday:    cfg.DefaultToday,
+		Strict:          cfg.Strict,
+		RequireContent:  cfg.RequireContent,
+		MinYear:         cfg.MinYear,
+		MaxYear:         cfg.MaxYear,
+		DefaultTags:     cfg.DefaultTags,
+		LowercaseTags:   cfg.LowercaseTags,
+		SanitizeTags:    cfg.SanitizeTags,
+		PathTemplate:    cfg.PathTemplate,
+		InlineSingleTag: cfg.InlineSingleTag,
+		NoteSeparator:   cfg.NoteSeparator,
+		NoFrontMatter:   cfg.NoFrontMatter,
+		WordsPerMinute:  cfg.WordsPerMinute,
+		Concurrency:     cfg.Concurrency,
+		Logger:          cfg.Logger,
+
+		SingleFrontMatterPerFile: cfg.SingleFrontMatterPerFile,
+		UniqueTitlePerDay:        cfg.UniqueTitlePerDay,
+	}
+
+	tagPattern, err := cfg.TagRegexp()
+	if err != nil {
+		logging.Errorf("Invalid tag pattern: %v", err)
+		return notes.Options{}, err
+	}
+	opts.TagPattern = tagPattern
+
+	opts.Undated, err = notes.ParseUndatedPolicy(cfg.UndatedPolicy)
+	if err != nil {
+		logging.Errorf("Invalid undated policy: %v", err)
+		return notes.Options{}, err
+	}
+
+	opts.FilePerm, opts.DirPerm, err = cfg.Permissions()
+	if err != nil {
+		logging.Errorf("Invalid permissions: %v", err)
+		return notes.Options{}, err
+	}
+
+	if cfg.AITags {
+		if apiKey := cfg.ResolveOpenAIAPIKey(); apiKey != "" {
+			opts.TagSuggester = ai.NewOpenAIClient(apiKey)
+		} else {
+			logging.Warnf("No OpenAI API key configured, skipping tag suggestions.")
+		}
+	}
+
+	return opts, nil
+}
+
+// summaryMessage describes what a run saved, e.g.
+// "Processed 12 notes across 5 files (3 created, 2 appended), 0 skipped."
+func summaryMessage(summary notes.ProcessSummary) string {
+	return fmt.Sprintf("Processed %d %s across %d %s (%d created, %d appended), %d skipped.",
+		summary.Written, plural(summary.Written, "note"), len(summary.Files), plural(len(summary.Files), "file"),
+		summary.Created, summary.Appended, summary.Skipped)
+}
+
+// plural adds an "s" to word unless n is 1.
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// buffer is the contents of one buffer file as read for processing.
+type buffer struct {
+	path string
+	data []byte
+}
+
+// readBuffers reads each buffer file in order. A missing file is skipped with
+// a warning so one unsynced device doesn't hold up the rest.
+func readBuffers(fs notes.FileSystem, paths []string) ([]buffer, error) {
+	var buffers []buffer
+	for _, path := range paths {
+		data, err := fs.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			logging.Warnf("Warning: buffer file %s doesn't exist, skipping it.", path)
+			continue
+		}
+		if err != nil {
+			logging.Errorf("Error reading buffer file %s: %v", path, err)
+			return nil, err
+		}
+		buffers = append(buffers, buffer{path: path, data: data})
+	}
+	return buffers, nil
+}
+
+// joinBuffers concatenates the buffers' contents, starting each on a new line
+// so its first note's front matter is still recognized.
+func joinBuffers(buffers []buffer) []byte {
+	var data []byte
+	for _, buf := range buffers {
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+		data = append(data, buf.data...)
+	}
+	return data
+}
+
+// bufferEmpty reports whether data holds no notes: nothing but whitespace and
+// stray "---" lines.
+func bufferEmpty(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && line != "---" {
+			return false
+		}
+	}
+	return true
+}
+
+// clearBuffer removes the processed notes from the buffer at path. Only what
+// was processed is cleared so notes saved in the meantime aren't lost.
+func clearBuffer(fs notes.FileSystem, path string, data []byte) {
+	err := fs.TruncateIfUnchanged(path, data)
+	switch {
+	case errors.Is(err, notes.ErrBufferChanged):
+		logging.Infof("Buffer file %s changed while processing, leaving it for the next run.", path)
+	case err != nil:
+		logging.Errorf("Error clearing buffer file %s: %v", path, err)
+	default:
+		logging.Infof("Buffer file %s cleared successfully.", path)
+	}
+}
+
+// removeBuffer clears the buffer at path like clearBuffer, then deletes the
+// file if nothing was added to it while processing.
+func removeBuffer(fs notes.FileSystem, path string, data []byte) {
+	err := fs.TruncateIfUnchanged(path, data)
+	switch {
+	case errors.Is(err, notes.ErrBufferChanged):
+		logging.Infof("Buffer file %s changed while processing, leaving it for the next run.", path)
+		return
+	case err != nil:
+		logging.Errorf("Error clearing buffer file %s: %v", path, err)
+		return
+	}
+
+	if rest, err := fs.ReadFile(path); err != nil || len(rest) > 0 {
+		logging.Infof("Buffer file %s cleared successfully.", path)
+		return
+	}
+	if err := fs.RemoveFile(path); err != nil {
+		logging.Errorf("Error removing buffer file %s: %v", path, err)
+		return
 	}
+	logging.Infof("Buffer file %s removed successfully.", path)
 }
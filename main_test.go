@@ -0,0 +1,295 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// editingFileSystem simulates the user saving a new note to the buffer while notes are being written.
+type editingFileSystem struct {
+	notes.OSFileSystem
+	bufferFile string
+	edit       string
+}
+
+func (fs editingFileSystem) AppendToFile(path string, data string, perm os.FileMode) error {
+	if err := fs.OSFileSystem.AppendToFile(fs.bufferFile, fs.edit, perm); err != nil {
+		return err
+	}
+	return fs.OSFileSystem.AppendToFile(path, data, perm)
+}
+
+func TestRunProcess_KeepsNotesAddedDuringProcessing(t *testing.T) {
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	processed := "---\ntitle: Processed\ndate: 2023-10-01\n---\nAlready in the buffer.\n"
+	if err := os.WriteFile(bufferFile, []byte(processed), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	added := "---\ntitle: Added\ndate: 2023-10-02\n---\nSaved while processing.\n"
+	fs := editingFileSystem{bufferFile: bufferFile, edit: added}
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: filepath.Join(tempDir, "notes"), ClearBuffer: true}
+
+	if err := runProcess(cfg, fs, nil); err != nil {
+		t.Fatalf("runProcess failed: %v", err)
+	}
+
+	data, err := os.ReadFile(bufferFile)
+	if err != nil {
+		t.Fatalf("Failed to read buffer file: %v", err)
+	}
+	if string(data) != added {
+		t.Errorf("Expected only the newly added note to remain in the buffer, got:\n%s", data)
+	}
+}
+
+func TestRunProcess_GitCommit(t *testing.T) {
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	buffer := "---\ntitle: Later\ndate: 2023-10-03\n---\nContent.\n---\ntitle: Earlier\ndate: 2023-10-01\n---\nContent.\n"
+	if err := os.WriteFile(bufferFile, []byte(buffer), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	var calls []string
+	original := gitRunner
+	gitRunner = func(dir string, args ...string) (string, error) {
+		calls = append(calls, strings.Join(args, " "))
+		if args[0] == "status" {
+			return "?? 2023/\n", nil
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { gitRunner = original })
+
+	notesDir := filepath.Join(tempDir, "notes")
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: notesDir, ClearBuffer: true, GitCommit: true}
+	if err := runProcess(cfg, notes.OSFileSystem{}, nil); err != nil {
+		t.Fatalf("runProcess failed: %v", err)
+	}
+
+	if len(calls) == 0 || calls[len(calls)-1] != "commit -m notes: 2023-10-01 to 2023-10-03 -- ." {
+		t.Errorf("Expected a commit naming the date range, got %v", calls)
+	}
+
+	// Nothing is committed when no notes were written
+	calls = nil
+	if err := runProcess(cfg, notes.OSFileSystem{}, nil); err != nil {
+		t.Fatalf("runProcess failed: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no git commands for an empty buffer, got %v", calls)
+	}
+}
+
+func TestCommitMessage(t *testing.T) {
+	tests := map[string][]string{
+		"notes: update":                   {""},
+		"notes: 2024-09-12":               {"2024-09-12", "2024-09-12"},
+		"notes: 2024-09-10 to 2024-09-12": {"2024-09-12", "2024-09-10", "", "2024-09-11"},
+	}
+
+	for expected, dates := range tests {
+		if got := commitMessage(dates); got != expected {
+			t.Errorf("commitMessage(%v): expected %q, got %q", dates, expected, got)
+		}
+	}
+}
+
+func TestSummaryMessage(t *testing.T) {
+	tests := map[string]notes.ProcessSummary{
+		"Processed 3 notes across 2 files (1 created, 1 appended), 0 skipped.": {Written: 3, Files: []string{"a.md", "b.md"}, Created: 1, Appended: 1},
+		"Processed 1 note across 1 file (0 created, 1 appended), 1 skipped.":   {Written: 1, Skipped: 1, Files: []string{"a.md"}, Appended: 1},
+		"Processed 0 notes across 0 files (0 created, 0 appended), 2 skipped.": {Skipped: 2},
+	}
+
+	for expected, summary := range tests {
+		if got := summaryMessage(summary); got != expected {
+			t.Errorf("summaryMessage(%+v): expected %q, got %q", summary, expected, got)
+		}
+	}
+}
+
+func TestRunProcess_NoClear(t *testing.T) {
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	buffer := "---\ntitle: Kept\ndate: 2023-10-01\n---\nStill in the buffer.\n"
+	if err := os.WriteFile(bufferFile, []byte(buffer), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	notesDir := filepath.Join(tempDir, "notes")
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: notesDir, ClearBuffer: false}
+	if err := runProcess(cfg, notes.OSFileSystem{}, nil); err != nil {
+		t.Fatalf("runProcess failed: %v", err)
+	}
+
+	data, err := os.ReadFile(bufferFile)
+	if err != nil {
+		t.Fatalf("Failed to read buffer file: %v", err)
+	}
+	if string(data) != buffer {
+		t.Errorf("Expected the buffer to be preserved, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(notesDir, "2023", "10", "01.md")); err != nil {
+		t.Errorf("Expected the note to be written: %v", err)
+	}
+}
+
+// emptyBufferFileSystem reads every file as data and records clears.
+type emptyBufferFileSystem struct {
+	notes.OSFileSystem
+	data    string
+	cleared []string
+}
+
+func (fs *emptyBufferFileSystem) ReadFile(path string) ([]byte, error) {
+	return []byte(fs.data), nil
+}
+
+func (fs *emptyBufferFileSystem) TruncateIfUnchanged(path string, expected []byte) error {
+	fs.cleared = append(fs.cleared, path)
+	return nil
+}
+
+func TestRunProcess_EmptyBuffer(t *testing.T) {
+	for _, data := range []string{"", " \n\t\n", "---\n---\n", "\n---\n  \n"} {
+		fs := &emptyBufferFileSystem{data: data}
+		notesDir := filepath.Join(t.TempDir(), "notes")
+		cfg := &config.Config{BufferFile: "buffer.md", NotesDir: notesDir, ClearBuffer: true, ArchiveBuffer: true}
+		if err := runProcess(cfg, fs, nil); err != nil {
+			t.Errorf("runProcess(%q) failed: %v", data, err)
+		}
+		if len(fs.cleared) != 0 {
+			t.Errorf("Expected an empty buffer %q not to be cleared, got %v", data, fs.cleared)
+		}
+		if _, err := os.Stat(notesDir); !os.IsNotExist(err) {
+			t.Errorf("Expected nothing to be written for %q, got %v", data, err)
+		}
+	}
+}
+
+func TestRunProcess_MultipleBuffers(t *testing.T) {
+	tempDir := t.TempDir()
+	laptop := filepath.Join(tempDir, "laptop.md")
+	phone := filepath.Join(tempDir, "phone.md")
+	// The laptop buffer doesn't end in a newline, which must not swallow the phone's first note
+	if err := os.WriteFile(laptop, []byte("---\ntitle: From Laptop\ndate: 2023-10-01\n---\nTyped."), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+	if err := os.WriteFile(phone, []byte("---\ntitle: From Phone\ndate: 2023-10-01\n---\nThumbed.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	notesDir := filepath.Join(tempDir, "notes")
+	cfg := &config.Config{
+		BufferFile:  laptop,
+		BufferFiles: []string{filepath.Join(tempDir, "tablet.md"), phone},
+		NotesDir:    notesDir,
+		ClearBuffer: true,
+	}
+	if err := runProcess(cfg, notes.OSFileSystem{}, nil); err != nil {
+		t.Fatalf("runProcess failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(notesDir, "2023", "10", "01.md"))
+	if err != nil {
+		t.Fatalf("Expected the day's file to be written: %v", err)
+	}
+	saved, err := notes.SplitNotesFromFile(string(data))
+	if err != nil || len(saved) != 2 || saved[0].Title != "From Laptop" || saved[1].Title != "From Phone" {
+		t.Errorf("Expected both buffers' notes in the order listed, got %v, %v", saved, err)
+	}
+
+	for _, path := range []string{laptop, phone} {
+		if data, err := os.ReadFile(path); err != nil || len(data) != 0 {
+			t.Errorf("Expected %s to be cleared, got %q, %v", path, data, err)
+		}
+	}
+}
+
+func TestRunProcess_BufferGlob(t *testing.T) {
+	tempDir := t.TempDir()
+	inbox := filepath.Join(tempDir, "inbox")
+	if err := os.MkdirAll(inbox, 0o755); err != nil {
+		t.Fatalf("Failed to create inbox: %v", err)
+	}
+	captures := map[string]string{
+		"b.md":   "---\ntitle: Second\ndate: 2023-10-01\n---\nLater.\n",
+		"a.md":   "---\ntitle: First\ndate: 2023-10-01\n---\nEarlier.\n",
+		"bad.md": "---\ntitle: [unclosed\ndate: 2023-10-01\n---\nBroken.\n",
+		"c.txt":  "---\ntitle: Ignored\ndate: 2023-10-01\n---\nNot Markdown.\n",
+	}
+	for name, content := range captures {
+		if err := os.WriteFile(filepath.Join(inbox, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write capture: %v", err)
+		}
+	}
+
+	notesDir := filepath.Join(tempDir, "notes")
+	cfg := &config.Config{
+		BufferFile:  filepath.Join(tempDir, "buffer.md"),
+		BufferGlob:  inbox,
+		NotesDir:    notesDir,
+		ClearBuffer: true,
+	}
+	err := runProcess(cfg, notes.OSFileSystem{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "bad.md") {
+		t.Errorf("Expected the failed capture to be reported, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(notesDir, "2023", "10", "01.md"))
+	if err != nil {
+		t.Fatalf("Expected the day's file to be written: %v", err)
+	}
+	saved, err := notes.SplitNotesFromFile(string(data))
+	if err != nil || len(saved) != 2 || saved[0].Title != "First" || saved[1].Title != "Second" {
+		t.Errorf("Expected the captures' notes in filename order, got %v, %v", saved, err)
+	}
+
+	for _, name := range []string{"a.md", "b.md"} {
+		if _, err := os.Stat(filepath.Join(inbox, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"bad.md", "c.txt"} {
+		if _, err := os.Stat(filepath.Join(inbox, name)); err != nil {
+			t.Errorf("Expected %s to be left in place, got %v", name, err)
+		}
+	}
+}
+
+func TestRunProcess_BufferGlobRemovesEmptyFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	inbox := filepath.Join(tempDir, "inbox")
+	if err := os.MkdirAll(inbox, 0o755); err != nil {
+		t.Fatalf("Failed to create inbox: %v", err)
+	}
+	for name, content := range map[string]string{"empty.md": "", "blank.md": "\n  \n"} {
+		if err := os.WriteFile(filepath.Join(inbox, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write capture: %v", err)
+		}
+	}
+
+	cfg := &config.Config{
+		BufferFile:  filepath.Join(tempDir, "buffer.md"),
+		BufferGlob:  inbox,
+		NotesDir:    filepath.Join(tempDir, "notes"),
+		ClearBuffer: true,
+	}
+	if err := runProcess(cfg, notes.OSFileSystem{}, nil); err != nil {
+		t.Fatalf("runProcess failed: %v", err)
+	}
+
+	for _, name := range []string{"empty.md", "blank.md"} {
+		if _, err := os.Stat(filepath.Join(inbox, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+}
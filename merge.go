@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runMerge gathers a day's notes scattered across the notes directory into
+// the day's file, optionally removing them from where they were.
+func runMerge(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ContinueOnError)
+	dateFlag := flags.String("date", "", "Day to merge (YYYY-MM-DD); defaults to today")
+	remove := flags.Bool("delete", false, "Remove merged notes from their source files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	date := time.Now()
+	if *dateFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *dateFlag)
+		if err != nil {
+			logging.Errorf("Invalid --date: %s", *dateFlag)
+			return err
+		}
+		date = parsed
+	}
+
+	opts, err := processOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	result, err := notes.MergeDay(fs, date, *remove, opts)
+	if err != nil {
+		logging.Errorf("Error merging notes: %v", err)
+		return err
+	}
+	if len(result.Sources) == 0 {
+		logging.Infof("No notes for %s outside %s", date.Format("2006-01-02"), result.Path)
+		return nil
+	}
+	logging.Infof("Merged %d note(s) from %d file(s) into %s", result.Merged, len(result.Sources), result.Path)
+	return nil
+}
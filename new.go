@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// newNoteNow dates notes started with the new command; tests replace it.
+var newNoteNow = time.Now
+
+// runNew adds a front matter skeleton dated today to the end of the buffer,
+// ready to be filled in. With --edit the skeleton is opened in $EDITOR instead
+// and the note is processed once the editor exits, bypassing the buffer.
+func runNew(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	flags := flag.NewFlagSet("new", flag.ContinueOnError)
+	title := flags.String("title", "", "Title of the new note")
+	tags := flags.String("tags", "", "Comma-separated tags, e.g. work,daily")
+	date := flags.String("date", "", "Date of the new note (YYYY-MM-DD); defaults to today")
+	edit := flags.Bool("edit", false, "Write the note in $EDITOR and process it when the editor exits")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *title == "" && !*edit {
+		logging.Errorf("new requires a title, e.g. chrononoteai new --title Standup --tags work,daily")
+		return errors.New("missing --title")
+	}
+	if *date == "" {
+		*date = newNoteNow().Format("2006-01-02")
+	}
+
+	skeleton, err := notes.RenderSkeleton(cfg.Template, *title, *date, splitTags(*tags))
+	if err != nil {
+		return err
+	}
+	if *edit {
+		return editNewNote(cfg, fs, skeleton)
+	}
+
+	// Start on a new line if the buffer doesn't end with one
+	existing, err := fs.ReadFile(cfg.BufferFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logging.Errorf("Error reading buffer file: %v", err)
+		return err
+	}
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		skeleton = "\n" + skeleton
+	}
+
+	filePerm, _, err := cfg.Permissions()
+	if err != nil {
+		logging.Errorf("Invalid permissions: %v", err)
+		return err
+	}
+	if err := fs.AppendToFile(cfg.BufferFile, skeleton, filePerm); err != nil {
+		logging.Errorf("Error adding note to buffer file: %v", err)
+		return err
+	}
+	logging.Infof("Added %q to %s", *title, cfg.BufferFile)
+	return nil
+}
+
+// splitTags splits a comma-separated list of tags, dropping empty entries.
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// editNewNote opens skeleton in $EDITOR in a temporary file and processes the
+// note written there once the editor exits. Nothing is processed if the editor
+// exits with an error or the file is saved unchanged.
+func editNewNote(cfg *config.Config, fs notes.FileSystem, skeleton string) error {
+	file, err := os.CreateTemp("", "chrononoteai-*.md")
+	if err != nil {
+		logging.Errorf("Error creating a file to edit the note in: %v", err)
+		return err
+	}
+	path := file.Name()
+	defer os.Remove(path)
+
+	_, err = file.WriteString(skeleton)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		logging.Errorf("Error writing the note template to %s: %v", path, err)
+		return err
+	}
+
+	if err := launchEditor(os.Getenv("EDITOR"), path); err != nil {
+		logging.Errorf("Editor exited with an error, note discarded: %v", err)
+		return err
+	}
+
+	// The editor writes plain text, so the file is read directly rather than through fs
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logging.Errorf("Error reading the edited note: %v", err)
+		return err
+	}
+	if string(data) == skeleton || bufferEmpty(data) {
+		logging.Infof("Note left unchanged, nothing to process.")
+		return nil
+	}
+
+	opts, err := processOptions(cfg)
+	if err != nil {
+		return err
+	}
+	var written []string
+	opts.OnWrite = func(path string, note notes.Note) {
+		written = append(written, note.Date.String())
+	}
+
+	summary, err := notes.ProcessNotesWithOptions(string(data), fs, opts)
+	if err != nil {
+		logging.Errorf("Error processing notes: %v", err)
+		return err
+	}
+	logging.Infof("%s", summaryMessage(summary))
+
+	if cfg.GitCommit && len(written) > 0 {
+		return commitNotes(cfg.NotesDir, written)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestRunNew(t *testing.T) {
+	original := newNoteNow
+	newNoteNow = func() time.Time { return time.Date(2023, 10, 1, 9, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { newNoteNow = original })
+
+	bufferFile := filepath.Join(t.TempDir(), "buffer.md")
+	if err := os.WriteFile(bufferFile, []byte("---\ntitle: Earlier\ndate: 2023-09-30\n---\nNo trailing newline."), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	cfg := &config.Config{BufferFile: bufferFile}
+	if err := runNew(cfg, notes.OSFileSystem{}, []string{"--title", "Standup", "--tags", "work, daily,"}); err != nil {
+		t.Fatalf("runNew failed: %v", err)
+	}
+
+	data, err := os.ReadFile(bufferFile)
+	if err != nil {
+		t.Fatalf("Failed to read buffer file: %v", err)
+	}
+	expected := "---\ntitle: Earlier\ndate: 2023-09-30\n---\nNo trailing newline.\n---\ntitle: Standup\ndate: 2023-10-01\ntags:\n    - work\n    - daily\n---\n\n"
+	if string(data) != expected {
+		t.Errorf("Buffer mismatch.\nExpected:\n%q\nGot:\n%q", expected, data)
+	}
+
+	stored, err := notes.SplitNotesFromFile(string(data))
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("Expected the buffer to parse as two notes, got %v (%v)", stored, err)
+	}
+	if !reflect.DeepEqual(stored[1].Tags, []string{"work", "daily"}) {
+		t.Errorf("Expected tags split on commas, got %v", stored[1].Tags)
+	}
+
+	if err := runNew(cfg, notes.OSFileSystem{}, nil); err == nil {
+		t.Error("Expected an error without --title")
+	}
+}
+
+// setEditor points $EDITOR at a script that runs body with the file to edit as $1.
+func setEditor(t *testing.T, body string) {
+	script := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write editor script: %v", err)
+	}
+	t.Setenv("EDITOR", script)
+}
+
+func TestRunNew_Edit(t *testing.T) {
+	original := newNoteNow
+	newNoteNow = func() time.Time { return time.Date(2023, 10, 1, 9, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { newNoteNow = original })
+
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: filepath.Join(tempDir, "notes"), ClearBuffer: true}
+	noteFile := filepath.Join(cfg.NotesDir, "2023", "10", "01.md")
+
+	// Saving the template unchanged or exiting with an error processes nothing
+	setEditor(t, "true")
+	if err := runNew(cfg, notes.OSFileSystem{}, []string{"--edit", "--title", "Standup"}); err != nil {
+		t.Fatalf("runNew failed: %v", err)
+	}
+	setEditor(t, `echo "Typed, then quit." >> "$1"; exit 1`)
+	if err := runNew(cfg, notes.OSFileSystem{}, []string{"--edit", "--title", "Standup"}); err == nil {
+		t.Error("Expected an error when the editor exits non-zero")
+	}
+	if _, err := os.Stat(cfg.NotesDir); !os.IsNotExist(err) {
+		t.Fatalf("Expected no notes to be written, stat returned: %v", err)
+	}
+
+	setEditor(t, `echo "Typed in the editor." >> "$1"`)
+	if err := runNew(cfg, notes.OSFileSystem{}, []string{"--edit", "--title", "Standup", "--tags", "work"}); err != nil {
+		t.Fatalf("runNew failed: %v", err)
+	}
+	data, err := os.ReadFile(noteFile)
+	if err != nil {
+		t.Fatalf("Expected the note to be filed: %v", err)
+	}
+	saved, err := notes.SplitNotesFromFile(string(data))
+	if err != nil || len(saved) != 1 || saved[0].Title != "Standup" || saved[0].Content != "Typed in the editor." {
+		t.Errorf("Expected the edited note, got %+v (%v)", saved, err)
+	}
+	if _, err := os.Stat(bufferFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the buffer to be left alone, stat returned: %v", err)
+	}
+}
+
+func TestRunNew_Template(t *testing.T) {
+	original := newNoteNow
+	newNoteNow = func() time.Time { return time.Date(2023, 10, 1, 9, 30, 0, 0, time.UTC) }
+	t.Cleanup(func() { newNoteNow = original })
+
+	bufferFile := filepath.Join(t.TempDir(), "buffer.md")
+	cfg := &config.Config{BufferFile: bufferFile, Template: "---\ntitle: {{yaml .Title}}\ndate: {{.Date}}\ntags: {{yaml .Tags}}\nsummary:\n---\n\n"}
+	if err := runNew(cfg, notes.OSFileSystem{}, []string{"--title", "Standup"}); err != nil {
+		t.Fatalf("runNew failed: %v", err)
+	}
+
+	data, err := os.ReadFile(bufferFile)
+	if err != nil {
+		t.Fatalf("Failed to read buffer file: %v", err)
+	}
+	if expected := "---\ntitle: Standup\ndate: 2023-10-01\ntags: []\nsummary:\n---\n\n"; string(data) != expected {
+		t.Errorf("Buffer mismatch.\nExpected:\n%q\nGot:\n%q", expected, data)
+	}
+}
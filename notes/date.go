@@ -0,0 +1,129 @@
+package notes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Date is a note's calendar day, written in front matter as an unquoted
+// 2006-01-02 value rather than the quoted string YAML would use to keep it
+// from reading as a timestamp, and read back whether it was saved plain or
+// quoted, as older notes were. The zero Date is written as an empty value.
+//
+// A date written some other way keeps its text. One with a time of day, such
+// as "2023-10-01 09:30", has its day and is written back as given; one that
+// isn't a day yet, such as "yesterday" or a configured layout, has only its
+// text until normalizeDateTime or saveNotes resolves it.
+type Date struct {
+	time.Time
+	text string // As written, when that isn't a plain 2006-01-02 day
+}
+
+// ParseDate parses a day written as 2006-01-02, or as an RFC 3339 timestamp,
+// which gives the day in its own offset. An empty value is the zero Date.
+func ParseDate(value string) (Date, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Date{}, nil
+	}
+	if day, err := time.Parse(isoDateLayout, value); err == nil {
+		return Date{Time: day}, nil
+	}
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return Date{Time: calendarDay(ts)}, nil
+	}
+	return Date{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD", value)
+}
+
+// readDate reads a date as written in front matter or an imported record. A
+// plain day, an RFC 3339 timestamp, or a day with a time of day gets its day;
+// anything else is kept as text for normalizeDateTime and validateNote.
+func readDate(text string) Date {
+	value := strings.TrimSpace(text)
+	if value == "" {
+		return Date{}
+	}
+	if day, err := time.Parse(isoDateLayout, value); err == nil {
+		return Date{Time: day}
+	}
+
+	date := Date{text: text}
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		date.Time = calendarDay(ts)
+	} else if ts, err := parseDate(value, dateTimeLayouts); err == nil {
+		date.Time = calendarDay(ts)
+	}
+	return date
+}
+
+// calendarDay returns midnight UTC on the day t falls on in its own location.
+func calendarDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// IsZero reports whether the date is empty, with neither a day nor text.
+func (d Date) IsZero() bool {
+	return d.Time.IsZero() && d.text == ""
+}
+
+// day returns the date's day, parsing its text with layouts if it doesn't
+// have one yet.
+func (d Date) day(layouts []string) (time.Time, error) {
+	if !d.Time.IsZero() {
+		return d.Time, nil
+	}
+	return parseDate(d.text, layouts)
+}
+
+// String returns the day as 2006-01-02, the text of a date without a day, or
+// "" for the zero Date.
+func (d Date) String() string {
+	if d.Time.IsZero() {
+		return d.text
+	}
+	return d.Format(isoDateLayout)
+}
+
+// MarshalYAML writes the day as a plain scalar, or the date's text as given.
+func (d Date) MarshalYAML() (any, error) {
+	switch {
+	case d.IsZero():
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null"}, nil
+	case d.text != "":
+		return &yaml.Node{Kind: yaml.ScalarNode, Value: d.text}, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!timestamp", Value: d.String()}, nil
+}
+
+// UnmarshalYAML reads a date written plain or quoted, as older notes were,
+// and accepts an empty value as the zero Date. See readDate.
+func (d *Date) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("line %d: date must be a single value", value.Line)
+	}
+	if value.Tag == "!!null" {
+		*d = Date{}
+		return nil
+	}
+	*d = readDate(value.Value)
+	return nil
+}
+
+// MarshalJSON writes the date as a string, see String.
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a date string, see readDate.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	*d = readDate(text)
+	return nil
+}
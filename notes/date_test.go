@@ -0,0 +1,106 @@
+package notes
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDate_MarshalYAML(t *testing.T) {
+	tests := []struct {
+		date     Date
+		expected string
+	}{
+		{date: Date{Time: time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)}, expected: "date: 2023-10-01\n"},
+		{date: Date{}, expected: "date:\n"},
+	}
+
+	for _, tt := range tests {
+		data, err := yaml.Marshal(struct {
+			Date Date `yaml:"date"`
+		}{tt.date})
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if string(data) != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, data)
+		}
+	}
+}
+
+func TestDate_UnmarshalYAML(t *testing.T) {
+	tests := map[string]string{
+		"date: 2023-10-01":                "2023-10-01",
+		`date: "2023-10-01"`:              "2023-10-01", // Quoted, as notes were saved before Date
+		"date: '2023-10-01'":              "2023-10-01",
+		"date: 2023-10-01T23:30:00-07:00": "2023-10-01",
+		"date: 2023-10-01 09:30":          "2023-10-01",
+		"date: someday":                   "someday", // Kept for validateNote to reject
+		"date:":                           "",
+		"title: Undated":                  "",
+	}
+
+	for input, expected := range tests {
+		var frontMatter struct {
+			Date Date `yaml:"date"`
+		}
+		if err := yaml.Unmarshal([]byte(input), &frontMatter); err != nil {
+			t.Errorf("%q: Unmarshal failed: %v", input, err)
+			continue
+		}
+		if frontMatter.Date.String() != expected {
+			t.Errorf("%q: expected %q, got %q", input, expected, frontMatter.Date)
+		}
+	}
+
+	var frontMatter struct {
+		Date Date `yaml:"date"`
+	}
+	err := yaml.Unmarshal([]byte("date: [2023-10-01]"), &frontMatter)
+	if err == nil || !strings.Contains(err.Error(), "single value") {
+		t.Errorf("Expected a list of dates to be rejected, got %v", err)
+	}
+}
+
+func TestDate_KeepsTimeOfDay(t *testing.T) {
+	data, err := yaml.Marshal(struct {
+		Date Date `yaml:"date"`
+	}{readDate("2023-10-01 09:30")})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != "date: 2023-10-01 09:30\n" {
+		t.Errorf("Expected the date to be written as given, got %q", data)
+	}
+
+	var decoded struct {
+		Date Date `json:"date"`
+	}
+	if err := json.Unmarshal([]byte(`{"date":"2023-10-01"}`), &decoded); err != nil || decoded.Date.String() != "2023-10-01" {
+		t.Errorf("Expected the JSON date to read back, got %q, %v", decoded.Date, err)
+	}
+}
+
+func TestDate_RoundTripThroughNote(t *testing.T) {
+	formatted, err := formatNoteContent(Note{Title: "Round Trip", Date: readDate("2023-10-01"), Content: "Body."}, Options{})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+
+	if !strings.Contains(formatted, "date: 2023-10-01\n") {
+		t.Errorf("Expected an unquoted date, got:\n%s", formatted)
+	}
+	saved, err := SplitNotesFromFile(formatted)
+	if err != nil || len(saved) != 1 || saved[0].Date.String() != "2023-10-01" {
+		t.Errorf("Expected the date to round-trip, got %+v, %v", saved, err)
+	}
+
+	// Quoted, as notes were saved before Date
+	saved, err = SplitNotesFromFile("---\ntitle: Old\ndate: \"2023-10-01\"\n---\nBody.\n")
+	if err != nil || len(saved) != 1 || saved[0].Date.String() != "2023-10-01" {
+		t.Errorf("Expected a quoted date to read back, got %+v, %v", saved, err)
+	}
+}
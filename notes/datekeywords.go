@@ -0,0 +1,38 @@
+package notes
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateKeywordOffsets are the day offsets from today named by date keywords.
+var dateKeywordOffsets = map[string]int{
+	"today":     0,
+	"yesterday": -1,
+	"tomorrow":  1,
+}
+
+// resolveDateKeyword turns a relative date such as "yesterday" or "-3" (three
+// days ago) into an ISO date counted from now. Keywords are matched ignoring
+// case; anything else is returned unchanged for the normal date parser.
+func resolveDateKeyword(s string, now time.Time) (string, error) {
+	keyword := strings.ToLower(strings.TrimSpace(s))
+
+	offset, ok := dateKeywordOffsets[keyword]
+	if !ok {
+		if len(keyword) < 2 || (keyword[0] != '+' && keyword[0] != '-') {
+			return s, nil
+		}
+		if strings.TrimLeft(keyword[1:], "0123456789") != "" {
+			return s, nil
+		}
+		n, err := strconv.Atoi(keyword)
+		if err != nil {
+			return s, err
+		}
+		offset = n
+	}
+
+	return now.AddDate(0, 0, offset).Format(isoDateLayout), nil
+}
@@ -0,0 +1,54 @@
+package notes
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveDateKeyword(t *testing.T) {
+	now := fixedClock()
+	tests := map[string]string{
+		"today":      "2023-10-01",
+		"Yesterday":  "2023-09-30",
+		" tomorrow ": "2023-10-02",
+		"+3":         "2023-10-04",
+		"-7":         "2023-09-24",
+		"+0":         "2023-10-01",
+		"2023-05-05": "2023-05-05",
+		"someday":    "someday",
+		"-":          "-",
+		"+1d":        "+1d",
+		"":           "",
+	}
+
+	for input, expected := range tests {
+		got, err := resolveDateKeyword(input, now)
+		if err != nil {
+			t.Errorf("resolveDateKeyword(%q) failed: %v", input, err)
+		}
+		if got != expected {
+			t.Errorf("resolveDateKeyword(%q): expected %q, got %q", input, expected, got)
+		}
+	}
+
+	if _, err := resolveDateKeyword("+99999999999999999999", now); err == nil {
+		t.Error("Expected an error for an offset that overflows")
+	}
+}
+
+func TestProcessNotes_DateKeywords(t *testing.T) {
+	data := "---\ntitle: Looking Back\ndate: yesterday\n---\nWritten a day late.\n---\ntitle: Planning\ndate: +2\n---\nLater this week.\n"
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if content := fs.Files[filepath.Join("/notes", "2023/09", "30.md")]; !strings.Contains(content, "date: 2023-09-30\n") {
+		t.Errorf("Expected yesterday's note dated 2023-09-30, got:\n%s", content)
+	}
+	if content := fs.Files[filepath.Join("/notes", "2023/10", "03.md")]; !strings.Contains(content, "date: 2023-10-03\n") {
+		t.Errorf("Expected the +2 note dated 2023-10-03, got:\n%s", content)
+	}
+}
@@ -0,0 +1,281 @@
+package notes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Armor lines around a note's encrypted content.
+const (
+	armorBegin = "-----BEGIN CHRONONOTEAI ENCRYPTED CONTENT-----"
+	armorEnd   = "-----END CHRONONOTEAI ENCRYPTED CONTENT-----"
+)
+
+const (
+	armorVersion   = 1
+	saltSize       = 16
+	armorLineWidth = 64
+
+	// scrypt parameters recommended for interactive use
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrDecrypt is returned when encrypted content can't be read with the passphrase.
+var ErrDecrypt = errors.New("failed to decrypt note content, is the passphrase correct?")
+
+// EncryptedFileSystem encrypts the content of notes saved under Dir with
+// AES-GCM, using a key derived from a passphrase with scrypt. Front matter
+// stays in plain text so files remain recognizable; each note's content is
+// replaced with an armored block that ReadFile decrypts again. Files outside
+// Dir, and files that aren't Markdown, pass through unchanged.
+type EncryptedFileSystem struct {
+	FileSystem
+	Dir string
+
+	passphrase []byte
+	salt       []byte // Used for everything this value encrypts
+
+	mu   sync.Mutex
+	keys map[string][]byte // Derived keys by salt, since scrypt is deliberately slow
+}
+
+// NewEncryptedFileSystem wraps fs so notes under dir are encrypted with passphrase.
+func NewEncryptedFileSystem(fs FileSystem, dir, passphrase string) (*EncryptedFileSystem, error) {
+	if passphrase == "" {
+		return nil, errors.New("encryption passphrase must not be empty")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return &EncryptedFileSystem{
+		FileSystem: fs,
+		Dir:        dir,
+		passphrase: []byte(passphrase),
+		salt:       salt,
+		keys:       make(map[string][]byte),
+	}, nil
+}
+
+// ReadFile returns the file with any encrypted note content decrypted.
+func (e *EncryptedFileSystem) ReadFile(path string) ([]byte, error) {
+	data, err := e.FileSystem.ReadFile(path)
+	if err != nil || !e.covers(path) {
+		return data, err
+	}
+
+	plain, err := e.decryptArmor(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return []byte(plain), nil
+}
+
+// WriteFile writes data with each note's content encrypted.
+func (e *EncryptedFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
+	if !e.covers(path) {
+		return e.FileSystem.WriteFile(path, data, perm)
+	}
+
+	sealed, err := e.encryptContent(string(data))
+	if err != nil {
+		return err
+	}
+	return e.FileSystem.WriteFile(path, []byte(sealed), perm)
+}
+
+// AppendToFile appends data with each note's content encrypted.
+func (e *EncryptedFileSystem) AppendToFile(path string, data string, perm os.FileMode) error {
+	if !e.covers(path) {
+		return e.FileSystem.AppendToFile(path, data, perm)
+	}
+
+	sealed, err := e.encryptContent(data)
+	if err != nil {
+		return err
+	}
+	return e.FileSystem.AppendToFile(path, sealed, perm)
+}
+
+// ErrNotEncrypted is returned when a note would be saved somewhere an
+// EncryptedFileSystem doesn't encrypt, such as a dir override outside its Dir.
+var ErrNotEncrypted = errors.New("note would be saved unencrypted")
+
+// ensureEncrypted returns ErrNotEncrypted if fs encrypts notes but wouldn't
+// encrypt one saved to path, so encryption never silently stops applying.
+func ensureEncrypted(fs FileSystem, path string, log logging.Logger) error {
+	e, ok := fs.(*EncryptedFileSystem)
+	if !ok || e.covers(path) {
+		return nil
+	}
+	log.Errorf("Refusing to save a note outside the encrypted notes directory %s: %s\n", e.Dir, path)
+	return fmt.Errorf("%s: %w", path, ErrNotEncrypted)
+}
+
+// covers reports whether path is a note file that should be encrypted.
+func (e *EncryptedFileSystem) covers(path string) bool {
+	return filepath.Ext(path) == ".md" && ensureWithinDir(e.Dir, path) == nil
+}
+
+// encryptContent replaces the content of every note in data with an armored
+// block, leaving front matter and anything before the first note untouched.
+// Content that is already armored is kept as it is.
+func (e *EncryptedFileSystem) encryptContent(data string) (string, error) {
+	lines := scanLines(data)
+
+	var out []string
+	copied := 0
+	for _, block := range splitNoteBlocks(lines) {
+		if block.end+1 >= block.next {
+			continue
+		}
+
+		content := lines[block.end+1 : block.next]
+		if len(content) > 0 && content[0] == armorBegin {
+			continue
+		}
+
+		armor, err := e.seal(strings.Join(content, "\n"))
+		if err != nil {
+			return "", err
+		}
+		out = append(out, lines[copied:block.end+1]...)
+		out = append(out, armor...)
+		copied = block.next
+	}
+	out = append(out, lines[copied:]...)
+
+	return rejoinLines(out, data), nil
+}
+
+// decryptArmor replaces every armored block in data with the text it holds.
+func (e *EncryptedFileSystem) decryptArmor(data string) (string, error) {
+	if !strings.Contains(data, armorBegin) {
+		return data, nil
+	}
+
+	lines := scanLines(data)
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		if lines[i] != armorBegin {
+			out = append(out, lines[i])
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && lines[end] != armorEnd {
+			end++
+		}
+		if end == len(lines) {
+			return "", errors.New("unterminated encrypted block")
+		}
+
+		plain, err := e.open(strings.Join(lines[i+1:end], ""))
+		if err != nil {
+			return "", err
+		}
+		out = append(out, strings.Split(plain, "\n")...)
+		i = end
+	}
+
+	return rejoinLines(out, data), nil
+}
+
+// seal encrypts text and returns the armored lines holding it.
+func (e *EncryptedFileSystem) seal(text string) ([]string, error) {
+	gcm, err := e.cipher(e.salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	payload := append([]byte{armorVersion}, e.salt...)
+	payload = append(payload, nonce...)
+	payload = gcm.Seal(payload, nonce, []byte(text), nil)
+
+	encoded := base64.StdEncoding.EncodeToString(payload)
+	armor := []string{armorBegin}
+	for len(encoded) > armorLineWidth {
+		armor = append(armor, encoded[:armorLineWidth])
+		encoded = encoded[armorLineWidth:]
+	}
+	return append(armor, encoded, armorEnd), nil
+}
+
+// open decrypts the base64 payload of an armored block.
+func (e *EncryptedFileSystem) open(encoded string) (string, error) {
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted block: %w", err)
+	}
+	if len(payload) < 1+saltSize || payload[0] != armorVersion {
+		return "", errors.New("unsupported encrypted block")
+	}
+
+	salt := payload[1 : 1+saltSize]
+	gcm, err := e.cipher(salt)
+	if err != nil {
+		return "", err
+	}
+
+	rest := payload[1+saltSize:]
+	if len(rest) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted block: too short")
+	}
+	plain, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plain), nil
+}
+
+// cipher returns AES-GCM keyed from the passphrase and salt.
+func (e *EncryptedFileSystem) cipher(salt []byte) (cipher.AEAD, error) {
+	e.mu.Lock()
+	key, ok := e.keys[string(salt)]
+	if !ok {
+		var err error
+		key, err = scrypt.Key(e.passphrase, salt, scryptN, scryptR, scryptP, 32)
+		if err != nil {
+			e.mu.Unlock()
+			return nil, err
+		}
+		e.keys[string(bytes.Clone(salt))] = key
+	}
+	e.mu.Unlock()
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// rejoinLines joins lines, ending with a newline if the original text did.
+func rejoinLines(lines []string, original string) string {
+	joined := strings.Join(lines, "\n")
+	if strings.HasSuffix(original, "\n") {
+		joined += "\n"
+	}
+	return joined
+}
@@ -0,0 +1,130 @@
+package notes
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedFileSystem_RoundTrip(t *testing.T) {
+	buffer := `---
+title: First
+date: 2023-10-01
+tags:
+    - private
+---
+The secret plan.
+---
+title: Second
+date: 2023-10-01
+---
+Another secret.
+`
+	mock := NewMockFileSystem()
+	fs, err := NewEncryptedFileSystem(mock, "/notes", "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("NewEncryptedFileSystem failed: %v", err)
+	}
+
+	if _, err := ProcessNotesWithOptions(buffer, fs, Options{NotesDir: "/notes"}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	stored := mock.Files[path]
+	for _, secret := range []string{"secret plan", "Another secret"} {
+		if strings.Contains(stored, secret) {
+			t.Errorf("Expected content to be encrypted at rest, found %q in:\n%s", secret, stored)
+		}
+	}
+	for _, plain := range []string{"title: First", "title: Second", "- private", armorBegin, armorEnd} {
+		if !strings.Contains(stored, plain) {
+			t.Errorf("Expected %q in the stored file, got:\n%s", plain, stored)
+		}
+	}
+
+	// A new wrapper with the same passphrase reads the notes back
+	reopened, err := NewEncryptedFileSystem(mock, "/notes", "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("NewEncryptedFileSystem failed: %v", err)
+	}
+	data, err := reopened.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	saved, err := SplitNotesFromFile(string(data))
+	if err != nil {
+		t.Fatalf("SplitNotesFromFile failed: %v", err)
+	}
+	if len(saved) != 2 || saved[0].Content != "The secret plan." || saved[1].Content != "Another secret." {
+		t.Errorf("Expected both notes to decrypt, got %+v", saved)
+	}
+
+	// Processing the same note again is still recognized as a duplicate
+	if _, err := ProcessNotesWithOptions(buffer, reopened, Options{NotesDir: "/notes"}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	data, _ = reopened.ReadFile(path)
+	if count := strings.Count(string(data), "The secret plan."); count != 1 {
+		t.Errorf("Expected the note once after reprocessing, found it %d times", count)
+	}
+}
+
+func TestEncryptedFileSystem_WrongPassphrase(t *testing.T) {
+	mock := NewMockFileSystem()
+	fs, err := NewEncryptedFileSystem(mock, "/notes", "right")
+	if err != nil {
+		t.Fatalf("NewEncryptedFileSystem failed: %v", err)
+	}
+	if err := fs.WriteFile("/notes/a.md", []byte("---\ntitle: A\n---\nHidden.\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	wrong, err := NewEncryptedFileSystem(mock, "/notes", "wrong")
+	if err != nil {
+		t.Fatalf("NewEncryptedFileSystem failed: %v", err)
+	}
+	if _, err := wrong.ReadFile("/notes/a.md"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt with the wrong passphrase, got %v", err)
+	}
+}
+
+func TestEncryptedFileSystem_PassesThroughOtherFiles(t *testing.T) {
+	mock := NewMockFileSystem()
+	fs, err := NewEncryptedFileSystem(mock, "/notes", "passphrase")
+	if err != nil {
+		t.Fatalf("NewEncryptedFileSystem failed: %v", err)
+	}
+
+	buffer := "---\ntitle: A\n---\nStill plain.\n"
+	if err := fs.WriteFile("/buffer.md", []byte(buffer), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if mock.Files["/buffer.md"] != buffer {
+		t.Errorf("Expected files outside the notes directory to be written as is, got:\n%s", mock.Files["/buffer.md"])
+	}
+}
+
+func TestEncryptedFileSystem_RejectsNotesOutsideDir(t *testing.T) {
+	mock := NewMockFileSystem()
+	fs, err := NewEncryptedFileSystem(mock, "/notes", "passphrase")
+	if err != nil {
+		t.Fatalf("NewEncryptedFileSystem failed: %v", err)
+	}
+
+	buffer := "---\ntitle: Elsewhere\ndate: 2023-10-01\ndir: /shared\n---\nWould be plain.\n"
+	_, err = ProcessNotesWithOptions(buffer, fs, Options{NotesDir: "/notes"})
+	if !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("Expected ErrNotEncrypted, got %v", err)
+	}
+	if len(mock.Files) != 0 {
+		t.Errorf("Expected nothing written, got %v", mock.Files)
+	}
+}
+
+func TestNewEncryptedFileSystem_RequiresPassphrase(t *testing.T) {
+	if _, err := NewEncryptedFileSystem(NewMockFileSystem(), "/notes", ""); err == nil {
+		t.Error("Expected an error for an empty passphrase")
+	}
+}
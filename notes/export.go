@@ -0,0 +1,153 @@
+package notes
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+// ExportMonth combines every saved note dated in the given month into one
+// Markdown document, ordered by date. Each note's front matter is rendered as
+// a heading followed by a list of its metadata. It returns the document and
+// the number of notes it holds; a month without notes still gets a title.
+func ExportMonth(fs FileSystem, dir string, month time.Time) (string, int, error) {
+	stored, err := ListNotes(fs, dir)
+	if err != nil {
+		return "", 0, err
+	}
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	var doc strings.Builder
+	fmt.Fprintf(&doc, "# Notes for %s\n", first.Format("January 2006"))
+
+	count := 0
+	for _, note := range stored {
+		if note.Err != nil || !inMonth(note.Date, first) {
+			continue
+		}
+
+		count++
+		doc.WriteString("\n")
+		writeExportedNote(&doc, note.Note)
+	}
+
+	return doc.String(), count, nil
+}
+
+// writeExportedNote renders a note's front matter as a heading block followed by its content.
+func writeExportedNote(doc *strings.Builder, note Note) {
+	fmt.Fprintf(doc, "## %s\n\n", note.Title)
+	fmt.Fprintf(doc, "- **Date:** %s\n", note.Date)
+	if note.Time != "" {
+		fmt.Fprintf(doc, "- **Time:** %s\n", note.Time)
+	}
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(doc, "- **Tags:** %s\n", strings.Join(note.Tags, ", "))
+	}
+	if note.Summary != "" {
+		fmt.Fprintf(doc, "- **Summary:** %s\n", note.Summary)
+	}
+	if note.Content != "" {
+		fmt.Fprintf(doc, "\n%s\n", note.Content)
+	}
+}
+
+// Formats accepted by ExportNotes and ExportJSON.
+const (
+	FormatJSON  = "json"  // One indented JSON array
+	FormatJSONL = "jsonl" // JSON Lines, one compact object per note
+)
+
+// ExportNotes writes every saved note under dir to w in the given format,
+// ordered by date and then title. Each object holds the note's title, date,
+// tags, raw Markdown content, and source file path.
+func ExportNotes(fs FileSystem, dir string, w io.Writer, format string) error {
+	exported, err := exportedNotes(fs, dir, time.Time{})
+	if err != nil {
+		return err
+	}
+	return writeNotes(w, exported, format)
+}
+
+// ExportJSON is ExportNotes returning the document, limited to notes dated in
+// month unless it is zero. It also returns the number of notes exported.
+func ExportJSON(fs FileSystem, dir string, month time.Time, format string) ([]byte, int, error) {
+	exported, err := exportedNotes(fs, dir, month)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var doc bytes.Buffer
+	if err := writeNotes(&doc, exported, format); err != nil {
+		return nil, 0, err
+	}
+	return doc.Bytes(), len(exported), nil
+}
+
+// exportedNotes returns the parseable notes under dir, optionally limited to
+// a month, sorted by date and then title. Missing tags become an empty list
+// so every exported object has the same shape.
+func exportedNotes(fs FileSystem, dir string, month time.Time) ([]StoredNote, error) {
+	stored, err := ListNotes(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	exported := []StoredNote{}
+	for _, note := range stored {
+		if note.Err != nil || (!month.IsZero() && !inMonth(note.Date, month)) {
+			continue
+		}
+		if note.Tags == nil {
+			note.Tags = []string{}
+		}
+		exported = append(exported, note)
+	}
+
+	// ListNotes sorts by date; the title keeps same-day notes in a stable order
+	slices.SortStableFunc(exported, func(a, b StoredNote) int {
+		return cmp.Or(cmp.Compare(a.Date.String(), b.Date.String()), cmp.Compare(a.Title, b.Title))
+	})
+	return exported, nil
+}
+
+// writeNotes encodes notes to w as FormatJSON or FormatJSONL.
+func writeNotes(w io.Writer, exported []StoredNote, format string) error {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(exported, "", "  ")
+		if err != nil {
+			logging.Errorf("Failed to serialize notes")
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case FormatJSONL:
+		enc := json.NewEncoder(w)
+		for _, note := range exported {
+			if err := enc.Encode(note); err != nil {
+				logging.Errorf("Failed to serialize note %q from %s", note.Title, note.Path)
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown export format %q, expected %s or %s", format, FormatJSON, FormatJSONL)
+	}
+}
+
+// inMonth reports whether a date's day falls in the month of the given time.
+func inMonth(date Date, month time.Time) bool {
+	if date.Time.IsZero() {
+		return false
+	}
+	return date.Year() == month.Year() && date.Month() == month.Month()
+}
@@ -0,0 +1,125 @@
+package notes
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportMonth(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.Files[filepath.Join("/notes", "2023/10", "05.md")] = "---\ntitle: Retro\ndate: 2023-10-05\n---\nWhat went well.\n"
+	fs.Files[filepath.Join("/notes", "2023/10", "01.md")] = "---\ntitle: Kickoff\ndate: 2023-10-01\ntags:\n  - work\n  - planning\nsummary: Started Q4\n---\nScope agreed.\n"
+	fs.Files[filepath.Join("/notes", "2023/09", "30.md")] = "---\ntitle: September\ndate: 2023-09-30\n---\nToo early.\n"
+	fs.Files[filepath.Join("/notes", "2023/11", "01.md")] = "---\ntitle: November\ndate: 2023-11-01\n---\nToo late.\n"
+
+	doc, count, err := ExportMonth(fs, "/notes", time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ExportMonth failed: %v", err)
+	}
+
+	expected := `# Notes for October 2023
+
+## Kickoff
+
+- **Date:** 2023-10-01
+- **Tags:** work, planning
+- **Summary:** Started Q4
+
+Scope agreed.
+
+## Retro
+
+- **Date:** 2023-10-05
+
+What went well.
+`
+	if count != 2 {
+		t.Errorf("Expected 2 notes, got %d", count)
+	}
+	if doc != expected {
+		t.Errorf("Export mismatch.\nExpected:\n%s\nGot:\n%s", expected, doc)
+	}
+}
+
+func TestExportMonth_Empty(t *testing.T) {
+	doc, count, err := ExportMonth(NewMockFileSystem(), "/notes", time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ExportMonth failed: %v", err)
+	}
+	if count != 0 || doc != "# Notes for October 2023\n" {
+		t.Errorf("Expected an empty export with a title, got %d notes:\n%s", count, doc)
+	}
+}
+
+func TestExportJSON(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.Files[filepath.Join("/notes", "2023/10", "05.md")] = "---\ntitle: Retro\ndate: 2023-10-05\n---\nWhat went well.\n"
+	fs.Files[filepath.Join("/notes", "2023/10", "01.md")] = "---\ntitle: Kickoff\ndate: 2023-10-01\ntags:\n  - work\n  - planning\n---\nScope agreed.\n"
+	fs.Files[filepath.Join("/notes", "2023/11", "01.md")] = "---\ntitle: November\ndate: 2023-11-01\ntags:\n  - later\n---\nNext month.\n"
+
+	data, count, err := ExportJSON(fs, "/notes", time.Time{}, FormatJSON)
+	if err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 notes, got %d", count)
+	}
+
+	var exported []StoredNote
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("Expected valid JSON, got %v:\n%s", err, data)
+	}
+
+	expected := []StoredNote{
+		{Note: Note{Title: "Kickoff", Date: readDate("2023-10-01"), Tags: []string{"work", "planning"}, Content: "Scope agreed."}, Path: filepath.Join("/notes", "2023/10", "01.md")},
+		{Note: Note{Title: "Retro", Date: readDate("2023-10-05"), Tags: []string{}, Content: "What went well."}, Path: filepath.Join("/notes", "2023/10", "05.md")},
+		{Note: Note{Title: "November", Date: readDate("2023-11-01"), Tags: []string{"later"}, Content: "Next month."}, Path: filepath.Join("/notes", "2023/11", "01.md")},
+	}
+	if !reflect.DeepEqual(exported, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, exported)
+	}
+
+	// A month limits the export
+	_, count, err = ExportJSON(fs, "/notes", time.Date(2023, time.November, 1, 0, 0, 0, 0, time.UTC), FormatJSON)
+	if err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 note for November, got %d", count)
+	}
+}
+
+func TestExportNotes_JSONLines(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.Files[filepath.Join("/notes", "2023/10", "02.md")] = "---\ntitle: Later\ndate: 2023-10-02\n---\nSecond day.\n"
+	fs.Files[filepath.Join("/notes", "2023/10", "01.md")] = "---\ntitle: Zeta\ndate: 2023-10-01\n---\nWritten first.\n\n---\ntitle: Alpha\ndate: 2023-10-01\ntags:\n  - work\n---\n# Heading\n\n- a list\n"
+
+	var out strings.Builder
+	if err := ExportNotes(fs, "/notes", &out, FormatJSONL); err != nil {
+		t.Fatalf("ExportNotes failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	var titles []string
+	for _, line := range lines {
+		var note StoredNote
+		if err := json.Unmarshal([]byte(line), &note); err != nil {
+			t.Fatalf("Expected one JSON object per line, got %v for %q", err, line)
+		}
+		titles = append(titles, note.Title)
+		if note.Title == "Alpha" && note.Content != "# Heading\n\n- a list" {
+			t.Errorf("Expected the raw Markdown content, got %q", note.Content)
+		}
+	}
+	if expected := []string{"Alpha", "Zeta", "Later"}; !reflect.DeepEqual(titles, expected) {
+		t.Errorf("Expected notes ordered by date then title %v, got %v", expected, titles)
+	}
+
+	if err := ExportNotes(fs, "/notes", &out, "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,119 @@
+package notes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+// RecordError describes an imported record that isn't a valid JSON note.
+type RecordError struct {
+	Line int // Line the record starts on, starting at 1
+	Err  error
+}
+
+func (e RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e RecordError) Unwrap() error {
+	return e.Err
+}
+
+// ImportNotes saves notes from JSON, such as the output of ExportNotes, the
+// same way ProcessNotesWithOptions saves a buffer. data is either a JSON array
+// of note objects or JSON Lines with one object per line. Records are
+// validated like buffer notes and errors name the line the record starts on;
+// as with a buffer, nothing is written if any note fails validation, while
+// records that aren't valid JSON are reported after the rest are saved.
+func ImportNotes(fs FileSystem, data []byte, opts Options) error {
+	notes, recordErrs, err := decodeRecords(data, opts.logger())
+	if err != nil {
+		return err
+	}
+	if len(recordErrs) > 0 {
+		opts.logger().Errorf("Failed to read %d record(s), importing the remaining %d\n", len(recordErrs), len(notes))
+	}
+
+	for i := range notes {
+		notes[i].index = i + 1
+		if notes[i].Title == "" {
+			notes[i].Title = headingTitle(notes[i].Content)
+		}
+		normalizeDateTime(&notes[i], opts)
+	}
+
+	var readErrs []error
+	for _, recordErr := range recordErrs {
+		readErrs = append(readErrs, recordErr)
+	}
+	_, err = saveNotes(context.Background(), notes, errors.Join(readErrs...), fs, opts)
+	return err
+}
+
+// decodeRecords reads notes from a JSON array or from JSON Lines, recording
+// the line each one starts on. JSON Lines records that fail to decode are
+// returned alongside the notes that did; a malformed array is an error.
+func decodeRecords(data []byte, log logging.Logger) ([]Note, []RecordError, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		notes, err := decodeArray(data, log)
+		return notes, nil, err
+	}
+
+	var notes []Note
+	var errs []RecordError
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var note Note
+		if err := json.Unmarshal(line, &note); err != nil {
+			recordErr := RecordError{Line: i + 1, Err: err}
+			log.Errorf("Failed to read record: %v\n", recordErr)
+			errs = append(errs, recordErr)
+			continue
+		}
+		note.line = i + 1
+		notes = append(notes, note)
+	}
+
+	return notes, errs, nil
+}
+
+// decodeArray reads notes from a JSON array. Decoding can't continue past a
+// malformed element, so the first one fails the whole import.
+func decodeArray(data []byte, log logging.Logger) ([]Note, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, RecordError{Line: 1, Err: err}
+	}
+
+	var notes []Note
+	for dec.More() {
+		line := lineAt(data, dec.InputOffset())
+
+		var note Note
+		if err := dec.Decode(&note); err != nil {
+			log.Errorf("Failed to read record on line %d: %v\n", line, err)
+			return nil, RecordError{Line: line, Err: err}
+		}
+		note.line = line
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// lineAt returns the line of the first character at or after offset that
+// starts a value, skipping whitespace and the comma between array elements.
+func lineAt(data []byte, offset int64) int {
+	for offset < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n,"), data[offset]) >= 0 {
+		offset++
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,106 @@
+package notes
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportNotes_JSONLines(t *testing.T) {
+	data := `{"title":"Kickoff","date":"2023-10-01","tags":["Work"],"content":"Scope agreed.","path":"/old/notes.md"}
+
+{"title":"Retro","date":"2023-10-05","content":"What went well."}
+`
+	fs := NewMockFileSystem()
+	if err := ImportNotes(fs, []byte(data), Options{NotesDir: "/notes", LowercaseTags: true, Now: fixedClock}); err != nil {
+		t.Fatalf("ImportNotes failed: %v", err)
+	}
+
+	kickoff := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]
+	for _, want := range []string{"title: Kickoff\n", "- work\n", "Scope agreed.\n"} {
+		if !strings.Contains(kickoff, want) {
+			t.Errorf("Expected %q in the imported note, got:\n%s", want, kickoff)
+		}
+	}
+	if !strings.Contains(fs.Files[filepath.Join("/notes", "2023/10", "05.md")], "What went well.") {
+		t.Error("Expected the second record to be imported")
+	}
+}
+
+func TestImportNotes_RoundTripsExport(t *testing.T) {
+	source := NewMockFileSystem()
+	buffer := "---\ntitle: One\ndate: 2023-10-01\ntags:\n  - a\n---\nFirst.\n---\ntitle: Two\ndate: 2023-10-02\n---\nSecond.\n"
+	if _, err := ProcessNotesWithOptions(buffer, source, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	var exported bytes.Buffer
+	if err := ExportNotes(source, "/notes", &exported, FormatJSON); err != nil {
+		t.Fatalf("ExportNotes failed: %v", err)
+	}
+
+	target := NewMockFileSystem()
+	if err := ImportNotes(target, exported.Bytes(), Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ImportNotes failed: %v", err)
+	}
+	for _, path := range []string{filepath.Join("/notes", "2023/10", "01.md"), filepath.Join("/notes", "2023/10", "02.md")} {
+		if target.Files[path] != source.Files[path] {
+			t.Errorf("Expected %s to match the original.\nExpected:\n%s\nGot:\n%s", path, source.Files[path], target.Files[path])
+		}
+	}
+}
+
+func TestImportNotes_ReportsLines(t *testing.T) {
+	data := `{"title":"Fine","date":"2023-10-01","content":"Ok."}
+{"title":"Undated","content":"No date."}
+`
+	fs := NewMockFileSystem()
+	err := ImportNotes(fs, []byte(data), Options{NotesDir: "/notes"})
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Line != 2 {
+		t.Fatalf("Expected a validation error for line 2, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected the error to name line 2, got %v", err)
+	}
+	if fs.Writes != 0 {
+		t.Errorf("Expected nothing written when a record is invalid, got %d writes", fs.Writes)
+	}
+
+	// Malformed JSON is reported and the other records are still imported
+	data = "{\"title\":\"Fine\",\"date\":\"2023-10-01\",\"content\":\"Ok.\"}\n{not json\n"
+	err = ImportNotes(fs, []byte(data), Options{NotesDir: "/notes"})
+	var recordErr RecordError
+	if !errors.As(err, &recordErr) || recordErr.Line != 2 {
+		t.Errorf("Expected a record error for line 2, got %v", err)
+	}
+	if _, ok := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]; !ok {
+		t.Error("Expected the valid record to be imported")
+	}
+
+	// Array elements are reported by the line they start on
+	data = "[\n  {\"title\": \"Fine\", \"date\": \"2023-10-01\"},\n  {\n    \"title\": \"Bad date\",\n    \"date\": \"someday\"\n  }\n]\n"
+	err = ImportNotes(NewMockFileSystem(), []byte(data), Options{NotesDir: "/notes"})
+	if !errors.As(err, &validationErr) || validationErr.Line != 3 {
+		t.Errorf("Expected a validation error for line 3, got %v", err)
+	}
+}
+
+func TestImportNotes_DefaultToday(t *testing.T) {
+	data := `{"title":"Undated","content":"Dated by the clock."}
+{"title":"Dated","date":"2023-09-15","content":"Keeps its date."}
+`
+	fs := NewMockFileSystem()
+	if err := ImportNotes(fs, []byte(data), Options{NotesDir: "/notes", DefaultToday: true, Now: fixedClock}); err != nil {
+		t.Fatalf("ImportNotes failed: %v", err)
+	}
+
+	if today := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]; !strings.Contains(today, "Dated by the clock.") {
+		t.Errorf("Expected the undated record on the clock's day, got:\n%s", today)
+	}
+	if dated := fs.Files[filepath.Join("/notes", "2023/09", "15.md")]; !strings.Contains(dated, "Keeps its date.") {
+		t.Errorf("Expected the explicit date to win, got:\n%s", dated)
+	}
+}
@@ -0,0 +1,161 @@
+package notes
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+// IndexFileName is the manifest RebuildIndex writes at the root of the notes directory.
+const IndexFileName = "index.json"
+
+// IndexEntry describes one saved note in the index.
+type IndexEntry struct {
+	Title string   `json:"title"`
+	Date  string   `json:"date"`
+	Tags  []string `json:"tags"`
+	Path  string   `json:"path"` // Relative to the notes directory, with forward slashes
+}
+
+// RebuildIndex walks the notes under dir and writes a JSON index of every
+// note to IndexFileName in dir. Entries are sorted by date, then path, then
+// position in the file so the output diffs cleanly. The file is only
+// rewritten when its contents change.
+func RebuildIndex(fs FileSystem, dir string) error {
+	return RebuildIndexWithOptions(fs, dir, Options{})
+}
+
+// RebuildIndexWithOptions is RebuildIndex creating files with the permissions in opts.
+func RebuildIndexWithOptions(fs FileSystem, dir string, opts Options) error {
+	entries := []IndexEntry{}
+
+	err := walkNoteFiles(fs, dir, func(path string, data []byte) error {
+		fileEntries, err := indexEntries(dir, path, data, opts.logger())
+		entries = append(entries, fileEntries...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return writeIndex(fs, dir, entries, opts)
+}
+
+// updateIndex refreshes the index entries for the note files at paths,
+// leaving the rest of the index as it is, so a run that writes a few files
+// doesn't read every note. Paths outside dir aren't indexed. Without an index
+// to update, or one that can't be read, the whole index is rebuilt.
+func updateIndex(fs FileSystem, dir string, paths []string, opts Options) error {
+	indexPath := filepath.Join(dir, IndexFileName)
+	existing, err := fs.ReadFile(indexPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return RebuildIndexWithOptions(fs, dir, opts)
+	}
+	if err != nil {
+		opts.logger().Errorf("Failed to read index %s: %v\n", indexPath, err)
+		return err
+	}
+
+	var entries []IndexEntry
+	if err := json.Unmarshal(existing, &entries); err != nil {
+		opts.logger().Warnf("Warning: rebuilding unreadable index %s: %v\n", indexPath, err)
+		return RebuildIndexWithOptions(fs, dir, opts)
+	}
+
+	updated := make(map[string]bool)
+	var fresh []IndexEntry
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || filepath.Ext(path) != ".md" || ensureWithinDir(dir, path) != nil {
+			continue
+		}
+		updated[filepath.ToSlash(rel)] = true
+
+		data, err := fs.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			opts.logger().Errorf("Failed to read file %s: %v\n", path, err)
+			return err
+		}
+		fileEntries, err := indexEntries(dir, path, data, opts.logger())
+		if err != nil {
+			return err
+		}
+		fresh = append(fresh, fileEntries...)
+	}
+
+	entries = slices.DeleteFunc(entries, func(entry IndexEntry) bool { return updated[entry.Path] })
+	return writeIndex(fs, dir, append(entries, fresh...), opts)
+}
+
+// indexEntries returns an entry for each note in the file at path, whose
+// contents are data. Notes that can't be parsed are left out with a warning.
+func indexEntries(dir, path string, data []byte, log logging.Logger) ([]IndexEntry, error) {
+	fileNotes, err := SplitNotesFromFile(string(data))
+	if err != nil {
+		log.Warnf("Warning: leaving unparseable notes in %s out of the index: %v\n", path, err)
+	}
+
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []IndexEntry
+	for _, note := range fileNotes {
+		tags := note.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		entries = append(entries, IndexEntry{Title: note.Title, Date: note.Date.String(), Tags: tags, Path: filepath.ToSlash(rel)})
+	}
+	return entries, nil
+}
+
+// writeIndex sorts entries and writes them to IndexFileName in dir, unless
+// the file already holds exactly that index.
+func writeIndex(fs FileSystem, dir string, entries []IndexEntry, opts Options) error {
+	if entries == nil {
+		entries = []IndexEntry{}
+	}
+
+	// Each file's entries are in file order, so a stable sort keeps them that way
+	slices.SortStableFunc(entries, func(a, b IndexEntry) int {
+		if c := cmp.Compare(a.Date, b.Date); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		opts.logger().Errorf("Failed to serialize note index")
+		return err
+	}
+	data = append(data, '\n')
+
+	indexPath := filepath.Join(dir, IndexFileName)
+	existing, err := fs.ReadFile(indexPath)
+	if err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		opts.logger().Errorf("Failed to read index %s: %v\n", indexPath, err)
+		return err
+	}
+
+	if err := ensureDir(fs, dir, opts.dirPerm()); err != nil {
+		return err
+	}
+	if err := fs.WriteFile(indexPath, data, opts.filePerm()); err != nil {
+		opts.logger().Errorf("Failed to write index %s: %v\n", indexPath, err)
+		return err
+	}
+	return nil
+}
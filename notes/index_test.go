@@ -0,0 +1,104 @@
+package notes
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRebuildIndex(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.Files[filepath.Join("/notes", "2023/10", "02.md")] = "---\ntitle: Later\ndate: 2023-10-02\ntags:\n  - work\n---\nContent.\n"
+	fs.Files[filepath.Join("/notes", "2023/10", "01.md")] = "---\ntitle: Earlier\ndate: 2023-10-01\n---\nContent.\n---\ntitle: Same Day\ndate: 2023-10-01\n---\nContent.\n"
+	fs.Files[filepath.Join("/notes", "projects", "01.md")] = "---\ntitle: Project\ndate: 2023-10-01\n---\nContent.\n"
+	fs.Files[filepath.Join("/notes", "broken.md")] = "---\ntitle: [broken\n---\nContent.\n"
+
+	if err := RebuildIndex(fs, "/notes"); err != nil {
+		t.Fatalf("RebuildIndex failed: %v", err)
+	}
+
+	indexPath := filepath.Join("/notes", IndexFileName)
+	var entries []IndexEntry
+	if err := json.Unmarshal([]byte(fs.Files[indexPath]), &entries); err != nil {
+		t.Fatalf("Failed to parse index: %v\n%s", err, fs.Files[indexPath])
+	}
+
+	expected := []IndexEntry{
+		{Title: "Earlier", Date: "2023-10-01", Tags: []string{}, Path: "2023/10/01.md"},
+		{Title: "Same Day", Date: "2023-10-01", Tags: []string{}, Path: "2023/10/01.md"},
+		{Title: "Project", Date: "2023-10-01", Tags: []string{}, Path: "projects/01.md"},
+		{Title: "Later", Date: "2023-10-02", Tags: []string{"work"}, Path: "2023/10/02.md"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d: %+v", len(expected), len(entries), entries)
+	}
+	for i := range expected {
+		if entries[i].Title != expected[i].Title || entries[i].Date != expected[i].Date ||
+			entries[i].Path != expected[i].Path || len(entries[i].Tags) != len(expected[i].Tags) {
+			t.Errorf("Entry %d: expected %+v, got %+v", i, expected[i], entries[i])
+		}
+	}
+
+	// An unchanged index isn't rewritten
+	writes := fs.Writes
+	if err := RebuildIndex(fs, "/notes"); err != nil {
+		t.Fatalf("RebuildIndex failed: %v", err)
+	}
+	if fs.Writes != writes {
+		t.Errorf("Expected no writes for an unchanged index, got %d", fs.Writes-writes)
+	}
+}
+
+func TestUpdateIndex(t *testing.T) {
+	fs := NewMockFileSystem()
+	first := filepath.Join("/notes", "2023/10", "01.md")
+	second := filepath.Join("/notes", "2023/10", "02.md")
+	fs.Files[first] = "---\ntitle: Earlier\ndate: 2023-10-01\n---\nContent.\n"
+	fs.Files[second] = "---\ntitle: Later\ndate: 2023-10-02\n---\nContent.\n"
+	if err := RebuildIndex(fs, "/notes"); err != nil {
+		t.Fatalf("RebuildIndex failed: %v", err)
+	}
+
+	// A file changed outside the update isn't read again, so it stays as it was indexed
+	fs.Files[second] = "---\ntitle: Renamed By Hand\ndate: 2023-10-02\n---\nContent.\n"
+	fs.Files[first] += "---\ntitle: Added\ndate: 2023-10-01\ntags: [new]\n---\nContent.\n"
+	if err := updateIndex(fs, "/notes", []string{first, "/elsewhere/03.md"}, Options{}); err != nil {
+		t.Fatalf("updateIndex failed: %v", err)
+	}
+
+	var entries []IndexEntry
+	if err := json.Unmarshal([]byte(fs.Files[filepath.Join("/notes", IndexFileName)]), &entries); err != nil {
+		t.Fatalf("Failed to parse index: %v", err)
+	}
+	var titles []string
+	for _, entry := range entries {
+		titles = append(titles, entry.Title)
+	}
+	if expected := []string{"Earlier", "Added", "Later"}; !slices.Equal(titles, expected) {
+		t.Errorf("Expected %v, got %v", expected, titles)
+	}
+
+	// A full rebuild picks up the change made by hand
+	if err := RebuildIndex(fs, "/notes"); err != nil {
+		t.Fatalf("RebuildIndex failed: %v", err)
+	}
+	if !strings.Contains(fs.Files[filepath.Join("/notes", IndexFileName)], "Renamed By Hand") {
+		t.Errorf("Expected the rebuilt index to include the renamed note, got:\n%s", fs.Files[filepath.Join("/notes", IndexFileName)])
+	}
+}
+
+func TestProcessNotes_RebuildsIndex(t *testing.T) {
+	data := "---\ntitle: Indexed\ndate: 2023-10-01\n---\nContent.\n"
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+		t.Fatalf("ProcessNotes failed: %v", err)
+	}
+
+	expected := "[\n  {\n    \"title\": \"Indexed\",\n    \"date\": \"2023-10-01\",\n    \"tags\": [],\n    \"path\": \"2023/10/01.md\"\n  }\n]\n"
+	if got := fs.Files[filepath.Join("/notes", IndexFileName)]; got != expected {
+		t.Errorf("Index mismatch.\nExpected:\n%s\nGot:\n%s", expected, got)
+	}
+}
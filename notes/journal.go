@@ -0,0 +1,112 @@
+package notes
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// topTagsLimit caps how many tags JournalStats reports.
+const topTagsLimit = 10
+
+// TagCount is a tag and the number of notes using it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// JournalStats summarizes journaling habits across saved notes.
+type JournalStats struct {
+	TotalNotes    int            `json:"total_notes"`
+	NotesPerMonth map[string]int `json:"notes_per_month"` // Keyed by "2006-01"
+	TopTags       []TagCount     `json:"top_tags"`
+	LongestStreak int            `json:"longest_streak"` // Most consecutive days with a note
+	CurrentStreak int            `json:"current_streak"` // Consecutive days with a note ending today or yesterday
+}
+
+// ComputeJournalStats reads every saved note under dir and summarizes them.
+// Notes that can't be parsed are left out. today anchors the current streak.
+func ComputeJournalStats(fs FileSystem, dir string, today time.Time) (JournalStats, error) {
+	stats := JournalStats{NotesPerMonth: make(map[string]int), TopTags: []TagCount{}}
+
+	stored, err := ListNotes(fs, dir)
+	if err != nil {
+		return stats, err
+	}
+
+	tagCounts := make(map[string]int)
+	days := make(map[time.Time]bool)
+	for _, note := range stored {
+		if note.Err != nil {
+			continue
+		}
+		stats.TotalNotes++
+
+		seen := make(map[string]bool, len(note.Tags))
+		for _, tag := range note.Tags {
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tagCounts[tag]++
+		}
+
+		if note.Date.Time.IsZero() {
+			continue
+		}
+		date := note.Date.Time
+		stats.NotesPerMonth[date.Format("2006-01")]++
+		days[date] = true
+	}
+
+	for tag, count := range tagCounts {
+		stats.TopTags = append(stats.TopTags, TagCount{Tag: tag, Count: count})
+	}
+	slices.SortFunc(stats.TopTags, func(a, b TagCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+	if len(stats.TopTags) > topTagsLimit {
+		stats.TopTags = stats.TopTags[:topTagsLimit]
+	}
+
+	stats.LongestStreak = longestStreak(days)
+	stats.CurrentStreak = currentStreak(days, today)
+
+	return stats, nil
+}
+
+// longestStreak returns the most consecutive days present in days.
+func longestStreak(days map[time.Time]bool) int {
+	longest := 0
+	for day := range days {
+		// Only count from the first day of each run
+		if days[day.AddDate(0, 0, -1)] {
+			continue
+		}
+		length := 1
+		for days[day.AddDate(0, 0, length)] {
+			length++
+		}
+		longest = max(longest, length)
+	}
+	return longest
+}
+
+// currentStreak counts consecutive days with notes ending today, or
+// yesterday if there's no note today yet.
+func currentStreak(days map[time.Time]bool, today time.Time) int {
+	day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if !days[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for days[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
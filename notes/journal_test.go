@@ -0,0 +1,51 @@
+package notes
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestComputeJournalStats(t *testing.T) {
+	fs := NewMockFileSystem()
+	for _, date := range []string{"2023-09-29", "2023-09-30", "2023-10-01", "2023-10-05", "2023-10-06"} {
+		day, _ := time.Parse(isoDateLayout, date)
+		path := filepath.Join("/notes", day.Format("2006/01/02")+".md")
+		fs.Files[path] = fmt.Sprintf("---\ntitle: Entry\ndate: %s\ntags:\n  - journal\n  - journal\n---\nContent.\n", date)
+	}
+	fs.Files[filepath.Join("/notes", "2023/10", "05.md")] += "---\ntitle: Work\ndate: 2023-10-05\ntags:\n  - work\n---\nContent.\n"
+	fs.Files[filepath.Join("/notes", "2023/10", "09.md")] = "---\ntitle: [broken\n---\nContent.\n"
+
+	stats, err := ComputeJournalStats(fs, "/notes", time.Date(2023, time.October, 7, 12, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatalf("ComputeJournalStats failed: %v", err)
+	}
+
+	if stats.TotalNotes != 6 {
+		t.Errorf("Expected 6 notes, got %d", stats.TotalNotes)
+	}
+	if stats.NotesPerMonth["2023-09"] != 2 || stats.NotesPerMonth["2023-10"] != 4 {
+		t.Errorf("Unexpected notes per month: %v", stats.NotesPerMonth)
+	}
+	expectedTags := []TagCount{{Tag: "journal", Count: 5}, {Tag: "work", Count: 1}}
+	if fmt.Sprint(stats.TopTags) != fmt.Sprint(expectedTags) {
+		t.Errorf("Expected top tags %v, got %v", expectedTags, stats.TopTags)
+	}
+	if stats.LongestStreak != 3 {
+		t.Errorf("Expected longest streak 3, got %d", stats.LongestStreak)
+	}
+	if stats.CurrentStreak != 2 {
+		t.Errorf("Expected current streak 2 ending yesterday, got %d", stats.CurrentStreak)
+	}
+}
+
+func TestComputeJournalStats_Empty(t *testing.T) {
+	stats, err := ComputeJournalStats(NewMockFileSystem(), "/notes", time.Now())
+	if err != nil {
+		t.Fatalf("ComputeJournalStats failed: %v", err)
+	}
+	if stats.TotalNotes != 0 || stats.LongestStreak != 0 || stats.CurrentStreak != 0 || len(stats.TopTags) != 0 {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+}
@@ -0,0 +1,54 @@
+package notes
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// LintIssue is a problem with a note saved under the notes directory.
+type LintIssue struct {
+	Path  string // File the note is in
+	Line  int    // Line the note's front matter starts on, starting at 1
+	Title string // The note's title, empty if it couldn't be parsed
+	Err   error
+}
+
+func (i LintIssue) Error() string {
+	if i.Title == "" {
+		return fmt.Sprintf("%s:%d: %v", i.Path, i.Line, i.Err)
+	}
+	return fmt.Sprintf("%s:%d: %q: %v", i.Path, i.Line, i.Title, i.Err)
+}
+
+func (i LintIssue) Unwrap() error {
+	return i.Err
+}
+
+// LintNotes checks every note saved under dir, reporting front matter that
+// can't be parsed and notes that would fail validation if they were processed
+// with opts. Issues are returned in file and line order.
+func LintNotes(fs FileSystem, dir string, opts Options) ([]LintIssue, error) {
+	var issues []LintIssue
+	err := walkNoteFiles(fs, dir, func(path string, data []byte) error {
+		parsed := parseSavedNotes(string(data), opts)
+		for _, noteErr := range parsed.Errors {
+			issues = append(issues, LintIssue{Path: path, Line: noteErr.Line, Err: noteErr.Err})
+		}
+		for _, note := range parsed.Notes {
+			if err := validateNote(note, opts); err != nil {
+				issues = append(issues, LintIssue{Path: path, Line: note.start, Title: note.Title, Err: err})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse errors and validation errors were gathered separately for each file
+	slices.SortStableFunc(issues, func(a, b LintIssue) int {
+		return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.Line, b.Line))
+	})
+	return issues, nil
+}
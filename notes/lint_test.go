@@ -0,0 +1,53 @@
+package notes
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintNotes(t *testing.T) {
+	fs := NewMockFileSystem()
+	clean := filepath.Join("notes", "2023", "10", "01.md")
+	broken := filepath.Join("notes", "2023", "10", "02.md")
+	fs.Files[clean] = "---\ntitle: Standup\ndate: 2023-10-01\n---\nFine.\n"
+	fs.Files[broken] = "---\ntitle: Fine\ndate: 2023-10-02\n---\nNothing wrong.\n" +
+		"---\ndate: 2023-10-02\n---\nNo title.\n" +
+		"---\ntitle: [unclosed\ndate: 2023-10-02\n---\nBad YAML.\n" +
+		"---\ntitle: Typo\ndate: 3023-10-02\n---\nFar future.\n"
+	fs.Files[filepath.Join("notes", "index.json")] = "not a note"
+
+	issues, err := LintNotes(fs, "notes", Options{Now: fixedClock})
+	if err != nil {
+		t.Fatalf("LintNotes failed: %v", err)
+	}
+
+	expected := []struct {
+		line  int
+		title string
+		err   string
+	}{
+		{line: 6, err: "missing title"},
+		{line: 10, err: "yaml"},
+		{line: 15, title: "Typo", err: "out of range"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %v", len(expected), issues)
+	}
+	for i, want := range expected {
+		issue := issues[i]
+		if issue.Path != broken || issue.Line != want.line || issue.Title != want.title || !strings.Contains(issue.Err.Error(), want.err) {
+			t.Errorf("Issue %d: expected %s:%d %q %q, got %+v", i, broken, want.line, want.title, want.err, issue)
+		}
+	}
+	if got := issues[2].Error(); !strings.HasPrefix(got, broken+`:15: "Typo": date 3023-10-02 is out of range`) {
+		t.Errorf("Unexpected issue message %q", got)
+	}
+
+	// Options such as Strict apply as they do when processing
+	fs.Files[clean] = "---\ntitle: Standup\ndate: 2023-10-01\ntag: work\n---\nFine.\n"
+	issues, err = LintNotes(fs, "notes", Options{Now: fixedClock, Strict: true})
+	if err != nil || len(issues) != 4 || issues[0].Path != clean || issues[0].Line != 1 {
+		t.Errorf("Expected the unknown key reported under Strict, got %v, %v", issues, err)
+	}
+}
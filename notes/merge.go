@@ -0,0 +1,152 @@
+package notes
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MergeResult describes what MergeDay moved into a day's file.
+type MergeResult struct {
+	Path    string   // The day's file the notes were merged into
+	Sources []string // Files notes were taken from, in the order they were read
+	Merged  int      // Notes added to Path
+	Skipped int      // Notes already in Path, which were not added again
+}
+
+// MergeDay gathers the notes dated date from every file under opts.NotesDir,
+// such as slugged or folder files, and adds them to the day's file from
+// DailyNotePath in file then append order, keeping each note's text. With
+// remove set, merged notes are taken out of their source files, and sources
+// left without notes are deleted. Drafts are left alone. In a dry run the
+// merge is only logged.
+func MergeDay(fs FileSystem, date time.Time, remove bool, opts Options) (MergeResult, error) {
+	log := opts.logger()
+	day := date.Format(isoDateLayout)
+
+	target, err := DailyNotePath(date, opts)
+	if err != nil {
+		log.Errorf("Failed to find the file for %s: %v\n", day, err)
+		return MergeResult{}, err
+	}
+	result := MergeResult{Path: target}
+
+	drafts := filepath.Join(opts.NotesDir, DraftsDir) + string(filepath.Separator)
+	err = walkNoteFiles(fs, opts.NotesDir, func(path string, data []byte) error {
+		if path == target || strings.HasPrefix(path, drafts) {
+			return nil
+		}
+
+		preamble, entries, err := orderedEntries(string(data), opts)
+		if err != nil {
+			return err
+		}
+
+		var kept []orderedEntry
+		merged := 0
+		for _, entry := range entries {
+			note, ok := entryNote(entry)
+			if !ok || note.Date.String() != day {
+				kept = append(kept, entry)
+				continue
+			}
+			merged++
+
+			exists, err := noteAlreadyExists(fs, target, note, opts)
+			if err != nil {
+				return err
+			}
+			if exists {
+				log.Infof("Skipping %q from %s, already in %s\n", note.Title, path, target)
+				result.Skipped++
+				continue
+			}
+
+			if opts.DryRun {
+				log.Infof("[dry-run] Would merge %q from %s into %s\n", note.Title, path, target)
+			} else {
+				if err := ensureDir(fs, filepath.Dir(target), opts.dirPerm()); err != nil {
+					log.Errorf("Failed to create directories for file %s: %v\n", target, err)
+					return err
+				}
+				if err := appendNoteInOrder(fs, target, note, entry.text, opts); err != nil {
+					log.Errorf("Failed to merge %q into %s: %v\n", note.Title, target, err)
+					return err
+				}
+			}
+			result.Merged++
+		}
+		if merged == 0 {
+			return nil
+		}
+
+		result.Sources = append(result.Sources, path)
+		if remove {
+			return removeMerged(fs, path, preamble, kept, opts)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if result.Merged > 0 && !opts.DryRun {
+		if err := RebuildIndexWithOptions(fs, opts.NotesDir, opts); err != nil {
+			log.Errorf("Failed to rebuild note index: %v\n", err)
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// entryNote parses the note in a file entry, reporting false if it can't be
+// parsed so the entry is left where it is.
+func entryNote(entry orderedEntry) (Note, bool) {
+	parsed, err := SplitNotesFromFile(entry.text)
+	if err != nil || len(parsed) != 1 {
+		return Note{}, false
+	}
+	return parsed[0], true
+}
+
+// removeMerged rewrites a source file with only the entries that weren't
+// merged, deleting it when none are left.
+func removeMerged(fs FileSystem, path, preamble string, kept []orderedEntry, opts Options) error {
+	log := opts.logger()
+	if len(kept) == 0 && strings.TrimSpace(preamble) == "" {
+		if opts.DryRun {
+			log.Infof("[dry-run] Would remove %s\n", path)
+			return nil
+		}
+		if err := fs.RemoveFile(path); err != nil {
+			log.Errorf("Failed to remove %s: %v\n", path, err)
+			return err
+		}
+		log.Infof("Removed %s\n", path)
+		return nil
+	}
+
+	var contents strings.Builder
+	contents.WriteString(preamble)
+	for i, entry := range kept {
+		if i > 0 {
+			separator, err := opts.separator(entry.note)
+			if err != nil {
+				return err
+			}
+			contents.WriteString(separator)
+		}
+		contents.WriteString(entry.text)
+	}
+
+	if opts.DryRun {
+		log.Infof("[dry-run] Would remove the merged notes from %s\n", path)
+		return nil
+	}
+	if err := fs.WriteFile(path, []byte(contents.String()), opts.filePerm()); err != nil {
+		log.Errorf("Failed to rewrite %s: %v\n", path, err)
+		return err
+	}
+	log.Infof("Removed the merged notes from %s\n", path)
+	return nil
+}
@@ -0,0 +1,109 @@
+package notes
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeDay(t *testing.T) {
+	target := filepath.Join("notes", "2023", "10", "01.md")
+	slugged := filepath.Join("notes", "2023", "10", "01-standup.md")
+	folder := filepath.Join("notes", "projects", "acme", "01.md")
+	other := filepath.Join("notes", "projects", "acme", "02.md")
+	draft := filepath.Join("notes", DraftsDir, "idea.md")
+
+	standup := "---\ntitle: Standup\ndate: 2023-10-01\nslug: standup\n---\nBlocked on review.\n\n"
+	kickoff := "---\ntitle: Acme Kickoff\ndate: 2023-10-01\nfolder: projects/acme\n---\nMet the team.\n\n"
+	later := "---\ntitle: Acme Follow-up\ndate: 2023-10-02\nfolder: projects/acme\n---\nNext steps.\n\n"
+
+	seed := func() *MockFileSystem {
+		fs := NewMockFileSystem()
+		fs.Files[target] = "---\ntitle: Breakfast\ndate: 2023-10-01\n---\nEggs.\n\n"
+		fs.Files[slugged] = standup
+		fs.Files[folder] = kickoff + later
+		fs.Files[other] = later
+		fs.Files[draft] = "---\ntitle: Idea\ndate: 2023-10-01\ndraft: true\n---\nLater.\n\n"
+		return fs
+	}
+	date := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	fs := seed()
+	result, err := MergeDay(fs, date, false, Options{NotesDir: "notes"})
+	if err != nil {
+		t.Fatalf("MergeDay failed: %v", err)
+	}
+	if result.Path != target || result.Merged != 2 || !slices.Equal(result.Sources, []string{slugged, folder}) {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	merged, err := SplitNotesFromFile(fs.Files[target])
+	if err != nil {
+		t.Fatalf("Failed to parse merged file: %v", err)
+	}
+	var titles []string
+	for _, note := range merged {
+		titles = append(titles, note.Title)
+	}
+	if !slices.Equal(titles, []string{"Breakfast", "Standup", "Acme Kickoff"}) {
+		t.Errorf("Expected the day's notes merged in order, got %v", titles)
+	}
+	if !strings.Contains(fs.Files[target], standup) {
+		t.Errorf("Expected each note's text kept, got:\n%s", fs.Files[target])
+	}
+	if fs.Files[slugged] != standup || fs.Files[folder] != kickoff+later {
+		t.Error("Expected sources left untouched without delete")
+	}
+
+	// Merging again doesn't duplicate notes
+	result, err = MergeDay(fs, date, false, Options{NotesDir: "notes"})
+	if err != nil || result.Merged != 0 || result.Skipped != 2 {
+		t.Errorf("Expected merged notes to be skipped, got %+v, %v", result, err)
+	}
+}
+
+func TestMergeDay_Delete(t *testing.T) {
+	target := filepath.Join("notes", "2023", "10", "01.md")
+	slugged := filepath.Join("notes", "2023", "10", "01-standup.md")
+	folder := filepath.Join("notes", "projects", "acme", "01.md")
+
+	later := "---\ntitle: Acme Follow-up\ndate: 2023-10-02\nfolder: projects/acme\n---\nNext steps.\n\n"
+	fs := NewMockFileSystem()
+	fs.Files[slugged] = "---\ntitle: Standup\ndate: 2023-10-01\nslug: standup\n---\nBlocked on review.\n\n"
+	fs.Files[folder] = "---\ntitle: Acme Kickoff\ndate: 2023-10-01\nfolder: projects/acme\n---\nMet the team.\n\n" + later
+
+	date := time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := MergeDay(fs, date, true, Options{NotesDir: "notes"}); err != nil {
+		t.Fatalf("MergeDay failed: %v", err)
+	}
+
+	merged, err := SplitNotesFromFile(fs.Files[target])
+	if err != nil || len(merged) != 2 {
+		t.Fatalf("Expected a single merged file with both notes, got %v, %v", merged, err)
+	}
+	if _, ok := fs.Files[slugged]; ok {
+		t.Error("Expected the emptied source to be removed")
+	}
+	if fs.Files[folder] != later {
+		t.Errorf("Expected only the other day's note left in its file, got:\n%s", fs.Files[folder])
+	}
+	if _, ok := fs.Files[filepath.Join("notes", IndexFileName)]; !ok {
+		t.Error("Expected the index to be rebuilt")
+	}
+}
+
+func TestMergeDay_DryRun(t *testing.T) {
+	slugged := filepath.Join("notes", "2023", "10", "01-standup.md")
+	fs := NewMockFileSystem()
+	fs.Files[slugged] = "---\ntitle: Standup\ndate: 2023-10-01\nslug: standup\n---\nBlocked on review.\n\n"
+
+	result, err := MergeDay(fs, time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC), true, Options{NotesDir: "notes", DryRun: true})
+	if err != nil || result.Merged != 1 {
+		t.Fatalf("Expected a merge to be reported, got %+v, %v", result, err)
+	}
+	if fs.Writes != 0 {
+		t.Errorf("Expected no writes in a dry run, got %d", fs.Writes)
+	}
+}
@@ -1,41 +1,122 @@
 package notes
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	"github.com/jasonmichels/chrononoteai/ai"
+	"github.com/jasonmichels/chrononoteai/logging"
 	"gopkg.in/yaml.v3"
 )
 
+// isoDateLayout is the layout dates are normalized to in saved front matter.
+const isoDateLayout = "2006-01-02"
+
+// dateTimeLayouts are the date and time of day forms a date may be written
+// in without an offset, e.g. "2023-10-01 09:30".
+var dateTimeLayouts = []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02T15:04:05"}
+
 // Note represents a single note with metadata and content.
 type Note struct {
-	Title   string   `yaml:"title"`
-	Date    string   `yaml:"date"`
-	Tags    []string `yaml:"tags"`
-	Content string   `yaml:"-"`
+	Title   string    `yaml:"title" json:"title"`
+	Date    Date      `yaml:"date" json:"date"`
+	Time    string    `yaml:"time" json:"time,omitempty"`
+	Summary string    `yaml:"summary" json:"summary,omitempty"`
+	Tags    []string  `yaml:"tags" json:"tags"`
+	Aliases []string  `yaml:"aliases" json:"aliases,omitempty"` // Alternate names the note can be found by, see ResolveAlias
+	Folder  string    `yaml:"folder" json:"folder,omitempty"`
+	Dir     string    `yaml:"dir" json:"dir,omitempty"`
+	Slug    string    `yaml:"slug" json:"slug,omitempty"`
+	Draft   bool      `yaml:"draft" json:"draft,omitempty"`
+	Updated time.Time `yaml:"updated" json:"updated"`
+	Content string    `yaml:"-" json:"content"`
+
+	// Timestamp is the full time when the date was given as an RFC 3339 timestamp
+	Timestamp time.Time `yaml:"-" json:"-"`
+
+	index       int      // Position in the buffer it was parsed from, starting at 1
+	start       int      // Line its front matter starts on in the buffer, starting at 1
+	line        int      // Line of the imported record it was read from, see ImportNotes
+	unknownKeys []string // Front matter keys that aren't recognized, sorted
 }
 
 // FrontMatter represents the YAML front matter of a note.
 type FrontMatter struct {
-	Title string   `yaml:"title"`
-	Date  string   `yaml:"date"`
-	Tags  []string `yaml:"tags"`
+	Title   string    `yaml:"title"`
+	Date    Date      `yaml:"date"`
+	Time    string    `yaml:"time,omitempty"`
+	Summary string    `yaml:"summary,omitempty"`
+	Tags    []string  `yaml:"tags"`
+	Aliases []string  `yaml:"aliases,omitempty"`
+	Folder  string    `yaml:"folder,omitempty"`
+	Dir     string    `yaml:"dir,omitempty"`
+	Slug    string    `yaml:"slug,omitempty"`
+	Draft   bool      `yaml:"draft,omitempty"`
+	Updated time.Time `yaml:"updated,omitempty"`
+
+	Words          int `yaml:"words"`
+	ReadingMinutes int `yaml:"reading_minutes"`
 }
 
 // FileSystem interface for dependency injection in file operations.
 type FileSystem interface {
 	ReadFile(path string) ([]byte, error)
 	WriteFile(path string, data []byte, perm os.FileMode) error
-	AppendToFile(path string, data string) error
+	AppendToFile(path string, data string, perm os.FileMode) error // perm applies if the file is created
 	MkdirAll(path string, perm os.FileMode) error
+	Exists(path string) (bool, error)
+	ListFiles(root string) ([]string, error)
+	TruncateIfUnchanged(path string, expected []byte) error
+	RemoveFile(path string) error
+	CopyFile(src, dst string, perm os.FileMode) error // perm applies if dst is created
+}
+
+// BackupSuffix is added to a file's path to name the copy Options.Backup makes.
+const BackupSuffix = ".bak"
+
+// DraftsDir is the folder within the notes directory that draft notes are saved to.
+const DraftsDir = "drafts"
+
+// InboxFileName is the file in the notes directory undated notes are added to
+// under UndatedInbox.
+const InboxFileName = "inbox.md"
+
+// UndatedPolicy is what happens to a note that has no date.
+type UndatedPolicy string
+
+const (
+	UndatedError UndatedPolicy = "error" // Reject the note; the default
+	UndatedToday UndatedPolicy = "today" // Date the note with the current day
+	UndatedInbox UndatedPolicy = "inbox" // Add the note to InboxFileName
+)
+
+// ParseUndatedPolicy returns the policy with the given name, ignoring case. An
+// empty name is unset, leaving the choice to Options.DefaultToday.
+func ParseUndatedPolicy(name string) (UndatedPolicy, error) {
+	switch policy := UndatedPolicy(strings.ToLower(name)); policy {
+	case "", UndatedError, UndatedToday, UndatedInbox:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown undated policy %q, expected error, today, or inbox", name)
 }
 
+// ErrBufferChanged is returned by TruncateIfUnchanged when the file no longer
+// starts with the contents that were processed.
+var ErrBufferChanged = errors.New("buffer changed since it was read")
+
 // OSFileSystem implements FileSystem using the OS package.
 type OSFileSystem struct{}
 
@@ -43,162 +124,1542 @@ func (fs OSFileSystem) ReadFile(path string) ([]byte, error) {
 	return os.ReadFile(path)
 }
 
+// WriteFile replaces the file's contents atomically, see writeFileAtomic.
 func (fs OSFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
-	return os.WriteFile(path, data, perm)
+	return writeFileAtomic(path, data, perm)
 }
 
-func (fs OSFileSystem) AppendToFile(path string, data string) error {
-	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+// AppendToFile appends data by rewriting the whole file atomically, so a crash
+// mid-write never leaves a half-written note behind. A new file gets perm.
+func (fs OSFileSystem) AppendToFile(path string, data string, perm os.FileMode) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logging.Errorf("Failed to read file %s: %v", path, err)
+		return err
+	}
+
+	if err := writeFileAtomic(path, append(existing, data...), perm); err != nil {
+		logging.Errorf("Failed to write to file %s: %v", path, err)
+		return err
+	}
+	return nil
+}
+
+// TruncateIfUnchanged removes the expected contents from the start of the file,
+// keeping anything written after them since they were read. If the file no
+// longer starts with expected it is left untouched and ErrBufferChanged is returned.
+//
+// The file is checked again just before the rewrite replaces it, so a save or
+// a delete and recreate made while the remainder was being written is not
+// overwritten. The rename itself is not guarded; a write landing between that
+// last check and the rename is still lost.
+//
+// Unlike the other write methods it takes no perm: it never creates the file,
+// and the buffer belongs to the user, so it keeps the permissions it has.
+func (fs OSFileSystem) TruncateIfUnchanged(path string, expected []byte) error {
+	before, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	remaining, err := unprocessedRemainder(current, expected)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomicIf(path, remaining, before.Mode().Perm(), func() error {
+		return unchangedSince(path, before)
+	})
+}
+
+// unchangedSince returns ErrBufferChanged if the file at path was replaced,
+// removed or modified since before was taken.
+func unchangedSince(path string, before os.FileInfo) error {
+	after, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrBufferChanged
+	}
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(before, after) || before.Size() != after.Size() || !before.ModTime().Equal(after.ModTime()) {
+		return ErrBufferChanged
+	}
+	return nil
+}
+
+// unprocessedRemainder returns what follows expected in current, or
+// ErrBufferChanged if current doesn't start with expected.
+func unprocessedRemainder(current, expected []byte) ([]byte, error) {
+	if !bytes.HasPrefix(current, expected) {
+		return nil, ErrBufferChanged
+	}
+	return current[len(expected):], nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so the file holds either its old or new contents and
+// never a partial write. An existing file keeps its permissions; a new file
+// gets perm. The temporary file is removed if anything fails.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	return writeFileAtomicIf(path, data, perm, nil)
+}
+
+// writeFileAtomicIf is writeFileAtomic, calling check just before the rename
+// when it isn't nil. If check fails path is left untouched and its error returned.
+func writeFileAtomicIf(path string, data []byte, perm os.FileMode, check func() error) (err error) {
+	if info, statErr := os.Stat(path); statErr == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
-		log.Printf("Failed to open file %s: %v", path, err)
 		return err
 	}
 	defer func() {
-		if closeError := f.Close(); closeError != nil && err == nil {
-			err = fmt.Errorf("failed to close file %s: %w", path, closeError)
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
 		}
 	}()
-	_, err = f.WriteString(data)
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close file %s: %w", tmp.Name(), err)
+	}
+	if check != nil {
+		if err = check(); err != nil {
+			return err
+		}
+	}
+
+	return renameFile(tmp.Name(), path)
+}
+
+// renameFile moves the finished temporary file into place. Tests replace it
+// to simulate a write that fails before the original is replaced.
+var renameFile = os.Rename
+
+// CopyFile replaces dst with the contents of src atomically. A new dst gets perm.
+func (fs OSFileSystem) CopyFile(src, dst string, perm os.FileMode) error {
+	data, err := os.ReadFile(src)
 	if err != nil {
-		log.Printf("Failed to write to file %s: %v", path, err)
 		return err
 	}
-	return nil
+	return writeFileAtomic(dst, data, perm)
+}
+
+// RemoveFile deletes the file at path.
+func (fs OSFileSystem) RemoveFile(path string) error {
+	return os.Remove(path)
 }
 
 func (fs OSFileSystem) MkdirAll(path string, perm os.FileMode) error {
 	return os.MkdirAll(path, perm)
 }
 
-// ProcessNotes parses, validates, and saves notes from the provided data.
-func ProcessNotes(data, markdownDir string, fs FileSystem) error {
-	notes, err := parseNotes(data)
+// Exists reports whether a file or directory exists at path.
+func (fs OSFileSystem) Exists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListFiles returns the paths of all regular files under root in lexical order.
+func (fs OSFileSystem) ListFiles(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// Options controls how notes are parsed and where they are saved.
+type Options struct {
+	NotesDir          string
+	DateLayouts       []string      // Layouts tried in order when parsing a note's date
+	DryRun            bool          // Log where notes would be written without touching the filesystem
+	Force             bool          // Write notes even when an identical note is already saved
+	Backup            bool          // Copy each existing file to BackupSuffix before notes are added to it
+	DefaultToday      bool          // Date notes that have no date with the current day instead of rejecting them
+	Undated           UndatedPolicy // What to do with notes that have no date; overrides DefaultToday when set
+	Strict            bool          // Reject notes with unknown front matter keys instead of warning about them
+	RequireContent    bool          // Reject notes whose content is empty or only whitespace
+	UniqueTitlePerDay bool          // Reject a batch in which two notes share a date and title
+	MinYear           int           // Earliest year a note may be dated; defaults to DefaultMinYear
+	MaxYear           int           // Latest year a note may be dated; defaults to next year
+
+	TagPattern    *regexp.Regexp // Optional; tags must match it
+	DefaultTags   []string       // Added to every note that doesn't already have them
+	LowercaseTags bool           // Lowercase tags when saving; duplicates are dropped ignoring case either way
+	SanitizeTags  bool           // Quote tags with characters that break plain YAML instead of rejecting them
+
+	PathTemplate string // Time layout or Go template for a note's path within NotesDir; see RenderPath
+
+	TagSuggester ai.TagSuggester              // Optional; fills in tags for notes that have none
+	Now          func() time.Time             // Clock for timestamps and default dates; defaults to the package clock
+	OnWrite      func(path string, note Note) // Optional; called after each note is written, one call at a time
+
+	InlineSingleTag          bool   // Write a lone tag as "tags: [tag]" instead of a block list
+	NoteSeparator            string // Template written between notes in the same file, e.g. "***" or "## {{.Time}}"
+	NoFrontMatter            bool   // Write notes as plain Markdown without YAML front matter; see formatPlainNote
+	SingleFrontMatterPerFile bool   // Write notes added to a file that has front matter as "## Title" sections; see saveSection
+	WordsPerMinute           int    // Reading speed for reading_minutes; defaults to 200
+
+	Concurrency int // Files written at once; defaults to GOMAXPROCS
+
+	FilePerm os.FileMode // Permissions for new note files; defaults to DefaultFilePerm
+	DirPerm  os.FileMode // Permissions for new directories; defaults to DefaultDirPerm
+
+	Logger logging.Logger // Optional; receives processing messages instead of the default logger
+}
+
+// Default permissions for new files and directories.
+const (
+	DefaultFilePerm os.FileMode = 0o644
+	DefaultDirPerm  os.FileMode = os.ModePerm
+)
+
+// filePerm returns the permissions for new note files.
+func (o Options) filePerm() os.FileMode {
+	if o.FilePerm != 0 {
+		return o.FilePerm
+	}
+	return DefaultFilePerm
+}
+
+// dirPerm returns the permissions for new directories.
+func (o Options) dirPerm() os.FileMode {
+	if o.DirPerm != 0 {
+		return o.DirPerm
+	}
+	return DefaultDirPerm
+}
+
+// logger returns the Logger processing messages go to.
+func (o Options) logger() logging.Logger {
+	return logging.OrDefault(o.Logger)
+}
+
+// concurrency returns how many files may be written at once.
+func (o Options) concurrency() int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// undatedPolicy returns what to do with notes that have no date.
+func (o Options) undatedPolicy() UndatedPolicy {
+	switch {
+	case o.Undated != "":
+		return o.Undated
+	case o.DefaultToday:
+		return UndatedToday
+	default:
+		return UndatedError
+	}
+}
+
+// DefaultMinYear is the earliest year a note may be dated when MinYear is unset.
+const DefaultMinYear = 1900
+
+// yearRange returns the years notes may be dated in, inclusive.
+func (o Options) yearRange() (int, int) {
+	minYear, maxYear := o.MinYear, o.MaxYear
+	if minYear == 0 {
+		minYear = DefaultMinYear
+	}
+	if maxYear == 0 {
+		maxYear = o.now().Year() + 1
+	}
+	return minYear, maxYear
+}
+
+// now is the package clock, used when Options.Now is unset. Tests can replace it.
+var now = time.Now
+
+// now returns the current time from the configured clock.
+func (o Options) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return now()
+}
+
+// pathTemplate returns the configured path template or the default layout.
+func (o Options) pathTemplate() string {
+	if o.PathTemplate != "" {
+		return o.PathTemplate
+	}
+	return DefaultPathTemplate
+}
+
+// separator renders the note separator for a note appended after another,
+// followed by a blank line. It is empty when no separator is configured.
+func (o Options) separator(note Note) (string, error) {
+	if o.NoteSeparator == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New("separator").Parse(o.NoteSeparator)
 	if err != nil {
-		log.Println("Failed to parse notes")
-		return err
+		o.logger().Errorf("Invalid note separator %q: %v\n", o.NoteSeparator, err)
+		return "", err
 	}
 
-	// Validate all notes before processing
+	var separator strings.Builder
+	if err := tmpl.Execute(&separator, note); err != nil {
+		o.logger().Errorf("Failed to render note separator %q: %v\n", o.NoteSeparator, err)
+		return "", err
+	}
+	return separator.String() + "\n\n", nil
+}
+
+// dateLayouts returns the configured date layouts followed by the ISO layout,
+// which is always accepted since it is the form dates are saved in.
+func (o Options) dateLayouts() []string {
+	for _, layout := range o.DateLayouts {
+		if layout == isoDateLayout {
+			return o.DateLayouts
+		}
+	}
+	layouts := make([]string, 0, len(o.DateLayouts)+1)
+	layouts = append(layouts, o.DateLayouts...)
+	return append(layouts, isoDateLayout)
+}
+
+// ProcessSummary reports what a run of ProcessNotes saved.
+type ProcessSummary struct {
+	Written int      // Notes written to files
+	Skipped int      // Duplicate notes that were already saved
+	Files   []string // Files notes were written to, sorted
+
+	Created  int // Files in Files that didn't exist before the run
+	Appended int // Files in Files that notes were added to
+}
+
+// ProcessNotes parses, validates, and saves notes from the provided data.
+func ProcessNotes(data, markdownDir string, fs FileSystem) (ProcessSummary, error) {
+	return ProcessNotesContext(context.Background(), data, markdownDir, fs)
+}
+
+// ProcessNotesContext is ProcessNotes with cancellation; see ProcessNotesWithOptionsContext.
+func ProcessNotesContext(ctx context.Context, data, markdownDir string, fs FileSystem) (ProcessSummary, error) {
+	return ProcessNotesWithOptionsContext(ctx, data, fs, Options{NotesDir: markdownDir})
+}
+
+// ProcessNotesWithOptions parses, validates, and saves notes using the given options.
+// Different files are saved concurrently, up to opts.Concurrency at once;
+// notes for the same file are appended in date order by a single worker.
+func ProcessNotesWithOptions(data string, fs FileSystem, opts Options) (ProcessSummary, error) {
+	return ProcessNotesWithOptionsContext(context.Background(), data, fs, opts)
+}
+
+// ProcessNotesWithOptionsContext is ProcessNotesWithOptions with cancellation.
+// The context is checked before each note is saved; once it is done no more
+// notes are started, the notes already saved are kept, and the context's
+// error is returned along with a summary of what was saved. The index is not
+// rebuilt after a canceled run.
+func ProcessNotesWithOptionsContext(ctx context.Context, data string, fs FileSystem, opts Options) (ProcessSummary, error) {
+	if err := ctx.Err(); err != nil {
+		return ProcessSummary{}, err
+	}
+
+	// Notes that fail to parse are reported at the end so the rest can still be saved
+	parsed := parseNotes(data, opts)
+	if len(parsed.Errors) > 0 {
+		opts.logger().Errorf("Failed to parse %d note(s), processing the remaining %d\n", len(parsed.Errors), len(parsed.Notes))
+	}
+	return saveNotes(ctx, parsed.Notes, parsed.Err(), fs, opts)
+}
+
+// saveNotes validates and saves parsed notes, summarizing what was saved.
+// parseErr describes notes that couldn't be parsed; it is returned once the
+// rest are saved.
+func saveNotes(ctx context.Context, notes []Note, parseErr error, fs FileSystem, opts Options) (ProcessSummary, error) {
+	// The clock is read once so every note in a run shares the same day and timestamp
+	start := opts.now()
+	for i := range notes {
+		notes[i].Tags = normalizeTags(notes[i].Tags, opts.LowercaseTags)
+
+		// Dates such as "yesterday" are counted from the run's clock
+		date, err := resolveDateKeyword(notes[i].Date.String(), start)
+		if err != nil {
+			opts.logger().Warnf("Warning: can't resolve date %q: %v\n", notes[i].Date, err)
+			continue
+		}
+		if date != notes[i].Date.String() {
+			notes[i].Date = readDate(date)
+		}
+	}
+	if opts.undatedPolicy() == UndatedToday {
+		today := Date{Time: calendarDay(start)}
+		for i := range notes {
+			if notes[i].Date.IsZero() && !notes[i].Draft {
+				opts.logger().Infof("Dating note %q today, %s\n", notes[i].Title, today)
+				notes[i].Date = today
+			}
+		}
+	}
+
+	// Validate all notes before processing, reporting every problem at once
+	var invalid []error
+	seen := make(map[[2]string]int) // Date and title to the index of the first note with them
 	for _, note := range notes {
-		if err := validateNote(note); err != nil {
-			log.Printf("Failed to validate note for date: %s, title: %s\n", note.Date, note.Title)
-			return err
+		err := validateNote(note, opts)
+		if err == nil && opts.UniqueTitlePerDay && !note.Date.IsZero() {
+			key := [2]string{note.Date.String(), note.Title}
+			if first, ok := seen[key]; ok {
+				err = fmt.Errorf("same title and date %s as note %d", note.Date, first)
+			} else {
+				seen[key] = note.index
+			}
+		}
+		if err != nil {
+			logging.With(opts.logger(), "title", note.Title).Errorf("Failed to validate note for date: %s, title: %s\n", note.Date, note.Title)
+			invalid = append(invalid, ValidationError{Index: note.index, Line: note.line, Title: note.Title, Err: err})
 		}
 	}
+	if len(invalid) > 0 {
+		opts.logger().Errorf("%d note(s) failed validation, nothing was written\n", len(invalid))
+		return ProcessSummary{}, errors.Join(parseErr, errors.Join(invalid...))
+	}
+
+	// Write in date order so same-day files aren't jumbled by buffer order
+	sortNotesByDate(notes, opts.dateLayouts())
 
-	// Process and save each note
+	updated := start.Truncate(time.Second)
+
+	// Paths are assigned in date order before anything is written, so notes in
+	// this batch that would share a title slug are renamed the same way every run
+	var files []fileNotes
+	fileIndex := make(map[string]int)
+	claimed := make(map[string]string)
 	for _, note := range notes {
-		log.Printf("Processing note for date: %s, title: %s\n", note.Date, note.Title)
-		filePath, err := buildMarkdownPath(note, markdownDir)
+		opts.logger().Debugf("Processing note for date: %s, title: %s\n", note.Date, note.Title)
+		filePath, err := assignPath(fs, note, opts, claimed)
 		if err != nil {
-			return err
+			return ProcessSummary{}, err
+		}
+		if err := ensureEncrypted(fs, filePath, opts.logger()); err != nil {
+			return ProcessSummary{}, err
+		}
+		if note.Draft {
+			logging.With(opts.logger(), "title", note.Title, "path", filePath).Infof("Note %q is a draft, saving it to %s\n", note.Title, filePath)
 		}
 
-		if err := fs.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
-			log.Printf("Failed to create directories for file %s: %v\n", filePath, err)
-			return err
+		i, ok := fileIndex[filePath]
+		if !ok {
+			i = len(files)
+			fileIndex[filePath] = i
+			files = append(files, fileNotes{path: filePath})
 		}
+		files[i].notes = append(files[i].notes, note)
+	}
 
-		// Format the note with YAML front matter
-		fullNote, err := formatNoteContent(note)
+	// Files are saved concurrently; the notes for each file are saved in order by one worker
+	var mu sync.Mutex
+	var summary ProcessSummary
+	err := forEachFile(ctx, files, opts.concurrency(), func(ctx context.Context, file fileNotes) error {
+		existed, err := fs.Exists(file.path)
 		if err != nil {
+			opts.logger().Errorf("Failed to check if %s exists: %v\n", file.path, err)
 			return err
 		}
+		if opts.Backup && !opts.DryRun {
+			if err := backupFile(fs, file.path, opts); err != nil {
+				return err
+			}
+		}
+		for i, note := range file.notes {
+			if err := ctx.Err(); err != nil {
+				if i > 0 {
+					opts.logger().Warnf("Stopped %s after %d of %d note(s), next was %q\n", file.path, i, len(file.notes), note.Title)
+				}
+				return err
+			}
 
-		if err := fs.AppendToFile(filePath, fullNote); err != nil {
-			log.Printf("Failed to write note to file %s: %v\n", filePath, err)
-			return err
+			wrote, err := saveNote(fs, file.path, note, updated, opts)
+			if err != nil {
+				return err
+			}
+			if !wrote {
+				if !opts.DryRun {
+					mu.Lock()
+					summary.Skipped++
+					mu.Unlock()
+				}
+				continue
+			}
+
+			mu.Lock()
+			if !slices.Contains(summary.Files, file.path) {
+				summary.Files = append(summary.Files, file.path)
+				if existed {
+					summary.Appended++
+				} else {
+					summary.Created++
+				}
+			}
+			summary.Written++
+			if opts.OnWrite != nil {
+				opts.OnWrite(file.path, note)
+			}
+			mu.Unlock()
+		}
+		return nil
+	})
+	slices.Sort(summary.Files)
+	if err != nil {
+		if ctx.Err() != nil {
+			opts.logger().Warnf("Processing canceled after saving %d of %d note(s): %v\n", summary.Written, len(notes), err)
 		}
-		log.Printf("Wrote note to file %s\n", filePath)
+		return summary, err
+	}
+
+	if summary.Written > 0 {
+		if err := updateIndex(fs, opts.NotesDir, summary.Files, opts); err != nil {
+			opts.logger().Errorf("Failed to update note index: %v\n", err)
+			return summary, err
+		}
+	}
+
+	return summary, parseErr
+}
+
+// saveNote formats a note and appends it to filePath, reporting whether it was
+// written. Duplicates of saved notes are skipped, and a dry run only logs a preview.
+func saveNote(fs FileSystem, filePath string, note Note, updated time.Time, opts Options) (bool, error) {
+	opts.Logger = logging.With(opts.logger(), "title", note.Title, "path", filePath)
+
+	if len(note.Tags) == 0 && opts.TagSuggester != nil {
+		note.Tags = suggestTags(opts.TagSuggester, note, opts.logger())
+	}
+	note.Tags = normalizeTags(mergeTags(note.Tags, opts.DefaultTags), opts.LowercaseTags)
+	note.Updated = updated
+
+	if opts.SingleFrontMatterPerFile && !opts.NoFrontMatter {
+		data, err := fs.ReadFile(filePath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			opts.logger().Errorf("Failed to read file %s: %v\n", filePath, err)
+			return false, err
+		}
+		if len(splitNoteBlocks(scanLines(string(data)))) > 0 {
+			return saveSection(fs, filePath, note, string(data), opts)
+		}
+	}
+
+	// Format the note with YAML front matter
+	fullNote, err := formatNoteContent(note, opts)
+	if err != nil {
+		return false, err
 	}
 
+	if !opts.Force {
+		exists, err := noteAlreadyExists(fs, filePath, note, opts)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			opts.logger().Infof("Skipping duplicate note for date: %s, title: %s already in %s\n", note.Date, note.Title, filePath)
+			return false, nil
+		}
+	}
+
+	if opts.DryRun {
+		return false, previewNote(fs, filePath, fullNote, opts.logger())
+	}
+
+	if err := ensureDir(fs, filepath.Dir(filePath), opts.dirPerm()); err != nil {
+		opts.logger().Errorf("Failed to create directories for file %s: %v\n", filePath, err)
+		return false, err
+	}
+
+	if err := touchExistingNotes(fs, filePath, note.Title, updated, opts.filePerm(), opts.logger()); err != nil {
+		return false, err
+	}
+
+	if err := appendNoteInOrder(fs, filePath, note, fullNote, opts); err != nil {
+		opts.logger().Errorf("Failed to write note to file %s: %v\n", filePath, err)
+		return false, err
+	}
+	opts.logger().Infof("Wrote note to file %s\n", filePath)
+	return true, nil
+}
+
+// saveSection appends note to a file whose existing contents already start
+// with front matter, as a "## Title" section rather than a note of its own. A
+// note already in the file, as a section or with front matter, is skipped as
+// a duplicate.
+func saveSection(fs FileSystem, filePath string, note Note, existing string, opts Options) (bool, error) {
+	section := formatSectionNote(note)
+	if !opts.Force && sectionExists(existing, note, section) {
+		opts.logger().Infof("Skipping duplicate note for date: %s, title: %s already in %s\n", note.Date, note.Title, filePath)
+		return false, nil
+	}
+
+	if opts.DryRun {
+		return false, previewNote(fs, filePath, section, opts.logger())
+	}
+
+	separator, err := opts.separator(note)
+	if err != nil {
+		return false, err
+	}
+	separator = sectionMarker + "\n" + separator
+	if !strings.HasSuffix(existing, "\n") {
+		separator = "\n" + separator
+	}
+	if err := fs.AppendToFile(filePath, separator+section, opts.filePerm()); err != nil {
+		opts.logger().Errorf("Failed to write note to file %s: %v\n", filePath, err)
+		return false, err
+	}
+	opts.logger().Infof("Wrote note to file %s\n", filePath)
+	return true, nil
+}
+
+// sectionExists reports whether note is already in a file's contents, either
+// word for word as section or as a note read back from the file.
+func sectionExists(existing string, note Note, section string) bool {
+	if strings.Contains(existing, section) {
+		return true
+	}
+
+	saved, _ := SplitNotesFromFile(existing)
+	content := strings.TrimSpace(note.Content)
+	for _, other := range saved {
+		if strings.TrimSpace(other.Content) == content &&
+			other.Title == note.Title && other.Date.String() == note.Date.String() && slices.Equal(other.Tags, note.Tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// backupFile copies an existing file to its BackupSuffix path, replacing any
+// earlier backup, so it can be restored if adding notes goes wrong. Files that
+// don't exist yet have nothing to back up.
+func backupFile(fs FileSystem, path string, opts Options) error {
+	exists, err := fs.Exists(path)
+	if err != nil || !exists {
+		return err
+	}
+
+	backupPath := path + BackupSuffix
+	if err := fs.CopyFile(path, backupPath, opts.filePerm()); err != nil {
+		opts.logger().Errorf("Failed to back up %s to %s: %v\n", path, backupPath, err)
+		return err
+	}
+	logging.With(opts.logger(), "path", path).Debugf("Backed up %s to %s\n", path, backupPath)
 	return nil
 }
 
-// parseNotes splits the input data into individual notes.
-func parseNotes(data string) ([]Note, error) {
-	var notes []Note
+// suggestTags asks the suggester for tags for an untagged note. Failures are
+// logged and leave the note untagged rather than stopping processing.
+func suggestTags(suggester ai.TagSuggester, note Note, log logging.Logger) []string {
+	tags, err := suggester.SuggestTags(note.Content)
+	if err != nil {
+		log.Errorf("Failed to suggest tags for note %s, leaving it untagged: %v\n", note.Title, err)
+		return nil
+	}
+	log.Infof("Suggested tags for note %s: %s\n", note.Title, strings.Join(tags, ", "))
+	return tags
+}
+
+// sortNotesByDate stably sorts validated notes by date, keeping buffer order within a day.
+func sortNotesByDate(notes []Note, layouts []string) {
+	slices.SortStableFunc(notes, func(a, b Note) int {
+		dateA, _ := a.Date.day(layouts)
+		dateB, _ := b.Date.day(layouts)
+		return dateA.Compare(dateB)
+	})
+}
+
+// noteAlreadyExists reports whether the file at path already holds a note with
+// the same title, date, tags, and content hash.
+func noteAlreadyExists(fs FileSystem, path string, note Note, opts Options) (bool, error) {
+	data, err := fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		opts.logger().Errorf("Failed to read file %s: %v\n", path, err)
+		return false, err
+	}
 
-	entries := strings.Split(data, "---")
-	for i := 1; i < len(entries); i += 2 {
-		var note Note
+	// Notes that can't be parsed can't be compared, but the rest still can
+	existing, err := SplitNotesFromFile(string(data))
+	if err != nil {
+		opts.logger().Warnf("Warning: failed to parse some existing notes in %s: %v\n", path, err)
+	}
+
+	hash := contentHash(note.Content)
+	for i, other := range existing {
+		// A separator before the following note reads as the end of this one
+		content := other.Content
+		if i+1 < len(existing) {
+			separator, err := opts.separator(existing[i+1])
+			if err != nil {
+				return false, err
+			}
+			content = strings.TrimSuffix(content, strings.TrimSpace(separator))
+		}
 
-		metadata := entries[i]
-		content := ""
-		if i+1 < len(entries) {
-			content = strings.TrimSpace(entries[i+1])
+		if other.Title == note.Title && other.Date.String() == note.Date.String() &&
+			contentHash(content) == hash && slices.Equal(other.Tags, note.Tags) {
+			return true, nil
 		}
+	}
+	return false, nil
+}
+
+// contentHash fingerprints note content, ignoring surrounding whitespace so
+// that a note reads the same before and after it is saved.
+func contentHash(content string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(strings.TrimSpace(content)))
+}
+
+// touchExistingNotes sets the updated timestamp on notes in the file at path
+// that share the given title, leaving the rest of the file untouched.
+func touchExistingNotes(fs FileSystem, path, title string, updated time.Time, perm os.FileMode, log logging.Logger) error {
+	data, err := fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		log.Errorf("Failed to read file %s: %v\n", path, err)
+		return err
+	}
+
+	lines := scanLines(string(data))
+	blocks := splitNoteBlocks(lines)
+	touched := false
+
+	// Work backwards so inserting a line doesn't shift blocks still to be visited
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+
+		var existing Note
+		if err := yaml.Unmarshal([]byte(block.metadata(lines)), &existing); err != nil {
+			continue
+		}
+		if existing.Title == "" {
+			existing.Title = headingTitle(block.content(lines, true))
+		}
+		if existing.Title != title {
+			continue
+		}
+
+		lines = setUpdatedLine(lines, block, updated)
+		touched = true
+	}
+
+	if !touched {
+		return nil
+	}
+
+	log.Debugf("Refreshing updated timestamp for note %s in %s\n", title, path)
+	contents := strings.Join(lines, "\n")
+	if strings.HasSuffix(string(data), "\n") {
+		contents += "\n"
+	}
+	return fs.WriteFile(path, []byte(contents), perm)
+}
+
+// setUpdatedLine replaces the block's updated front matter line, adding one
+// before the closing delimiter if there isn't one.
+func setUpdatedLine(lines []string, block noteBlock, updated time.Time) []string {
+	line := "updated: " + updated.Format(time.RFC3339)
+
+	for i := block.start + 1; i < block.end; i++ {
+		if strings.HasPrefix(lines[i], "updated:") {
+			lines[i] = line
+			return lines
+		}
+	}
+
+	return slices.Insert(lines, block.end, line)
+}
+
+// ensureDir creates dir and any missing parents with perm unless it already exists.
+func ensureDir(fs FileSystem, dir string, perm os.FileMode) error {
+	exists, err := fs.Exists(dir)
+	if err != nil || exists {
+		return err
+	}
+	return fs.MkdirAll(dir, perm)
+}
+
+// previewNote logs where a note would be written and its formatted content without writing it.
+func previewNote(fs FileSystem, filePath, fullNote string, log logging.Logger) error {
+	exists, err := fs.Exists(filePath)
+	if err != nil {
+		log.Errorf("Failed to check file %s: %v\n", filePath, err)
+		return err
+	}
+	action := "append to existing"
+	if !exists {
+		action = "create new"
+	}
+
+	log.Infof("[dry-run] Would %s file %s:\n%s", action, filePath, fullNote)
+	return nil
+}
+
+// errPathEscapes is returned when a note would be written outside the notes directory.
+var errPathEscapes = errors.New("note path escapes notes directory")
+
+// frontMatterDelimiter marks the start and end of a note's YAML front matter.
+const frontMatterDelimiter = "---"
+
+// frontMatterKeyLine matches a top-level YAML key such as "title:".
+var frontMatterKeyLine = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*\s*:(\s|$)`)
+
+// knownFrontMatterKeys are the front matter keys notes may use, including
+// the ones formatNoteContent writes to saved notes.
+var knownFrontMatterKeys = map[string]bool{
+	"title":           true,
+	"date":            true,
+	"time":            true,
+	"summary":         true,
+	"description":     true,
+	"tags":            true,
+	"aliases":         true,
+	"folder":          true,
+	"dir":             true,
+	"slug":            true,
+	"draft":           true,
+	"updated":         true,
+	"words":           true,
+	"reading_minutes": true,
+}
+
+// snippetLength caps how much of a note's front matter is quoted in parse errors.
+const snippetLength = 60
+
+// ParseResult holds the notes parsed from a buffer along with any notes whose
+// front matter could not be parsed.
+type ParseResult struct {
+	Notes  []Note
+	Errors []NoteError
+}
+
+// NoteError describes a note whose front matter could not be parsed.
+type NoteError struct {
+	Index   int    // Position of the note in the buffer, starting at 1
+	Line    int    // Line the note's front matter starts on, starting at 1
+	Snippet string // Start of the offending front matter
+	Err     error
+}
+
+func (e NoteError) Error() string {
+	return fmt.Sprintf("note %d: %v (front matter: %q)", e.Index, e.Err, e.Snippet)
+}
+
+func (e NoteError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError describes a parsed note that failed validation.
+type ValidationError struct {
+	Index int    // Position of the note in the buffer, starting at 1
+	Line  int    // Line of the record the note was imported from, or 0
+	Title string // The note's title, which may be empty
+	Err   error
+}
+
+func (e ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d (title %q): %v", e.Line, e.Title, e.Err)
+	}
+	return fmt.Sprintf("note %d (title %q): %v", e.Index, e.Title, e.Err)
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Err joins the parse errors, returning nil if every note parsed.
+func (r ParseResult) Err() error {
+	errs := make([]error, len(r.Errors))
+	for i, noteErr := range r.Errors {
+		errs[i] = noteErr
+	}
+	return errors.Join(errs...)
+}
+
+// parseNotes splits the input data into individual notes, normalizing dates
+// written in any of the configured layouts to the ISO layout and line endings
+// to LF. Notes with malformed front matter are reported in the result's Errors
+// and skipped.
+func parseNotes(data string, opts Options) ParseResult {
+	return parseNoteBlocks(data, opts, false)
+}
+
+// parseSavedNotes parses a saved note file like parseNotes, also reading back
+// the notes saveSection added to it as sections. A buffer isn't read this way,
+// so a section marker typed into a note stays part of its content.
+func parseSavedNotes(data string, opts Options) ParseResult {
+	return parseNoteBlocks(data, opts, true)
+}
+
+// parseNoteBlocks parses data for parseNotes and parseSavedNotes, splitting
+// out sections if asked to.
+func parseNoteBlocks(data string, opts Options, sections bool) ParseResult {
+	var result ParseResult
+
+	lines := scanLines(normalizeLineEndings(data))
+	for i, block := range splitNoteBlocks(lines) {
+		metadata := block.metadata(lines)
+		content := block.content(lines, sections)
 
 		if strings.TrimSpace(metadata) == "" && content == "" {
 			continue
 		}
 
-		if err := yaml.Unmarshal([]byte(metadata), &note); err != nil {
-			log.Println("Failed to parse YAML")
-			return nil, err
+		note, fields, err := decodeFrontMatter(metadata)
+		if err != nil {
+			noteErr := NoteError{Index: i + 1, Line: block.start + 1, Snippet: frontMatterSnippet(metadata), Err: err}
+			opts.logger().Errorf("Failed to parse YAML: %v\n", noteErr)
+			result.Errors = append(result.Errors, noteErr)
+			continue
+		}
+
+		// A YAML title wins, otherwise fall back to the first heading
+		if note.Title == "" {
+			note.Title = headingTitle(content)
 		}
 
+		normalizeDateTime(&note, opts)
 		note.Content = content
-		notes = append(notes, note)
+		note.index = i + 1
+		note.start = block.start + 1
+		note.unknownKeys = unknownKeys(fields)
+		if len(note.unknownKeys) > 0 {
+			opts.logger().Warnf("Warning: note %d (%q) has unknown front matter keys: %s\n", note.index, note.Title, strings.Join(note.unknownKeys, ", "))
+		}
+		result.Notes = append(result.Notes, note)
+		if sections {
+			result.Notes = append(result.Notes, block.sections(lines, note)...)
+		}
+	}
+
+	return result
+}
+
+// decodeFrontMatter parses a note's front matter once, returning the note it
+// describes and the mapping node it was read from, or nil for empty front
+// matter. description is read as an alias for summary.
+func decodeFrontMatter(metadata string) (Note, *yaml.Node, error) {
+	var note Note
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(metadata), &doc); err != nil || len(doc.Content) == 0 {
+		return note, nil, err
+	}
+
+	fields := doc.Content[0]
+	if err := fields.Decode(&note); err != nil {
+		return note, nil, err
+	}
+	if description := mappingValue(fields, "description"); description != nil && note.Summary == "" {
+		_ = description.Decode(&note.Summary)
+	}
+	return note, fields, nil
+}
+
+// unknownKeys returns the sorted keys of the front matter mapping that aren't
+// in knownFrontMatterKeys.
+func unknownKeys(fields *yaml.Node) []string {
+	if fields == nil {
+		return nil
+	}
+
+	var unknown []string
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		if key := fields.Content[i].Value; !knownFrontMatterKeys[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// frontMatterSnippet shortens front matter to a single line for error messages.
+func frontMatterSnippet(metadata string) string {
+	snippet := []rune(strings.Join(strings.Fields(metadata), " "))
+	if len(snippet) > snippetLength {
+		return string(snippet[:snippetLength]) + "..."
+	}
+	return string(snippet)
+}
+
+// noteBlock locates one note within a buffer's lines.
+type noteBlock struct {
+	start int // Opening front matter delimiter
+	end   int // Closing front matter delimiter, or len(lines) if missing
+	next  int // Start of the following note, or len(lines)
+}
+
+// splitNoteBlocks finds each note's front matter and content in lines.
+func splitNoteBlocks(lines []string) []noteBlock {
+	var blocks []noteBlock
+
+	// Anything before the first delimiter is not part of a note
+	start := nextDelimiter(lines, 0)
+	for start < len(lines) {
+		block := noteBlock{start: start, end: nextDelimiter(lines, start+1), next: len(lines)}
+		if block.end < len(lines) {
+			block.next = nextNoteStart(lines, block.end+1)
+		}
+		blocks = append(blocks, block)
+		start = block.next
+	}
+
+	return blocks
+}
+
+// metadata returns the block's raw YAML front matter.
+func (b noteBlock) metadata(lines []string) string {
+	return strings.Join(lines[b.start+1:b.end], "\n")
+}
+
+// content returns the block's trimmed content, up to any sections added after
+// it if sections is set.
+func (b noteBlock) content(lines []string, sections bool) string {
+	if b.end+1 >= b.next {
+		return ""
+	}
+	end := b.next
+	if markers := b.sectionMarkers(lines); sections && len(markers) > 0 {
+		end = markers[0]
+	}
+	return strings.TrimSpace(strings.Join(lines[b.end+1:end], "\n"))
+}
+
+// sectionMarkers returns the lines in the block's content holding sectionMarker.
+func (b noteBlock) sectionMarkers(lines []string) []int {
+	var markers []int
+	for i := b.end + 1; i < b.next; i++ {
+		if lines[i] == sectionMarker {
+			markers = append(markers, i)
+		}
+	}
+	return markers
+}
+
+// sections reads back the notes saveSection added to the block as "## Title"
+// sections. Each takes its date from parent, the note whose front matter the
+// file starts with.
+func (b noteBlock) sections(lines []string, parent Note) []Note {
+	markers := b.sectionMarkers(lines)
+	var notes []Note
+	for i, marker := range markers {
+		end := b.next
+		if i+1 < len(markers) {
+			end = markers[i+1]
+		}
+		if note, ok := parseSection(lines[marker+1 : end]); ok {
+			note.Date = parent.Date
+			note.index = parent.index
+			note.start = marker + 2
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// parseSection reads a note written by formatSectionNote. Any separator
+// before its heading is skipped, and the line after it is read as the time
+// only if it is one. It reports false if there is no heading.
+func parseSection(lines []string) (Note, bool) {
+	heading := slices.IndexFunc(lines, func(line string) bool { return strings.HasPrefix(line, "## ") })
+	if heading < 0 {
+		return Note{}, false
+	}
+
+	note := Note{Title: strings.TrimSpace(strings.TrimPrefix(lines[heading], "## "))}
+	body := lines[heading+1:]
+	if len(body) > 0 {
+		if _, err := parseTime(body[0]); err == nil {
+			note.Time = strings.TrimSpace(body[0])
+			body = body[1:]
+		}
+	}
+
+	content := strings.TrimSpace(strings.Join(body, "\n"))
+	if before, tags, ok := cutLastLine(content, "Tags: "); ok {
+		note.Tags = strings.Split(tags, ", ")
+		content = strings.TrimSpace(before)
+	}
+	note.Content = content
+	return note, true
+}
+
+// cutLastLine splits off the last line of s if it starts with prefix,
+// returning what comes before it and the rest of that line.
+func cutLastLine(s, prefix string) (before, rest string, found bool) {
+	i := strings.LastIndex(s, "\n")
+	rest, found = strings.CutPrefix(s[i+1:], prefix)
+	if !found {
+		return s, "", false
+	}
+	return s[:i+1], rest, true
+}
+
+// headingTitle returns the text of the first "# " heading in the content, or
+// an empty string if there is none.
+func headingTitle(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if title, ok := strings.CutPrefix(line, "# "); ok {
+			return strings.TrimSpace(title)
+		}
+	}
+	return ""
+}
+
+// scanLines splits data into lines without their line endings.
+func scanLines(data string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// normalizeLineEndings converts Windows (CRLF) and old Mac (CR) line endings to LF.
+func normalizeLineEndings(data string) string {
+	if !strings.Contains(data, "\r") {
+		return data
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(data, "\r\n", "\n"), "\r", "\n")
+}
+
+// isDelimiter reports whether the line is a front matter delimiter on its own.
+func isDelimiter(line string) bool {
+	return strings.TrimSpace(line) == frontMatterDelimiter
+}
+
+// nextDelimiter returns the index of the first delimiter line at or after from,
+// or len(lines) if there is none.
+func nextDelimiter(lines []string, from int) int {
+	for i := from; i < len(lines); i++ {
+		if isDelimiter(lines[i]) {
+			return i
+		}
 	}
+	return len(lines)
+}
 
-	return notes, nil
+// nextNoteStart returns the index of the first delimiter at or after from that
+// opens a new front matter block, or len(lines) if the rest is content.
+// Delimiters that don't open front matter, such as horizontal rules, are content.
+func nextNoteStart(lines []string, from int) int {
+	for i := nextDelimiter(lines, from); i < len(lines); i = nextDelimiter(lines, i+1) {
+		end := nextDelimiter(lines, i+1)
+		if end < len(lines) && looksLikeFrontMatter(lines[i+1:end]) {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+// looksLikeFrontMatter reports whether the lines form a YAML mapping: the first
+// non-blank line is a key and every other line is a key, list item, comment,
+// or indented continuation. At least one key must be a known front matter key,
+// so text such as "Note: read this" between two horizontal rules stays content.
+func looksLikeFrontMatter(lines []string) bool {
+	sawKey, sawKnownKey := false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case frontMatterKeyLine.MatchString(line):
+			sawKey = true
+			key, _, _ := strings.Cut(line, ":")
+			sawKnownKey = sawKnownKey || knownFrontMatterKeys[strings.TrimSpace(key)]
+		case !sawKey:
+			return false
+		case strings.HasPrefix(trimmed, "-"), strings.HasPrefix(trimmed, "#"),
+			strings.HasPrefix(line, " "), strings.HasPrefix(line, "\t"):
+			continue
+		default:
+			return false
+		}
+	}
+	return sawKnownKey
 }
 
 // validateNote checks if the note has all required fields and valid data.
-func validateNote(note Note) error {
+func validateNote(note Note, opts Options) error {
 	if note.Title == "" {
 		return errors.New("missing title")
 	}
-	if note.Date == "" {
+	if note.Date.IsZero() && !note.Draft && opts.undatedPolicy() != UndatedInbox {
 		return errors.New("missing date")
 	}
-	if _, err := time.Parse("2006-01-02", note.Date); err != nil {
-		log.Printf("Invalid date: %s\n", note.Date)
+	if !note.Date.IsZero() {
+		noteDate, err := note.Date.day(opts.dateLayouts())
+		if err != nil {
+			opts.logger().Errorf("Invalid date: %s\n", note.Date)
+			return err
+		}
+		// A typo in the year would otherwise create a far-off directory
+		if minYear, maxYear := opts.yearRange(); noteDate.Year() < minYear || noteDate.Year() > maxYear {
+			opts.logger().Errorf("Date out of range: %s\n", note.Date)
+			return fmt.Errorf("date %s is out of range: the year must be between %d and %d", note.Date, minYear, maxYear)
+		}
+	}
+	if note.Time != "" {
+		if _, err := parseTime(note.Time); err != nil {
+			opts.logger().Errorf("Invalid time: %s\n", note.Time)
+			return err
+		}
+	}
+	if err := validateFolder(note.Folder); err != nil {
+		opts.logger().Errorf("Invalid folder: %s\n", note.Folder)
 		return err
 	}
+	if err := validateTags(note.Tags, opts); err != nil {
+		return err
+	}
+	if opts.Strict && len(note.unknownKeys) > 0 {
+		return fmt.Errorf("unknown front matter keys: %s", strings.Join(note.unknownKeys, ", "))
+	}
+	if opts.RequireContent && strings.TrimSpace(note.Content) == "" {
+		return errors.New("missing content")
+	}
 	return nil
 }
 
-// buildMarkdownPath creates the file path for a note based on its date.
-func buildMarkdownPath(note Note, baseDir string) (string, error) {
-	noteDate, err := time.Parse("2006-01-02", note.Date)
+// validateFolder checks that a folder override stays inside the notes directory.
+func validateFolder(folder string) error {
+	if folder == "" {
+		return nil
+	}
+	if filepath.IsAbs(folder) {
+		return errors.New("folder must be relative to the notes directory")
+	}
+	return ensureWithinDir(".", folder)
+}
+
+// ensureWithinDir returns errPathEscapes unless path resolves to a location inside dir.
+func ensureWithinDir(dir, path string) error {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return errPathEscapes
+	}
+	return nil
+}
+
+// normalizeDateTime rewrites a note's date in the ISO layout and its time as
+// 24-hour time. A date given as an RFC 3339 timestamp is kept in Timestamp and
+// filed under its local date, honoring its offset, with the full timestamp
+// moved to the time field unless one is set. A date with a time of day but no
+// offset, such as "2023-10-01 09:30", is filed and ordered by its date alone
+// and written back as it was given, see Date, unless it matches one of
+// Options.DateLayouts. Values that don't parse are left as-is for validateNote
+// to report.
+func normalizeDateTime(note *Note, opts Options) {
+	if text := strings.TrimSpace(note.Date.text); text != "" {
+		if ts, err := time.Parse(time.RFC3339, text); err == nil {
+			note.Timestamp = ts
+			note.Date = Date{Time: calendarDay(ts)}
+			if note.Time == "" {
+				note.Time = ts.Format(time.RFC3339)
+			}
+		} else if day, err := parseDate(note.Date.text, opts.dateLayouts()); err == nil {
+			note.Date = Date{Time: day}
+		}
+	}
+
+	note.Time = normalizeTime(note.Time)
+}
+
+// parseDate parses value with each layout in order and returns the first successful result.
+func parseDate(value string, layouts []string) (time.Time, error) {
+	var firstErr error
+	for _, layout := range layouts {
+		date, err := time.Parse(layout, value)
+		if err == nil {
+			return date, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, firstErr
+}
+
+// buildMarkdownPath creates the file path for a note by rendering the path
+// template against its date and title. A folder override replaces the template's
+// directories, keeping only the rendered file name, and a dir override replaces
+// the notes directory as the base the path is built on; see baseDir. When the template names
+// files by title and the file already holds a different note, -2, -3, and so
+// on are appended to the slug until a free name is found.
+func buildMarkdownPath(fs FileSystem, note Note, opts Options) (string, error) {
+	return assignPath(fs, note, opts, nil)
+}
+
+// assignPath is buildMarkdownPath for a batch of notes. claimed maps the paths
+// already given out in the batch to their note titles, so a note isn't given a
+// file name another note in the batch is about to write; it may be nil.
+func assignPath(fs FileSystem, note Note, opts Options, claimed map[string]string) (string, error) {
+	if note.Draft {
+		return buildDraftPath(fs, note, opts, claimed)
+	}
+	// Only notes filed under UndatedInbox get this far without a date
+	if note.Date.IsZero() {
+		path := filepath.Join(opts.NotesDir, InboxFileName)
+		logging.With(opts.logger(), "title", note.Title, "path", path).Infof("Note %q has no date, adding it to %s\n", note.Title, path)
+		return path, nil
+	}
+
+	noteDate, err := note.Date.day(opts.dateLayouts())
 	if err != nil {
-		log.Printf("Invalid date: %s\n", note.Date)
+		opts.logger().Errorf("Invalid date: %s\n", note.Date)
 		return "", err
 	}
 
-	datePath := filepath.Join(baseDir, noteDate.Format("2006/01"))
-	fileName := fmt.Sprintf("%02d.md", noteDate.Day())
+	data := newPathData(noteDate, note.Title)
+	slug := data.TitleSlug
+	for n := 2; ; n++ {
+		filePath, err := renderNotePath(note, data, opts)
+		if err != nil || !usesTitleSlug(opts.pathTemplate()) {
+			return filePath, err
+		}
 
-	return filepath.Join(datePath, fileName), nil
+		taken, err := pathTaken(fs, filePath, note.Title, claimed, opts.logger())
+		if err != nil || !taken {
+			return filePath, err
+		}
+
+		opts.logger().Infof("%s already holds a different note, renaming %s\n", filePath, note.Title)
+		data.TitleSlug = fmt.Sprintf("%s-%d", slug, n)
+	}
+}
+
+// buildDraftPath places a draft in DraftsDir, named after its slug or title.
+// A different draft already using the name gets -2, -3, and so on added.
+func buildDraftPath(fs FileSystem, note Note, opts Options, claimed map[string]string) (string, error) {
+	name := note.Slug
+	if name == "" {
+		name = note.Title
+	}
+	slug := slugify(name)
+
+	base := baseDir(note, opts)
+	filePath := filepath.Join(base, DraftsDir, slug+".md")
+	for n := 2; ; n++ {
+		taken, err := pathTaken(fs, filePath, note.Title, claimed, opts.logger())
+		if err != nil || !taken {
+			return filePath, err
+		}
+
+		opts.logger().Infof("%s already holds a different draft, renaming %s\n", filePath, note.Title)
+		filePath = filepath.Join(base, DraftsDir, fmt.Sprintf("%s-%d.md", slug, n))
+	}
+}
+
+// renderNotePath renders the path template and checks the result stays inside the note's base directory.
+func renderNotePath(note Note, data PathData, opts Options) (string, error) {
+	relPath, err := renderPath(opts.pathTemplate(), data)
+	if err != nil {
+		opts.logger().Errorf("Failed to render path template %q: %v\n", opts.pathTemplate(), err)
+		return "", err
+	}
+	relPath = filepath.FromSlash(relPath)
+	if note.Slug != "" {
+		relPath = withSlug(relPath, note.Slug)
+	}
+	if note.Folder != "" {
+		relPath = filepath.Join(note.Folder, filepath.Base(relPath))
+	}
+	base := baseDir(note, opts)
+	filePath := filepath.Join(base, relPath)
+
+	if err := ensureWithinDir(base, filePath); err != nil {
+		opts.logger().Errorf("Refusing to write note outside %s: %s\n", base, filePath)
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// baseDir returns the directory a note's path is built on: its dir override,
+// resolved against NotesDir when relative, or NotesDir itself.
+func baseDir(note Note, opts Options) string {
+	switch {
+	case note.Dir == "":
+		return opts.NotesDir
+	case filepath.IsAbs(note.Dir):
+		return filepath.Clean(note.Dir)
+	default:
+		return filepath.Join(opts.NotesDir, note.Dir)
+	}
+}
+
+// pathTaken reports whether path belongs to a note with a different title,
+// either one earlier in the batch or one already saved. A free path is
+// claimed for title.
+func pathTaken(fs FileSystem, path, title string, claimed map[string]string, log logging.Logger) (bool, error) {
+	if owner, ok := claimed[path]; ok {
+		return owner != title, nil
+	}
+
+	taken, err := pathTakenByOtherNote(fs, path, title, log)
+	if err == nil && !taken && claimed != nil {
+		claimed[path] = title
+	}
+	return taken, err
+}
+
+// pathTakenByOtherNote reports whether the file at path holds a note with a
+// different title. Files that can't be parsed count as taken.
+func pathTakenByOtherNote(fs FileSystem, path, title string, log logging.Logger) (bool, error) {
+	exists, err := fs.Exists(path)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		log.Errorf("Failed to read file %s: %v\n", path, err)
+		return false, err
+	}
+
+	existing, err := SplitNotesFromFile(string(data))
+	if err != nil {
+		return true, nil
+	}
+	for _, other := range existing {
+		if other.Title != title {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 // formatNoteContent formats the note's content with YAML front matter.
-func formatNoteContent(note Note) (string, error) {
+func formatNoteContent(note Note, opts Options) (string, error) {
+	if opts.NoFrontMatter {
+		return formatPlainNote(note), nil
+	}
+
+	// A date with a day keeps any text it was written with
+	date := note.Date
+	if !date.IsZero() && date.Time.IsZero() {
+		day, err := date.day(opts.dateLayouts())
+		if err != nil {
+			opts.logger().Errorf("Invalid date: %s\n", note.Date)
+			return "", err
+		}
+		date = Date{Time: day}
+	}
+
+	words := countWords(note.Content)
 	frontMatter := FrontMatter{
-		Title: note.Title,
-		Date:  note.Date,
-		Tags:  note.Tags,
+		Title:   note.Title,
+		Date:    date,
+		Time:    note.Time,
+		Summary: note.Summary,
+		Tags:    note.Tags,
+		Aliases: note.Aliases,
+		Folder:  note.Folder,
+		Dir:     note.Dir,
+		Slug:    note.Slug,
+		Draft:   note.Draft,
+		Updated: note.Updated,
+
+		Words:          words,
+		ReadingMinutes: readingMinutes(words, opts.WordsPerMinute),
+	}
+
+	var node yaml.Node
+	if err := node.Encode(frontMatter); err != nil {
+		opts.logger().Errorf("Failed to encode YAML front matter")
+		return "", err
+	}
+
+	if opts.InlineSingleTag && len(note.Tags) == 1 {
+		if tags := mappingValue(&node, "tags"); tags != nil {
+			tags.Style = yaml.FlowStyle
+		}
 	}
+	quoteUnsafeTags(mappingValue(&node, "tags"))
 
-	yamlFrontMatterBytes, err := yaml.Marshal(frontMatter)
+	yamlFrontMatterBytes, err := yaml.Marshal(&node)
 	if err != nil {
-		log.Println("Failed to marshal YAML front matter")
+		opts.logger().Errorf("Failed to marshal YAML front matter")
 		return "", err
 	}
 
-	yamlFrontMatter := string(yamlFrontMatterBytes)
+	return fmt.Sprintf("---\n%s---\n%s\n\n", yamlFrontMatterBytes, note.Content), nil
+}
+
+// formatPlainNote formats a note as plain Markdown for tools that don't read
+// front matter: the title as a heading, then the date and time on one line,
+// the content, and the tags on a trailing line. Empty parts are left out.
+func formatPlainNote(note Note) string {
+	return formatHeadingNote(note, "#", strings.TrimSpace(note.Date.String()+" "+note.Time))
+}
 
-	// Post-process to remove quotes around the date field
-	yamlFrontMatter = removeQuotesFromDateField(yamlFrontMatter, note.Date)
+// sectionMarker is written on the line before each note saveSection adds, so
+// its "## " heading is told apart from one in a note's own content. As an HTML
+// comment it doesn't show when the Markdown is rendered.
+const sectionMarker = "<!-- section -->"
 
-	return fmt.Sprintf("---\n%s---\n%s\n\n", yamlFrontMatter, note.Content), nil
+// formatSectionNote formats a note as a section of the file it is added to,
+// like formatPlainNote but with a "## " heading and only the time, since the
+// file's front matter already gives the date.
+func formatSectionNote(note Note) string {
+	return formatHeadingNote(note, "##", note.Time)
 }
 
-// removeQuotesFromDateField removes quotes around the date field in the YAML front matter.
-func removeQuotesFromDateField(yamlContent string, dateValue string) string {
-	re := regexp.MustCompile(`(?m)^date:.*$`)
-	unquotedDate := fmt.Sprintf("date: %s", dateValue)
-	return re.ReplaceAllString(yamlContent, unquotedDate)
+// formatHeadingNote writes the title under heading, when on the next line, the
+// content, and the tags, leaving out empty parts.
+func formatHeadingNote(note Note, heading, when string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", heading, note.Title)
+	if when != "" {
+		fmt.Fprintf(&b, "%s\n", when)
+	}
+	fmt.Fprintf(&b, "\n%s\n", note.Content)
+	if len(note.Tags) > 0 {
+		fmt.Fprintf(&b, "\nTags: %s\n", strings.Join(note.Tags, ", "))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil if the key is absent.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
 }
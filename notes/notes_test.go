@@ -1,25 +1,47 @@
 package notes
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/logging"
 )
 
+// MockFileSystem is an in-memory FileSystem. It is safe for concurrent use;
+// tests read its fields directly once processing has finished.
 type MockFileSystem struct {
-	Files map[string]string
-	Dirs  map[string]bool
+	Files  map[string]string
+	Dirs   map[string]bool
+	Perms  map[string]os.FileMode // Permissions each file and directory was created with
+	Writes int                    // Number of mutating calls received
+
+	mu sync.Mutex
 }
 
 func NewMockFileSystem() *MockFileSystem {
 	return &MockFileSystem{
 		Files: make(map[string]string),
 		Dirs:  make(map[string]bool),
+		Perms: make(map[string]os.FileMode),
 	}
 }
 
 func (fs *MockFileSystem) ReadFile(path string) ([]byte, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
 	if data, exists := fs.Files[path]; exists {
 		return []byte(data), nil
 	}
@@ -27,29 +49,134 @@ func (fs *MockFileSystem) ReadFile(path string) ([]byte, error) {
 }
 
 func (fs *MockFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.Writes++
 	fs.Files[path] = string(data)
+	fs.Perms[path] = perm
 	return nil
 }
 
-func (fs *MockFileSystem) AppendToFile(path string, data string) error {
+func (fs *MockFileSystem) AppendToFile(path string, data string, perm os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.Writes++
+	if _, exists := fs.Files[path]; !exists {
+		fs.Perms[path] = perm
+	}
 	fs.Files[path] += data
 	return nil
 }
 
+func (fs *MockFileSystem) RemoveFile(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, exists := fs.Files[path]; !exists {
+		return os.ErrNotExist
+	}
+	fs.Writes++
+	delete(fs.Files, path)
+	return nil
+}
+
+func (fs *MockFileSystem) CopyFile(src, dst string, perm os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	data, exists := fs.Files[src]
+	if !exists {
+		return os.ErrNotExist
+	}
+	fs.Writes++
+	if _, exists := fs.Files[dst]; !exists {
+		fs.Perms[dst] = perm
+	}
+	fs.Files[dst] = data
+	return nil
+}
+
 func (fs *MockFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.Writes++
 	fs.Dirs[path] = true
+	fs.Perms[path] = perm
+	return nil
+}
+
+func (fs *MockFileSystem) Exists(path string) (bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	path = filepath.Clean(path)
+	if _, exists := fs.Files[path]; exists || fs.Dirs[path] {
+		return true, nil
+	}
+
+	// Directories holding files exist even if they weren't created explicitly
+	prefix := path + string(filepath.Separator)
+	for file := range fs.Files {
+		if strings.HasPrefix(file, prefix) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (fs *MockFileSystem) TruncateIfUnchanged(path string, expected []byte) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	current, exists := fs.Files[path]
+	if !exists {
+		return os.ErrNotExist
+	}
+
+	remaining, err := unprocessedRemainder([]byte(current), expected)
+	if err != nil {
+		return err
+	}
+	fs.Writes++
+	fs.Files[path] = string(remaining)
 	return nil
 }
 
+func (fs *MockFileSystem) ListFiles(root string) ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var paths []string
+	prefix := filepath.Clean(root) + string(filepath.Separator)
+	for path := range fs.Files {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 && !fs.Dirs[filepath.Clean(root)] {
+		return nil, os.ErrNotExist
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// fixedClock pins the time used for updated timestamps.
+func fixedClock() time.Time {
+	return time.Date(2023, 10, 1, 9, 30, 0, 0, time.UTC)
+}
+
 func TestFormatNoteContent_PostProcessing(t *testing.T) {
 	note := Note{
 		Title:   "Test Note",
-		Date:    "2023-10-01",
+		Date:    readDate("2023-10-01"),
 		Tags:    []string{"testing", "golang"},
 		Content: "This is a test note content.",
 	}
 
-	fullNote, err := formatNoteContent(note)
+	fullNote, err := formatNoteContent(note, Options{})
 	if err != nil {
 		t.Fatalf("formatNoteContent failed: %v", err)
 	}
@@ -60,6 +187,8 @@ date: 2023-10-01
 tags:
     - testing
     - golang
+words: 6
+reading_minutes: 1
 ---
 This is a test note content.
 
@@ -82,7 +211,7 @@ This is a test note content.
 `
 
 	fs := NewMockFileSystem()
-	err := ProcessNotes(data, "/notes", fs)
+	_, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock})
 	if err != nil {
 		t.Fatalf("ProcessNotes failed: %v", err)
 	}
@@ -98,6 +227,9 @@ date: 2023-10-01
 tags:
     - testing
     - golang
+updated: 2023-10-01T09:30:00Z
+words: 6
+reading_minutes: 1
 ---
 This is a test note content.
 
@@ -119,7 +251,7 @@ Content without a title.
 `
 
 	fs := NewMockFileSystem()
-	err := ProcessNotes(data, "/notes", fs)
+	_, err := ProcessNotes(data, "/notes", fs)
 	if err == nil {
 		t.Fatal("Expected error due to missing title, but got none")
 	}
@@ -129,22 +261,197 @@ Content without a title.
 	}
 }
 
+func TestProcessNotes_ReportsAllInvalidNotes(t *testing.T) {
+	data := `---
+title: Missing Date
+---
+No date here.
+---
+title: Valid Note
+date: 2023-10-01
+---
+Fine on its own.
+---
+title: Bad Date
+date: not-a-date
+---
+Unparseable date.
+`
+
+	fs := NewMockFileSystem()
+	_, err := ProcessNotes(data, "/notes", fs)
+	if err == nil {
+		t.Fatal("Expected validation errors, got none")
+	}
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a ValidationError, got: %v", err)
+	}
+	for _, expected := range []string{`note 1 (title "Missing Date"): missing date`, `note 3 (title "Bad Date")`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got:\n%v", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Valid Note") {
+		t.Errorf("Expected only invalid notes in the error, got:\n%v", err)
+	}
+
+	// All-or-nothing: the valid note isn't written either
+	if fs.Writes != 0 {
+		t.Errorf("Expected nothing to be written, got %d writes", fs.Writes)
+	}
+}
+
+func TestProcessNotes_OnWrite(t *testing.T) {
+	data := "---\ntitle: Written\ndate: 2023-10-02\n---\nContent.\n---\ntitle: Also Written\ndate: 2023-10-01\n---\nContent.\n"
+
+	var dates []string
+	opts := Options{NotesDir: "/notes", OnWrite: func(path string, note Note) { dates = append(dates, note.Date.String()) }}
+	if _, err := ProcessNotesWithOptions(data, NewMockFileSystem(), opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if !slices.Equal(dates, []string{"2023-10-01", "2023-10-02"}) {
+		t.Errorf("Expected OnWrite for each note in date order, got %v", dates)
+	}
+}
+
+func TestProcessNotes_PackageClock(t *testing.T) {
+	original := now
+	now = fixedClock
+	t.Cleanup(func() { now = original })
+
+	data := "---\ntitle: Clocked\ndate: 2023-10-01\n---\nContent.\n"
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+		t.Fatalf("ProcessNotes failed: %v", err)
+	}
+
+	if content := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]; !strings.Contains(content, "updated: 2023-10-01T09:30:00Z\n") {
+		t.Errorf("Expected the package clock's time, got:\n%s", content)
+	}
+}
+
+func TestProcessNotes_DefaultToday(t *testing.T) {
+	data := `---
+title: Quick Capture
+---
+No date typed.
+---
+title: Another Quick One
+---
+Also undated.
+---
+title: Dated
+date: 2023-09-15
+---
+Keeps its date.
+`
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", DefaultToday: true, Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	today := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]
+	if strings.Count(today, "date: 2023-10-01") != 2 {
+		t.Errorf("Expected both undated notes to land on 2023-10-01, got:\n%s", today)
+	}
+	if _, exists := fs.Files[filepath.Join("/notes", "2023/09", "15.md")]; !exists {
+		t.Error("Expected the dated note to keep its own date")
+	}
+
+	// Without the option an undated note is still rejected
+	_, err := ProcessNotesWithOptions(data, NewMockFileSystem(), Options{NotesDir: "/notes", Now: fixedClock})
+	if err == nil || !strings.Contains(err.Error(), "missing date") {
+		t.Errorf("Expected missing date error, got: %v", err)
+	}
+}
+
+func TestProcessNotes_UndatedPolicy(t *testing.T) {
+	data := "---\ntitle: Quick Thought\n---\nNo date typed.\n---\ntitle: Dated\ndate: 2023-09-15\n---\nKeeps its date.\n"
+	inbox := filepath.Join("/notes", InboxFileName)
+	today := filepath.Join("/notes", "2023/10", "01.md")
+	dated := filepath.Join("/notes", "2023/09", "15.md")
+
+	tests := []struct {
+		policy       UndatedPolicy
+		defaultToday bool
+		err          string
+		undatedIn    string
+	}{
+		{policy: "", err: "missing date"},
+		{policy: UndatedError, err: "missing date"},
+		{policy: UndatedError, defaultToday: true, err: "missing date"},
+		{policy: UndatedToday, undatedIn: today},
+		{policy: "", defaultToday: true, undatedIn: today},
+		{policy: UndatedInbox, undatedIn: inbox},
+		{policy: UndatedInbox, defaultToday: true, undatedIn: inbox},
+	}
+
+	for _, tt := range tests {
+		fs := NewMockFileSystem()
+		_, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Undated: tt.policy, DefaultToday: tt.defaultToday, Now: fixedClock})
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) || len(fs.Files) != 0 {
+				t.Errorf("policy %q, default today %v: expected %q and nothing written, got %v and %v", tt.policy, tt.defaultToday, tt.err, err, fs.Files)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("policy %q, default today %v: ProcessNotesWithOptions failed: %v", tt.policy, tt.defaultToday, err)
+			continue
+		}
+		if !strings.Contains(fs.Files[tt.undatedIn], "No date typed.") {
+			t.Errorf("policy %q, default today %v: expected the undated note in %s, got %v", tt.policy, tt.defaultToday, tt.undatedIn, fs.Files)
+		}
+		if !strings.Contains(fs.Files[dated], "Keeps its date.") {
+			t.Errorf("policy %q, default today %v: expected the dated note in %s, got %v", tt.policy, tt.defaultToday, dated, fs.Files)
+		}
+	}
+
+	// Inbox notes stay undated and are appended to the same file
+	fs := NewMockFileSystem()
+	opts := Options{NotesDir: "/notes", Undated: UndatedInbox, Now: fixedClock}
+	for _, thought := range []string{"First thought.", "Second thought."} {
+		if _, err := ProcessNotesWithOptions("---\ntitle: "+thought+"\n---\n"+thought+"\n", fs, opts); err != nil {
+			t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+		}
+	}
+	saved, err := SplitNotesFromFile(fs.Files[inbox])
+	if err != nil || len(saved) != 2 || saved[0].Date.String() != "" || saved[1].Title != "Second thought." {
+		t.Errorf("Expected both thoughts undated in the inbox, got %+v, %v", saved, err)
+	}
+}
+
+func TestParseUndatedPolicy(t *testing.T) {
+	for name, expected := range map[string]UndatedPolicy{"": "", "error": UndatedError, "Today": UndatedToday, "INBOX": UndatedInbox} {
+		if policy, err := ParseUndatedPolicy(name); err != nil || policy != expected {
+			t.Errorf("ParseUndatedPolicy(%q) = %q, %v; expected %q", name, policy, err, expected)
+		}
+	}
+	if _, err := ParseUndatedPolicy("tomorrow"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
 func TestValidateNote(t *testing.T) {
 	validNote := Note{
 		Title: "Valid Note",
-		Date:  "2023-10-01",
+		Date:  readDate("2023-10-01"),
 	}
 
-	if err := validateNote(validNote); err != nil {
+	if err := validateNote(validNote, Options{}); err != nil {
 		t.Errorf("Expected valid note, got error: %v", err)
 	}
 
 	invalidNote := Note{
 		Title: "",
-		Date:  "2023-10-01",
+		Date:  readDate("2023-10-01"),
 	}
 
-	if err := validateNote(invalidNote); err == nil {
+	if err := validateNote(invalidNote, Options{}); err == nil {
 		t.Error("Expected error due to missing title, got none")
 	}
 }
@@ -166,8 +473,9 @@ tags:
 Content of the second note.
 `
 
-	notes, err := parseNotes(data)
-	if err != nil {
+	result := parseNotes(data, Options{})
+	notes := result.Notes
+	if err := result.Err(); err != nil {
 		t.Fatalf("parseNotes failed: %v", err)
 	}
 
@@ -183,3 +491,2260 @@ Content of the second note.
 		t.Errorf("Expected second note title 'Second Note', got '%s'", notes[1].Title)
 	}
 }
+
+func TestProcessNotes_DateLayouts(t *testing.T) {
+	data := `---
+title: Slash Date
+date: 2024/09/12
+---
+Written with slashes.
+---
+title: Written Date
+date: 12 Sep 2024
+---
+Written out.
+`
+
+	fs := NewMockFileSystem()
+	opts := Options{
+		NotesDir:    "/notes",
+		DateLayouts: []string{"2006-01-02", "2006/01/02", "02 Jan 2006"},
+	}
+	if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	expectedPath := filepath.Join("/notes", "2024/09", "12.md")
+	content, exists := fs.Files[expectedPath]
+	if !exists {
+		t.Fatalf("Expected file %s to be created", expectedPath)
+	}
+
+	if strings.Count(content, "date: 2024-09-12\n") != 2 {
+		t.Errorf("Expected both dates to be normalized to ISO, got:\n%s", content)
+	}
+}
+
+func TestValidateNote_DefaultDateLayout(t *testing.T) {
+	note := Note{
+		Title: "Slash Date",
+		Date:  readDate("2024/09/12"),
+	}
+
+	if err := validateNote(note, Options{}); err == nil {
+		t.Error("Expected error for non-ISO date without configured layouts, got none")
+	}
+
+	if err := validateNote(note, Options{DateLayouts: []string{"2006/01/02"}}); err != nil {
+		t.Errorf("Expected configured layout to be accepted, got error: %v", err)
+	}
+}
+
+func TestValidateNote_YearRange(t *testing.T) {
+	opts := Options{Now: fixedClock}
+	tests := map[string]bool{
+		"2023-10-01": true,
+		"1900-01-01": true,
+		"2024-12-31": true,
+		"1899-12-31": false,
+		"2025-01-01": false,
+		"9999-10-01": false,
+	}
+
+	for date, valid := range tests {
+		err := validateNote(Note{Title: "Dated", Date: readDate(date)}, opts)
+		if valid && err != nil {
+			t.Errorf("Expected %s to be accepted, got %v", date, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "between 1900 and 2024")) {
+			t.Errorf("Expected %s to be rejected with the allowed years, got %v", date, err)
+		}
+	}
+
+	// The window can be configured
+	opts = Options{Now: fixedClock, MinYear: 2000, MaxYear: 2030}
+	if err := validateNote(Note{Title: "Far", Date: readDate("2030-06-01")}, opts); err != nil {
+		t.Errorf("Expected a configured max year to be accepted, got %v", err)
+	}
+	if err := validateNote(Note{Title: "Old", Date: readDate("1999-06-01")}, opts); err == nil {
+		t.Error("Expected a date before the configured min year to be rejected")
+	}
+}
+
+func TestProcessNotes_DryRun(t *testing.T) {
+	data := `---
+title: Existing Day
+date: 2023-10-01
+---
+Appended content.
+---
+title: New Day
+date: 2023-10-02
+---
+Fresh content.
+`
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	fs := NewMockFileSystem()
+	existingPath := filepath.Join("/notes", "2023/10", "01.md")
+	fs.Files[existingPath] = "existing note\n"
+
+	_, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", DryRun: true})
+	if err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if fs.Files[existingPath] != "existing note\n" {
+		t.Errorf("Expected existing file to be untouched, got:\n%s", fs.Files[existingPath])
+	}
+	newPath := filepath.Join("/notes", "2023/10", "02.md")
+	if _, exists := fs.Files[newPath]; exists {
+		t.Errorf("Expected file %s not to be created in dry-run mode", newPath)
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, "Would append to existing file "+existingPath) {
+		t.Errorf("Expected append preview for %s, got:\n%s", existingPath, output)
+	}
+	if !strings.Contains(output, "Would create new file "+newPath) {
+		t.Errorf("Expected create preview for %s, got:\n%s", newPath, output)
+	}
+	if !strings.Contains(output, "Fresh content.") {
+		t.Errorf("Expected formatted note content in preview, got:\n%s", output)
+	}
+}
+
+func TestProcessNotes_DryRunSkipsWrites(t *testing.T) {
+	data := `---
+title: First Note
+date: 2023-10-01
+---
+First content.
+---
+title: Second Note
+date: 2023-11-15
+---
+Second content.
+`
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", DryRun: true}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if fs.Writes != 0 {
+		t.Errorf("Expected no writes in dry-run mode, got %d", fs.Writes)
+	}
+	if len(fs.Files) != 0 || len(fs.Dirs) != 0 {
+		t.Errorf("Expected no files or directories, got files %v and dirs %v", fs.Files, fs.Dirs)
+	}
+}
+
+func TestProcessNotes_DryRunStillValidates(t *testing.T) {
+	data := `---
+title: Valid Note
+date: 2023-10-01
+---
+Valid content.
+---
+title: Bad Date
+date: 2023-13-45
+---
+Invalid content.
+`
+
+	fs := NewMockFileSystem()
+	_, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", DryRun: true})
+	if err == nil {
+		t.Fatal("Expected validation error in dry-run mode, but got none")
+	}
+
+	if fs.Writes != 0 {
+		t.Errorf("Expected no writes in dry-run mode, got %d", fs.Writes)
+	}
+}
+
+func TestProcessNotes_DateLayoutsRouteToSamePath(t *testing.T) {
+	opts := Options{
+		NotesDir:    "/notes",
+		DateLayouts: []string{"01/02/2006", "2006-01-02 15:04", "Jan 2, 2006"},
+	}
+
+	dates := []string{"10/01/2023", "2023-10-01 14:30", "Oct 1, 2023", "2023-10-01"}
+	for _, date := range dates {
+		t.Run(date, func(t *testing.T) {
+			data := "---\ntitle: Layout Note\ndate: " + date + "\n---\nSame day.\n"
+
+			fs := NewMockFileSystem()
+			if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+				t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+			}
+
+			expectedPath := filepath.Join("/notes", "2023/10", "01.md")
+			content, exists := fs.Files[expectedPath]
+			if !exists {
+				t.Fatalf("Expected file %s to be created, got %v", expectedPath, fs.Files)
+			}
+			if !strings.Contains(content, "date: 2023-10-01\n") {
+				t.Errorf("Expected date normalized to ISO, got:\n%s", content)
+			}
+		})
+	}
+}
+
+func TestParseNotes_NormalizesDates(t *testing.T) {
+	data := `---
+title: Slash Date
+date: 2023/10/01
+---
+Content.
+---
+title: Bad Date
+date: not a date
+---
+Content.
+`
+
+	result := parseNotes(data, Options{DateLayouts: []string{"2006/01/02"}})
+	notes := result.Notes
+	if err := result.Err(); err != nil {
+		t.Fatalf("parseNotes failed: %v", err)
+	}
+
+	if notes[0].Date.String() != "2023-10-01" {
+		t.Errorf("Expected normalized date 2023-10-01, got '%s'", notes[0].Date)
+	}
+	if notes[1].Date.String() != "not a date" {
+		t.Errorf("Expected unparseable date to be left as-is, got '%s'", notes[1].Date)
+	}
+}
+
+func TestParseNotes_DelimiterInContent(t *testing.T) {
+	data := `---
+title: Horizontal Rule
+date: 2023-10-01
+---
+Before the rule.
+
+---
+
+After the rule, with an inline --- dash run.
+---
+title: Second Note
+date: 2023-10-02
+---
+Second content.
+`
+
+	result := parseNotes(data, Options{})
+	notes := result.Notes
+	if err := result.Err(); err != nil {
+		t.Fatalf("parseNotes failed: %v", err)
+	}
+
+	if len(notes) != 2 {
+		t.Fatalf("Expected 2 notes, got %d", len(notes))
+	}
+
+	expectedContent := "Before the rule.\n\n---\n\nAfter the rule, with an inline --- dash run."
+	if notes[0].Content != expectedContent {
+		t.Errorf("First note content mismatch.\nExpected:\n%s\nGot:\n%s", expectedContent, notes[0].Content)
+	}
+
+	if notes[1].Title != "Second Note" || notes[1].Content != "Second content." {
+		t.Errorf("Unexpected second note: %+v", notes[1])
+	}
+}
+
+func TestProcessNotes_HorizontalRulesSurvive(t *testing.T) {
+	content := "Before the rules.\n\n---\nNote: this line sits between two rules\n---\n\nAfter the rules.\n\n---\n\nThe end."
+	data := "---\ntitle: Ruled\ndate: 2023-10-01\n---\n" + content + "\n---\ntitle: Next\ndate: 2023-10-01\n---\nSecond note.\n"
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	written := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]
+	if !strings.Contains(written, "---\n"+content+"\n\n") {
+		t.Errorf("Expected the content with its rules intact, got:\n%s", written)
+	}
+
+	saved, err := SplitNotesFromFile(written)
+	if err != nil {
+		t.Fatalf("SplitNotesFromFile failed: %v", err)
+	}
+	if len(saved) != 2 || saved[0].Content != content || saved[1].Title != "Next" {
+		t.Errorf("Expected the saved file to read back as two notes with the rules intact, got %+v", saved)
+	}
+}
+
+func TestParseNotes_InlineDelimiter(t *testing.T) {
+	data := `---
+title: Inline Dashes
+date: 2023-10-01
+tags:
+  - test
+---
+Dates like 2023---10 and em---dashes stay in the sentence.
+`
+
+	result := parseNotes(data, Options{})
+	notes := result.Notes
+	if err := result.Err(); err != nil {
+		t.Fatalf("parseNotes failed: %v", err)
+	}
+
+	if len(notes) != 1 {
+		t.Fatalf("Expected 1 note, got %d", len(notes))
+	}
+
+	expectedContent := "Dates like 2023---10 and em---dashes stay in the sentence."
+	if notes[0].Content != expectedContent {
+		t.Errorf("Expected content %q, got %q", expectedContent, notes[0].Content)
+	}
+}
+
+func TestProcessNotes_SkipsDuplicates(t *testing.T) {
+	data := `---
+title: Repeated Note
+date: 2023-10-01
+tags:
+  - testing
+---
+Same content every time.
+`
+
+	fs := NewMockFileSystem()
+	for i := 0; i < 2; i++ {
+		if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+			t.Fatalf("ProcessNotes run %d failed: %v", i+1, err)
+		}
+	}
+
+	expectedPath := filepath.Join("/notes", "2023/10", "01.md")
+	if count := strings.Count(fs.Files[expectedPath], "title: Repeated Note"); count != 1 {
+		t.Errorf("Expected note to be written once, found %d copies:\n%s", count, fs.Files[expectedPath])
+	}
+}
+
+func TestProcessNotes_SortsByDate(t *testing.T) {
+	data := `---
+title: Third
+date: 2023-10-03
+---
+Third note.
+---
+title: Second
+date: 2023-10-02
+---
+Second note.
+---
+title: First
+date: 2023-10-01
+---
+First note.
+---
+title: First Again
+date: 2023-10-01
+---
+Same day, later in the buffer.
+`
+
+	// One worker saves files in date order; more may finish them in any order
+	fs := &recordingFileSystem{MockFileSystem: NewMockFileSystem()}
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Concurrency: 1}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join("/notes", "2023/10", "01.md"),
+		filepath.Join("/notes", "2023/10", "01.md"),
+		filepath.Join("/notes", "2023/10", "02.md"),
+		filepath.Join("/notes", "2023/10", "03.md"),
+	}
+	if !slices.Equal(fs.appended, expected) {
+		t.Errorf("Expected writes in date order %v, got %v", expected, fs.appended)
+	}
+
+	firstDay := fs.Files[expected[0]]
+	if strings.Index(firstDay, "title: First\n") > strings.Index(firstDay, "title: First Again") {
+		t.Errorf("Expected same-day notes to keep buffer order, got:\n%s", firstDay)
+	}
+}
+
+// recordingFileSystem records the order files are appended to.
+type recordingFileSystem struct {
+	*MockFileSystem
+	appended []string
+}
+
+func (fs *recordingFileSystem) AppendToFile(path, data string, perm os.FileMode) error {
+	fs.appended = append(fs.appended, path)
+	return fs.MockFileSystem.AppendToFile(path, data, perm)
+}
+
+func TestProcessNotes_SkipsMkdirAllForExistingDirs(t *testing.T) {
+	data := `---
+title: Morning
+date: 2023-10-01
+---
+First.
+---
+title: Evening
+date: 2023-10-01
+---
+Second.
+`
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+		t.Fatalf("ProcessNotes failed: %v", err)
+	}
+
+	// One MkdirAll for the new directory, one append per note, then the index
+	if fs.Writes != 4 {
+		t.Errorf("Expected 4 writes, got %d", fs.Writes)
+	}
+	if !fs.Dirs[filepath.Join("/notes", "2023/10")] {
+		t.Error("Expected the note directory to be created")
+	}
+}
+
+func TestOSFileSystem_Exists(t *testing.T) {
+	fs := OSFileSystem{}
+	dir := t.TempDir()
+
+	if exists, err := fs.Exists(dir); err != nil || !exists {
+		t.Errorf("Expected %s to exist, got %v, %v", dir, exists, err)
+	}
+	if exists, err := fs.Exists(filepath.Join(dir, "missing.md")); err != nil || exists {
+		t.Errorf("Expected missing file not to exist, got %v, %v", exists, err)
+	}
+}
+
+func TestProcessNotes_ForceWritesDuplicates(t *testing.T) {
+	data := `---
+title: Repeated Note
+date: 2023-10-01
+---
+Same content every time.
+`
+
+	fs := NewMockFileSystem()
+	for i := 0; i < 2; i++ {
+		if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Force: true}); err != nil {
+			t.Fatalf("ProcessNotesWithOptions run %d failed: %v", i+1, err)
+		}
+	}
+
+	expectedPath := filepath.Join("/notes", "2023/10", "01.md")
+	if count := strings.Count(fs.Files[expectedPath], "title: Repeated Note"); count != 2 {
+		t.Errorf("Expected --force to write the note twice, found %d copies:\n%s", count, fs.Files[expectedPath])
+	}
+}
+
+func TestProcessNotes_DuplicateIgnoresSurroundingWhitespace(t *testing.T) {
+	fs := NewMockFileSystem()
+	first := "---\ntitle: Spaced Note\ndate: 2023-10-01\n---\nSame content.\n"
+	second := "---\ntitle: Spaced Note\ndate: 2023-10-01\n---\n\nSame content.\n\n\n"
+
+	for _, data := range []string{first, second} {
+		if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+			t.Fatalf("ProcessNotes failed: %v", err)
+		}
+	}
+
+	expectedPath := filepath.Join("/notes", "2023/10", "01.md")
+	if count := strings.Count(fs.Files[expectedPath], "title: Spaced Note"); count != 1 {
+		t.Errorf("Expected whitespace-only differences to count as duplicates, found %d copies:\n%s", count, fs.Files[expectedPath])
+	}
+}
+
+func TestProcessNotes_DifferentTagsAreDistinct(t *testing.T) {
+	first := `---
+title: Tagged Note
+date: 2023-10-01
+tags:
+  - work
+---
+Same content.
+`
+	second := strings.Replace(first, "work", "personal", 1)
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotes(first, "/notes", fs); err != nil {
+		t.Fatalf("ProcessNotes failed: %v", err)
+	}
+	if _, err := ProcessNotes(second, "/notes", fs); err != nil {
+		t.Fatalf("ProcessNotes failed: %v", err)
+	}
+
+	expectedPath := filepath.Join("/notes", "2023/10", "01.md")
+	if count := strings.Count(fs.Files[expectedPath], "title: Tagged Note"); count != 2 {
+		t.Errorf("Expected notes differing in tags to both be written, found %d:\n%s", count, fs.Files[expectedPath])
+	}
+}
+
+func TestProcessNotes_FolderOverride(t *testing.T) {
+	data := `---
+title: Acme Kickoff
+date: 2023-10-01
+folder: projects/acme
+---
+Kickoff notes.
+`
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+		t.Fatalf("ProcessNotes failed: %v", err)
+	}
+
+	expectedPath := filepath.Join("/notes", "projects", "acme", "01.md")
+	content, exists := fs.Files[expectedPath]
+	if !exists {
+		t.Fatalf("Expected file %s to be created, got %v", expectedPath, fs.Files)
+	}
+	if !fs.Dirs[filepath.Join("/notes", "projects", "acme")] {
+		t.Errorf("Expected nested folder directories to be created, got %v", fs.Dirs)
+	}
+	if !strings.Contains(content, "folder: projects/acme\n") {
+		t.Errorf("Expected folder to be kept in front matter, got:\n%s", content)
+	}
+}
+
+func TestValidateNote_FolderEscapes(t *testing.T) {
+	for _, folder := range []string{"../outside", "projects/../../outside", "/etc"} {
+		note := Note{Title: "Escape", Date: readDate("2023-10-01"), Folder: folder}
+		if err := validateNote(note, Options{}); err == nil {
+			t.Errorf("Expected error for folder %q, got none", folder)
+		}
+	}
+
+	note := Note{Title: "Nested", Date: readDate("2023-10-01"), Folder: "projects/../archive"}
+	if err := validateNote(note, Options{}); err != nil {
+		t.Errorf("Expected folder resolving inside notes directory to be valid, got: %v", err)
+	}
+}
+
+type mockTagSuggester struct {
+	tags  []string
+	err   error
+	calls int
+}
+
+func (s *mockTagSuggester) SuggestTags(content string) ([]string, error) {
+	s.calls++
+	return s.tags, s.err
+}
+
+func TestProcessNotes_SuggestsTagsForUntaggedNotes(t *testing.T) {
+	data := `---
+title: Untagged Note
+date: 2023-10-01
+---
+Needs tags.
+---
+title: Tagged Note
+date: 2023-10-01
+tags:
+  - mine
+---
+Already tagged.
+`
+
+	suggester := &mockTagSuggester{tags: []string{"ideas", "ai"}}
+	fs := NewMockFileSystem()
+	_, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", TagSuggester: suggester})
+	if err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if suggester.calls != 1 {
+		t.Errorf("Expected suggester to be called once for the untagged note, got %d", suggester.calls)
+	}
+
+	content := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]
+	if !strings.Contains(content, "title: Untagged Note\ndate: 2023-10-01\ntags:\n    - ideas\n    - ai\n") {
+		t.Errorf("Expected suggested tags in front matter, got:\n%s", content)
+	}
+	if !strings.Contains(content, "tags:\n    - mine\n") {
+		t.Errorf("Expected existing tags to be kept, got:\n%s", content)
+	}
+}
+
+func TestProcessNotes_TagSuggestionFailureContinues(t *testing.T) {
+	data := `---
+title: Untagged Note
+date: 2023-10-01
+---
+Needs tags.
+`
+
+	suggester := &mockTagSuggester{err: errors.New("api unavailable")}
+	fs := NewMockFileSystem()
+	_, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", TagSuggester: suggester})
+	if err != nil {
+		t.Fatalf("Expected processing to continue after suggestion failure, got: %v", err)
+	}
+
+	content := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]
+	if !strings.Contains(content, "tags: []\n") {
+		t.Errorf("Expected note to be written untagged, got:\n%s", content)
+	}
+}
+
+func TestBuildMarkdownPath_PathTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		folder   string
+		expected string
+	}{
+		{template: "", expected: "/notes/2023/10/01.md"},
+		{template: "2006-01-02.md", expected: "/notes/2023-10-01.md"},
+		{template: "2006/01-January/02.md", expected: "/notes/2023/10-October/01.md"},
+		{template: "2006-01-02.md", folder: "projects/acme", expected: "/notes/projects/acme/2023-10-01.md"},
+		{template: "{{.Year}}/{{.Month}}/{{.Day}}-{{.TitleSlug}}.md", expected: "/notes/2023/10/01-templated.md"},
+	}
+
+	for _, tt := range tests {
+		note := Note{Title: "Templated", Date: readDate("2023-10-01"), Folder: tt.folder}
+		path, err := buildMarkdownPath(NewMockFileSystem(), note, Options{NotesDir: "/notes", PathTemplate: tt.template})
+		if err != nil {
+			t.Fatalf("buildMarkdownPath failed for template %q: %v", tt.template, err)
+		}
+		if path != tt.expected {
+			t.Errorf("Template %q: expected %s, got %s", tt.template, tt.expected, path)
+		}
+	}
+}
+
+func TestBuildMarkdownPath_Slug(t *testing.T) {
+	tests := []struct {
+		template string
+		folder   string
+		slug     string
+		expected string
+	}{
+		{expected: "/notes/2023/10/01.md"},
+		{slug: "standup-notes", expected: "/notes/2023/10/01-standup-notes.md"},
+		{slug: "Standup Notes!", expected: "/notes/2023/10/01-standup-notes.md"},
+		{slug: "../../etc/passwd", expected: "/notes/2023/10/01-etcpasswd.md"},
+		{template: "2006-01-02.md", folder: "projects/acme", slug: "kickoff", expected: "/notes/projects/acme/2023-10-01-kickoff.md"},
+	}
+
+	for _, tt := range tests {
+		note := Note{Title: "Slugged", Date: readDate("2023-10-01"), Folder: tt.folder, Slug: tt.slug}
+		path, err := buildMarkdownPath(NewMockFileSystem(), note, Options{NotesDir: "/notes", PathTemplate: tt.template})
+		if err != nil {
+			t.Fatalf("buildMarkdownPath failed for slug %q: %v", tt.slug, err)
+		}
+		if path != tt.expected {
+			t.Errorf("Slug %q: expected %s, got %s", tt.slug, tt.expected, path)
+		}
+	}
+}
+
+func TestBuildMarkdownPath_DirOverride(t *testing.T) {
+	tests := []struct {
+		dir      string
+		folder   string
+		expected string
+	}{
+		{expected: "/notes/2023/10/01.md"},
+		{dir: "archive", expected: "/notes/archive/2023/10/01.md"},
+		{dir: "../archive", expected: "/archive/2023/10/01.md"},
+		{dir: "/srv/archive", expected: "/srv/archive/2023/10/01.md"},
+		{dir: "/srv/archive", folder: "projects", expected: "/srv/archive/projects/01.md"},
+	}
+
+	for _, tt := range tests {
+		note := Note{Title: "Routed", Date: readDate("2023-10-01"), Dir: tt.dir, Folder: tt.folder}
+		path, err := buildMarkdownPath(NewMockFileSystem(), note, Options{NotesDir: "/notes"})
+		if err != nil {
+			t.Fatalf("buildMarkdownPath failed for dir %q: %v", tt.dir, err)
+		}
+		if path != filepath.FromSlash(tt.expected) {
+			t.Errorf("Dir %q: expected %s, got %s", tt.dir, tt.expected, path)
+		}
+	}
+}
+
+func TestProcessNotes_DirOverride(t *testing.T) {
+	data := "---\ntitle: Archived\ndate: 2023-10-01\ndir: /archive\n---\nKept elsewhere.\n"
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	content, ok := fs.Files[filepath.Join("/archive", "2023/10", "01.md")]
+	if !ok {
+		t.Fatalf("Expected the note under the dir override, got files %v", slices.Sorted(maps.Keys(fs.Files)))
+	}
+	if !strings.Contains(content, "dir: /archive\n") {
+		t.Errorf("Expected the dir override to be kept in the front matter, got:\n%s", content)
+	}
+	if _, exists := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]; exists {
+		t.Error("Expected nothing under the notes directory")
+	}
+}
+
+func TestProcessNotes_Slug(t *testing.T) {
+	data := "---\ntitle: Standup\ndate: 2023-10-01\nslug: Standup Notes\n---\nOwn file.\n---\ntitle: Daily\ndate: 2023-10-01\n---\nDaily file.\n"
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	slugged := fs.Files[filepath.Join("/notes", "2023/10", "01-standup-notes.md")]
+	if !strings.Contains(slugged, "slug: Standup Notes\n") || !strings.Contains(slugged, "Own file.") {
+		t.Errorf("Expected the slugged note in its own file, got:\n%s", slugged)
+	}
+	daily := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]
+	if !strings.Contains(daily, "Daily file.") || strings.Contains(daily, "Own file.") {
+		t.Errorf("Expected only the unslugged note in the daily file, got:\n%s", daily)
+	}
+}
+
+func TestProcessNotes_Draft(t *testing.T) {
+	data := `---
+title: Half-Finished Idea
+draft: true
+---
+Not ready yet.
+---
+title: Other Idea
+date: 2023-10-02
+draft: true
+slug: half finished idea
+---
+Different draft, same slug.
+---
+title: Filed
+date: 2023-10-01
+---
+Ready.
+`
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", DefaultToday: true, Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	draft := fs.Files[filepath.Join("/notes", DraftsDir, "half-finished-idea.md")]
+	if !strings.Contains(draft, "date:\n") || !strings.Contains(draft, "draft: true\n") || !strings.Contains(draft, "Not ready yet.") {
+		t.Errorf("Expected the undated draft in the drafts folder, got:\n%s", draft)
+	}
+	other := fs.Files[filepath.Join("/notes", DraftsDir, "half-finished-idea-2.md")]
+	if !strings.Contains(other, "Different draft, same slug.") {
+		t.Errorf("Expected the colliding draft in a suffixed file, got:\n%s", other)
+	}
+	if _, exists := fs.Files[filepath.Join("/notes", "2023/10", "02.md")]; exists {
+		t.Error("Expected the dated draft not to be filed by date")
+	}
+	if filed := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]; !strings.Contains(filed, "Ready.") {
+		t.Errorf("Expected the regular note to be filed by date, got:\n%s", filed)
+	}
+	if !strings.Contains(logs.String(), `Note "Half-Finished Idea" is a draft`) {
+		t.Errorf("Expected a log line for the draft, got:\n%s", logs.String())
+	}
+
+	// Only drafts may be undated
+	_, err := ProcessNotesWithOptions("---\ntitle: Undated\n---\nContent.\n", NewMockFileSystem(), Options{NotesDir: "/notes"})
+	if err == nil || !strings.Contains(err.Error(), "missing date") {
+		t.Errorf("Expected a missing date error for a regular note, got %v", err)
+	}
+}
+
+func TestProcessNotes_SlugCollisions(t *testing.T) {
+	data := `---
+title: Q4 Plan
+date: 2023-10-01
+---
+First plan.
+---
+title: Q4 plan!
+date: 2023-10-01
+---
+A different note with the same slug.
+---
+title: Q4 Plan
+date: 2023-10-01
+---
+More on the first plan.
+`
+
+	fs := NewMockFileSystem()
+	opts := Options{NotesDir: "/notes", PathTemplate: "{{.Year}}/{{.Month}}/{{.Day}}-{{.TitleSlug}}.md", Now: fixedClock}
+	for i := 0; i < 2; i++ {
+		if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+			t.Fatalf("ProcessNotesWithOptions run %d failed: %v", i+1, err)
+		}
+	}
+
+	first := fs.Files[filepath.Join("/notes", "2023/10", "01-q4-plan.md")]
+	second := fs.Files[filepath.Join("/notes", "2023/10", "01-q4-plan-2.md")]
+	if strings.Count(first, "title: Q4 Plan\n") != 2 || strings.Contains(first, "Q4 plan!") {
+		t.Errorf("Expected both Q4 Plan notes in 01-q4-plan.md, got:\n%s", first)
+	}
+	if strings.Count(second, "title: Q4 plan!") != 1 {
+		t.Errorf("Expected the colliding note once in 01-q4-plan-2.md, got:\n%s", second)
+	}
+	if _, exists := fs.Files[filepath.Join("/notes", "2023/10", "01-q4-plan-3.md")]; exists {
+		t.Error("Expected reprocessing not to create another suffixed file")
+	}
+}
+
+func TestBuildMarkdownPath_RejectsEscapes(t *testing.T) {
+	tests := []Note{
+		{Title: "Folder Escape", Date: readDate("2023-10-01"), Folder: "../../etc"},
+		{Title: "Nested Escape", Date: readDate("2023-10-01"), Folder: "projects/../../outside"},
+	}
+
+	for _, note := range tests {
+		_, err := buildMarkdownPath(NewMockFileSystem(), note, Options{NotesDir: "/notes"})
+		if !errors.Is(err, errPathEscapes) {
+			t.Errorf("Expected errPathEscapes for folder %q, got: %v", note.Folder, err)
+		}
+	}
+
+	// Dates containing traversal sequences never parse, so they can't build a path
+	note := Note{Title: "Date Escape", Date: readDate("../../2023-10-01")}
+	if _, err := buildMarkdownPath(NewMockFileSystem(), note, Options{NotesDir: "/notes"}); err == nil {
+		t.Error("Expected error for date containing ../, got none")
+	}
+}
+
+func TestProcessNotes_RejectsEscapingFolder(t *testing.T) {
+	data := `---
+title: Sneaky Note
+date: 2023-10-01
+folder: ../../tmp
+---
+Should never be written.
+`
+
+	fs := NewMockFileSystem()
+	_, err := ProcessNotes(data, "/notes", fs)
+	if !errors.Is(err, errPathEscapes) {
+		t.Fatalf("Expected errPathEscapes, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "note path escapes notes directory") {
+		t.Errorf("Expected descriptive error, got: %v", err)
+	}
+	if fs.Writes != 0 {
+		t.Errorf("Expected no writes, got %d", fs.Writes)
+	}
+}
+
+func TestOSFileSystem_AppendToFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "01.md")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	fs := OSFileSystem{}
+	if err := fs.AppendToFile(path, "second\n", DefaultFilePerm); err != nil {
+		t.Fatalf("AppendToFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "first\nsecond\n" {
+		t.Errorf("Expected appended contents, got %q", data)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("Expected permissions 0600 to be kept, got %o", perm)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+}
+
+func TestOSFileSystem_AppendToFileFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "01.md")
+	if err := os.WriteFile(path, []byte("first\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	original := renameFile
+	renameFile = func(oldpath, newpath string) error { return errors.New("killed mid-write") }
+	defer func() { renameFile = original }()
+
+	if err := (OSFileSystem{}).AppendToFile(path, "second\n", DefaultFilePerm); err == nil {
+		t.Fatal("Expected the failed write to return an error")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "first\n" {
+		t.Errorf("Expected the original file to be untouched, got %q", data)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected the temporary file to be removed, got %d entries", len(entries))
+	}
+}
+
+func TestOSFileSystem_WriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "new.md")
+
+	fs := OSFileSystem{}
+	if err := fs.WriteFile(path, []byte("contents"), 0o640); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o640 {
+		t.Errorf("Expected new file permissions 0640, got %o", perm)
+	}
+
+	// Writing into a missing directory fails without leaving anything behind
+	missing := filepath.Join(dir, "missing", "file.md")
+	if err := fs.WriteFile(missing, []byte("contents"), 0o644); err == nil {
+		t.Error("Expected error writing into a missing directory, got none")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the written file in %s, got %d entries", dir, len(entries))
+	}
+}
+
+func TestOSFileSystem_TruncateIfUnchanged(t *testing.T) {
+	processed := "---\ntitle: Processed\ndate: 2023-10-01\n---\nDone.\n"
+	added := "---\ntitle: Added Later\ndate: 2023-10-02\n---\nWritten during processing.\n"
+
+	tests := []struct {
+		name     string
+		current  string
+		expected string
+		err      error
+	}{
+		{name: "unchanged", current: processed, expected: ""},
+		{name: "appended after read", current: processed + added, expected: added},
+		{name: "edited after read", current: strings.Replace(processed, "Done.", "Edited.", 1), expected: strings.Replace(processed, "Done.", "Edited.", 1), err: ErrBufferChanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "buffer.md")
+			if err := os.WriteFile(path, []byte(tt.current), 0o644); err != nil {
+				t.Fatalf("Failed to write buffer: %v", err)
+			}
+
+			err := OSFileSystem{}.TruncateIfUnchanged(path, []byte(processed))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected error %v, got %v", tt.err, err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("Failed to read buffer: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("Buffer mismatch.\nExpected:\n%q\nGot:\n%q", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestOSFileSystem_TruncateIfUnchangedKeepsPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.md")
+	if err := os.WriteFile(path, []byte("Processed.\nAdded.\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write buffer: %v", err)
+	}
+
+	if err := (OSFileSystem{}).TruncateIfUnchanged(path, []byte("Processed.\n")); err != nil {
+		t.Fatalf("TruncateIfUnchanged failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat buffer: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the buffer to keep mode 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestUnchangedSince(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buffer.md")
+	if err := os.WriteFile(path, []byte("Before.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer: %v", err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat buffer: %v", err)
+	}
+	if err := unchangedSince(path, before); err != nil {
+		t.Errorf("Expected an untouched file to be unchanged, got %v", err)
+	}
+
+	// An editor deletes the buffer and saves a new one in its place
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove buffer: %v", err)
+	}
+	if err := unchangedSince(path, before); !errors.Is(err, ErrBufferChanged) {
+		t.Errorf("Expected ErrBufferChanged for a removed file, got %v", err)
+	}
+	if err := os.WriteFile(path, []byte("After.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to recreate buffer: %v", err)
+	}
+	if err := unchangedSince(path, before); !errors.Is(err, ErrBufferChanged) {
+		t.Errorf("Expected ErrBufferChanged for a recreated file, got %v", err)
+	}
+}
+
+func TestParseNotes_HeadingTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{
+			name:     "heading only",
+			data:     "---\ndate: 2023-10-01\n---\nIntro line.\n# Heading Title\nBody.\n## Subheading\n",
+			expected: "Heading Title",
+		},
+		{
+			name:     "yaml only",
+			data:     "---\ntitle: YAML Title\ndate: 2023-10-01\n---\nBody without a heading.\n",
+			expected: "YAML Title",
+		},
+		{
+			name:     "yaml and heading",
+			data:     "---\ntitle: YAML Title\ndate: 2023-10-01\n---\n# Heading Title\nBody.\n",
+			expected: "YAML Title",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseNotes(tt.data, Options{})
+			notes := result.Notes
+			if err := result.Err(); err != nil {
+				t.Fatalf("parseNotes failed: %v", err)
+			}
+			if len(notes) != 1 {
+				t.Fatalf("Expected 1 note, got %d", len(notes))
+			}
+			if notes[0].Title != tt.expected {
+				t.Errorf("Expected title %q, got %q", tt.expected, notes[0].Title)
+			}
+			if err := validateNote(notes[0], Options{}); err != nil {
+				t.Errorf("Expected note to pass validation, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestProcessNotes_RefreshesUpdatedForSameTitle(t *testing.T) {
+	fs := NewMockFileSystem()
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	fs.Files[path] = `---
+title: Standup
+date: 2023-10-01
+tags: []
+updated: 2023-10-01T08:00:00Z
+---
+Morning standup.
+
+---
+title: Lunch
+date: 2023-10-01
+tags: []
+---
+Sandwich.
+
+`
+
+	data := `---
+title: Standup
+date: 2023-10-01
+---
+Follow-up from standup.
+`
+
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	expected := `---
+title: Standup
+date: 2023-10-01
+tags: []
+updated: 2023-10-01T09:30:00Z
+---
+Morning standup.
+
+---
+title: Lunch
+date: 2023-10-01
+tags: []
+---
+Sandwich.
+
+---
+title: Standup
+date: 2023-10-01
+tags: []
+updated: 2023-10-01T09:30:00Z
+words: 3
+reading_minutes: 1
+---
+Follow-up from standup.
+
+`
+	if fs.Files[path] != expected {
+		t.Errorf("File content mismatch.\nExpected:\n%s\nGot:\n%s", expected, fs.Files[path])
+	}
+}
+
+func TestSetUpdatedLine_AddsMissingField(t *testing.T) {
+	lines := scanLines("---\ntitle: Old Note\ndate: 2023-10-01\n---\nContent.\n")
+	blocks := splitNoteBlocks(lines)
+
+	lines = setUpdatedLine(lines, blocks[0], fixedClock())
+
+	expected := "---\ntitle: Old Note\ndate: 2023-10-01\nupdated: 2023-10-01T09:30:00Z\n---\nContent."
+	if got := strings.Join(lines, "\n"); got != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
+
+func TestProcessNotes_MalformedYAMLKeepsValidNotes(t *testing.T) {
+	data := `---
+title: Good Note
+date: 2023-10-01
+---
+This one is fine.
+---
+title: [unclosed
+date: 2023-10-02
+---
+This one is broken.
+`
+
+	fs := NewMockFileSystem()
+	_, err := ProcessNotes(data, "/notes", fs)
+	if err == nil {
+		t.Fatal("Expected an error for the malformed note, got none")
+	}
+
+	var noteErr NoteError
+	if !errors.As(err, &noteErr) {
+		t.Fatalf("Expected a NoteError, got: %v", err)
+	}
+	if noteErr.Index != 2 {
+		t.Errorf("Expected the second note to be reported, got index %d", noteErr.Index)
+	}
+	if !strings.Contains(noteErr.Snippet, "title: [unclosed") {
+		t.Errorf("Expected snippet of the offending YAML, got %q", noteErr.Snippet)
+	}
+
+	goodPath := filepath.Join("/notes", "2023/10", "01.md")
+	if !strings.Contains(fs.Files[goodPath], "This one is fine.") {
+		t.Errorf("Expected the valid note to be written to %s, got files %v", goodPath, fs.Files)
+	}
+	if _, exists := fs.Files[filepath.Join("/notes", "2023/10", "02.md")]; exists {
+		t.Error("Expected the malformed note not to be written")
+	}
+}
+
+func TestParseNotes_CollectsErrors(t *testing.T) {
+	data := `---
+title: [first broken
+---
+Broken.
+---
+title: Fine
+date: 2023-10-01
+---
+Fine.
+---
+title: [second broken
+---
+Broken again.
+`
+
+	result := parseNotes(data, Options{})
+
+	if len(result.Notes) != 1 || result.Notes[0].Title != "Fine" {
+		t.Errorf("Expected only the valid note to be parsed, got %+v", result.Notes)
+	}
+	if len(result.Errors) != 2 {
+		t.Fatalf("Expected 2 errors, got %d", len(result.Errors))
+	}
+	if result.Errors[0].Index != 1 || result.Errors[1].Index != 3 {
+		t.Errorf("Expected errors for notes 1 and 3, got %d and %d", result.Errors[0].Index, result.Errors[1].Index)
+	}
+
+	err := result.Err()
+	if err == nil || !strings.Contains(err.Error(), "note 1") || !strings.Contains(err.Error(), "note 3") {
+		t.Errorf("Expected aggregated error naming both notes, got: %v", err)
+	}
+}
+
+func TestFormatNoteContent_Summary(t *testing.T) {
+	note := Note{Title: "Summarized", Date: readDate("2023-10-01"), Summary: "Decided on the Q4 roadmap", Content: "Content."}
+
+	fullNote, err := formatNoteContent(note, Options{})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+	if !strings.Contains(fullNote, "date: 2023-10-01\nsummary: Decided on the Q4 roadmap\n") {
+		t.Errorf("Expected summary after date, got:\n%s", fullNote)
+	}
+
+	note.Summary = ""
+	fullNote, err = formatNoteContent(note, Options{})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+	if strings.Contains(fullNote, "summary:") {
+		t.Errorf("Expected no summary line for a note without one, got:\n%s", fullNote)
+	}
+}
+
+func TestParseNotes_Summary(t *testing.T) {
+	data := `---
+title: With Summary
+date: 2023-10-01
+summary: One line about the note
+---
+Content.
+---
+title: With Description
+date: 2023-10-01
+description: Described instead
+---
+Content.
+---
+title: Without Summary
+date: 2023-10-01
+---
+Content.
+`
+
+	result := parseNotes(data, Options{})
+	if len(result.Notes) != 3 {
+		t.Fatalf("Expected 3 notes, got %d", len(result.Notes))
+	}
+
+	expected := []string{"One line about the note", "Described instead", ""}
+	for i, note := range result.Notes {
+		if note.Summary != expected[i] {
+			t.Errorf("Note %d: expected summary %q, got %q", i+1, expected[i], note.Summary)
+		}
+	}
+}
+
+func TestParseNotes_WarnsOnUnknownKeys(t *testing.T) {
+	data := `---
+title: Typo
+date: 2023-10-01
+catgeory: work
+tag: meeting
+---
+Content.
+---
+title: Clean
+date: 2023-10-01
+tags:
+  - meeting
+---
+Content.
+`
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	result := parseNotes(data, Options{})
+	if len(result.Notes) != 2 || result.Err() != nil {
+		t.Fatalf("Expected both notes to parse, got %d notes and error %v", len(result.Notes), result.Err())
+	}
+
+	if !slices.Equal(result.Notes[0].unknownKeys, []string{"catgeory", "tag"}) {
+		t.Errorf("Expected unknown keys [catgeory tag], got %v", result.Notes[0].unknownKeys)
+	}
+	if len(result.Notes[1].unknownKeys) != 0 {
+		t.Errorf("Expected no unknown keys for a clean note, got %v", result.Notes[1].unknownKeys)
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, `note 1 ("Typo") has unknown front matter keys: catgeory, tag`) {
+		t.Errorf("Expected a warning naming the unknown keys, got:\n%s", output)
+	}
+	if strings.Contains(output, "Clean") {
+		t.Errorf("Expected no warning for the clean note, got:\n%s", output)
+	}
+}
+
+func TestProcessNotes_Strict(t *testing.T) {
+	data := `---
+title: Clean
+date: 2023-10-01
+---
+Content.
+---
+title: Typo
+date: 2023-10-02
+catgeory: work
+---
+Content.
+`
+
+	fs := NewMockFileSystem()
+	_, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Strict: true})
+	if err == nil {
+		t.Fatal("Expected strict mode to reject the note with an unknown key")
+	}
+	if !strings.Contains(err.Error(), `note 2 (title "Typo"): unknown front matter keys: catgeory`) {
+		t.Errorf("Expected the error to name the note and key, got: %v", err)
+	}
+	if len(fs.Files) != 0 {
+		t.Errorf("Expected nothing to be written, got %v", fs.Files)
+	}
+
+	// Without strict mode the unknown key is only a warning
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes"}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if _, exists := fs.Files[filepath.Join("/notes", "2023/10", "02.md")]; !exists {
+		t.Error("Expected the note to be saved when strict mode is off")
+	}
+}
+
+func TestValidateNote_TagPattern(t *testing.T) {
+	opts := Options{TagPattern: regexp.MustCompile(`^[\p{L}\p{N}_/-]+$`)}
+
+	valid := Note{Title: "Tagged", Date: readDate("2023-10-01"), Tags: []string{"work", "project_x", "work/acme-corp", "café2"}}
+	if err := validateNote(valid, opts); err != nil {
+		t.Errorf("Expected tags %v to be valid, got %v", valid.Tags, err)
+	}
+
+	invalid := Note{Title: "Spaced", Date: readDate("2023-10-01"), Tags: []string{"work", "project x"}}
+	err := validateNote(invalid, opts)
+	if err == nil || !strings.Contains(err.Error(), `invalid tag "project x"`) {
+		t.Errorf("Expected an error naming the invalid tag, got %v", err)
+	}
+
+	// Without a pattern any tag is accepted
+	if err := validateNote(invalid, Options{}); err != nil {
+		t.Errorf("Expected no tag check without a pattern, got %v", err)
+	}
+
+	// The error reported for a buffer names the note's title
+	data := "---\ntitle: Spaced\ndate: 2023-10-01\ntags:\n  - project x\n---\nContent.\n"
+	_, err = ProcessNotesWithOptions(data, NewMockFileSystem(), Options{NotesDir: "/notes", TagPattern: opts.TagPattern})
+	if err == nil || !strings.Contains(err.Error(), `note 1 (title "Spaced"): invalid tag "project x"`) {
+		t.Errorf("Expected a validation error naming the note, got %v", err)
+	}
+}
+
+func TestValidateNote_RequireContent(t *testing.T) {
+	for _, content := range []string{"", " \n\t\n"} {
+		note := Note{Title: "Placeholder", Date: readDate("2023-10-01"), Content: content}
+		if err := validateNote(note, Options{}); err != nil {
+			t.Errorf("Expected content %q to be allowed by default, got %v", content, err)
+		}
+		if err := validateNote(note, Options{RequireContent: true}); err == nil || err.Error() != "missing content" {
+			t.Errorf("Expected content %q to be rejected, got %v", content, err)
+		}
+	}
+
+	// The error reported for a buffer names the note's title, and no notes are saved
+	data := "---\ntitle: Written\ndate: 2023-10-01\n---\nContent.\n---\ntitle: Empty\ndate: 2023-10-01\n---\n\n"
+	fs := NewMockFileSystem()
+	_, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", RequireContent: true})
+	if err == nil || !strings.Contains(err.Error(), `note 2 (title "Empty"): missing content`) {
+		t.Errorf("Expected a validation error naming the note, got %v", err)
+	}
+	if len(fs.Files) != 0 {
+		t.Errorf("Expected no notes to be saved, got %v", fs.Files)
+	}
+}
+
+func TestProcessNotes_UniqueTitlePerDay(t *testing.T) {
+	// The same title on different days, and different titles on the same day, are fine
+	data := `---
+title: Standup
+date: 2023-10-01
+---
+Monday.
+---
+title: Standup
+date: 2023-10-02
+---
+Tuesday.
+---
+title: Retro
+date: 2023-10-02
+---
+Went well.
+`
+	opts := Options{NotesDir: "/notes", Now: fixedClock, UniqueTitlePerDay: true}
+	summary, err := ProcessNotesWithOptions(data, NewMockFileSystem(), opts)
+	if err != nil || summary.Written != 3 {
+		t.Fatalf("Expected every note saved, got %+v, %v", summary, err)
+	}
+
+	colliding := data + "---\ntitle: Standup\ndate: 2023-10-01\n---\nMonday again.\n"
+	fs := NewMockFileSystem()
+	_, err = ProcessNotesWithOptions(colliding, fs, opts)
+	if err == nil || !strings.Contains(err.Error(), `note 4 (title "Standup"): same title and date 2023-10-01 as note 1`) {
+		t.Errorf("Expected an error naming the collision, got %v", err)
+	}
+	if len(fs.Files) != 0 {
+		t.Errorf("Expected nothing saved, got %v", fs.Files)
+	}
+
+	// Without the option both are saved to the same file
+	if _, err := ProcessNotesWithOptions(colliding, NewMockFileSystem(), Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Errorf("Expected colliding titles to be allowed by default, got %v", err)
+	}
+}
+
+func TestValidateNote_YAMLUnsafeTags(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected string
+	}{
+		{tag: "project: x", expected: `invalid tag "project: x": it contains ": "`},
+		{tag: "-draft", expected: `invalid tag "-draft": it starts with "-"`},
+		{tag: "#todo", expected: `invalid tag "#todo": it starts with "#"`},
+		{tag: "c #sharp", expected: `invalid tag "c #sharp": it contains " #"`},
+		{tag: "project:", expected: `invalid tag "project:": it ends with ":"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			note := Note{Title: "Tagged", Date: readDate("2023-10-01"), Tags: []string{"work", tt.tag}}
+
+			// Rejected even when any tag pattern is allowed
+			err := validateNote(note, Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+
+			if err := validateNote(note, Options{SanitizeTags: true}); err != nil {
+				t.Errorf("Expected the tag to be allowed when sanitizing, got %v", err)
+			}
+		})
+	}
+
+	// Special characters inside a tag read back the same unquoted
+	for _, tag := range []string{"c#", "don't", "me@home", "project:x", "a,b"} {
+		note := Note{Title: "Tagged", Date: readDate("2023-10-01"), Tags: []string{tag}}
+		if err := validateNote(note, Options{}); err != nil {
+			t.Errorf("Expected %q to be allowed, got %v", tag, err)
+		}
+		formatted, err := formatNoteContent(note, Options{})
+		if err != nil {
+			t.Fatalf("formatNoteContent failed: %v", err)
+		}
+		saved, err := SplitNotesFromFile(formatted)
+		if err != nil || len(saved) != 1 || !slices.Equal(saved[0].Tags, []string{tag}) {
+			t.Errorf("Expected %q to round-trip, got %+v, %v", tag, saved, err)
+		}
+	}
+}
+
+func TestProcessNotes_SanitizeTags(t *testing.T) {
+	data := "---\ntitle: Tagged\ndate: 2023-10-01\ntags:\n  - \"project: x\"\n  - \"-draft\"\n---\nContent.\n"
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes"}); err == nil {
+		t.Fatal("Expected tags that break YAML to be rejected")
+	}
+
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", SanitizeTags: true}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	saved := fs.Files[filepath.Join("/notes", "2023", "10", "01.md")]
+	if !strings.Contains(saved, `- "project: x"`) || !strings.Contains(saved, `- "-draft"`) {
+		t.Errorf("Expected the tags to be quoted, got:\n%s", saved)
+	}
+
+	// Another append reads the quoted tags back unchanged
+	notes, err := SplitNotesFromFile(saved)
+	if err != nil || len(notes) != 1 || !slices.Equal(notes[0].Tags, []string{"project: x", "-draft"}) {
+		t.Errorf("Expected the tags to round-trip, got %v, %v", notes, err)
+	}
+}
+
+func TestFormatNoteContent_WordsPerMinute(t *testing.T) {
+	note := Note{Title: "Long Read", Date: readDate("2023-10-01"), Content: strings.Repeat("word ", 250)}
+
+	fullNote, err := formatNoteContent(note, Options{WordsPerMinute: 100})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+
+	if !strings.Contains(fullNote, "words: 250\nreading_minutes: 3\n") {
+		t.Errorf("Expected 250 words read in 3 minutes, got:\n%s", fullNote)
+	}
+}
+
+func TestFormatNoteContent_InlineSingleTag(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		inline   bool
+		expected string
+	}{
+		{name: "single tag inline", tags: []string{"golang"}, inline: true, expected: "tags: [golang]\n"},
+		{name: "single tag opted out", tags: []string{"golang"}, inline: false, expected: "tags:\n    - golang\n"},
+		{name: "multiple tags stay block", tags: []string{"golang", "testing"}, inline: true, expected: "tags:\n    - golang\n    - testing\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			note := Note{Title: "Tag Style", Date: readDate("2023-10-01"), Tags: tt.tags, Content: "Content."}
+
+			fullNote, err := formatNoteContent(note, Options{InlineSingleTag: tt.inline})
+			if err != nil {
+				t.Fatalf("formatNoteContent failed: %v", err)
+			}
+
+			expected := "---\ntitle: Tag Style\ndate: 2023-10-01\n" + tt.expected + "words: 1\nreading_minutes: 1\n---\nContent.\n\n"
+			if fullNote != expected {
+				t.Errorf("Full note content mismatch.\nExpected:\n%s\nGot:\n%s", expected, fullNote)
+			}
+		})
+	}
+}
+
+func TestProcessNotesContext_Canceled(t *testing.T) {
+	buffer := `---
+title: First
+date: 2023-10-01
+---
+One.
+---
+title: Second
+date: 2023-10-01
+---
+Two.
+---
+title: Third
+date: 2023-10-02
+---
+Three.
+`
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesContext(ctx, buffer, "/notes", fs); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if fs.Writes != 0 {
+		t.Errorf("Expected nothing to be written with a canceled context, got %d writes", fs.Writes)
+	}
+
+	// Canceling after the first note keeps it and stops before the rest
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var saved []string
+	opts := Options{NotesDir: "/notes", Concurrency: 1, OnWrite: func(path string, note Note) {
+		saved = append(saved, note.Title)
+		cancel()
+	}}
+	if _, err := ProcessNotesWithOptionsContext(ctx, buffer, fs, opts); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(saved) != 1 || saved[0] != "First" {
+		t.Errorf("Expected only the first note to be saved, got %v", saved)
+	}
+	if _, exists := fs.Files[filepath.Join("/notes", "2023/10", "02.md")]; exists {
+		t.Error("Expected no file for notes after the cancellation")
+	}
+	if _, exists := fs.Files[filepath.Join("/notes", IndexFileName)]; exists {
+		t.Error("Expected the index not to be rebuilt after a canceled run")
+	}
+}
+
+func TestProcessNotes_DateWithTimeOfDay(t *testing.T) {
+	data := `---
+title: Standup
+date: 2023-10-01 09:30
+---
+With time.
+---
+title: Review
+date: 2023-10-01 14:05:30
+---
+With seconds.
+---
+title: Lunch
+date: 2023-10-01 12:00
+time: "12:15"
+---
+Time field wins.
+---
+title: Whole Day
+date: 2023-10-01
+---
+Date only.
+`
+	fs := NewMockFileSystem()
+	summary, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock})
+	if err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	path := filepath.Join("/notes", "2023", "10", "01.md")
+	if summary.Written != 4 || !slices.Equal(summary.Files, []string{path}) {
+		t.Fatalf("Expected every note in %s, got %+v", path, summary)
+	}
+
+	saved, err := SplitNotesFromFile(fs.Files[path])
+	if err != nil {
+		t.Fatalf("SplitNotesFromFile failed: %v", err)
+	}
+	times := map[string]string{}
+	for _, note := range saved {
+		if note.Date.String() != "2023-10-01" {
+			t.Errorf("Expected %q dated 2023-10-01, got %q", note.Title, note.Date)
+		}
+		times[note.Title] = note.Time
+	}
+	want := map[string]string{"Standup": "", "Review": "", "Lunch": "12:15", "Whole Day": ""}
+	if !maps.Equal(times, want) {
+		t.Errorf("Expected times %v, got %v in:\n%s", want, times, fs.Files[path])
+	}
+	// The dates are written back as given, without a time field added
+	for _, line := range []string{"date: 2023-10-01 09:30\n", "date: 2023-10-01 14:05:30\n", "date: 2023-10-01 12:00\ntime: \"12:15\"\n"} {
+		if !strings.Contains(fs.Files[path], line) {
+			t.Errorf("Expected %q in front matter, got:\n%s", line, fs.Files[path])
+		}
+	}
+	if count := strings.Count(fs.Files[path], "time:"); count != 1 {
+		t.Errorf("Expected only the given time field, got %d in:\n%s", count, fs.Files[path])
+	}
+}
+
+func TestProcessNotes_RFC3339Date(t *testing.T) {
+	data := `---
+title: Late Night
+date: 2024-09-12T23:30:00-07:00
+---
+Still the 12th in Denver.
+---
+title: Early Tokyo
+date: 2024-09-13T00:15:00+09:00
+---
+Already the 13th in Tokyo.
+---
+title: Breakfast
+date: 2024-09-12
+time: "07:00"
+---
+Before the late note.
+`
+	parsed := parseNotes(data, Options{})
+	if len(parsed.Errors) != 0 {
+		t.Fatalf("Expected timestamps to parse, got %v", parsed.Err())
+	}
+	late := parsed.Notes[0]
+	if late.Date.String() != "2024-09-12" || late.Time != "2024-09-12T23:30:00-07:00" {
+		t.Errorf("Expected the local date and full timestamp, got date %q, time %q", late.Date, late.Time)
+	}
+	if want := time.Date(2024, 9, 13, 6, 30, 0, 0, time.UTC); !late.Timestamp.Equal(want) {
+		t.Errorf("Expected timestamp %v, got %v", want, late.Timestamp)
+	}
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	// Both timestamps fall on the other side of midnight in UTC
+	twelfth := fs.Files[filepath.Join("/notes", "2024/09", "12.md")]
+	if !strings.Contains(twelfth, "Still the 12th") {
+		t.Errorf("Expected the Denver note on the 12th, got:\n%s", twelfth)
+	}
+	if !strings.Contains(twelfth, "time: \"2024-09-12T23:30:00-07:00\"\n") {
+		t.Errorf("Expected the full timestamp in the time field, got:\n%s", twelfth)
+	}
+	if strings.Index(twelfth, "Before the late note.") > strings.Index(twelfth, "Still the 12th") {
+		t.Errorf("Expected notes ordered by local time of day, got:\n%s", twelfth)
+	}
+	if thirteenth := fs.Files[filepath.Join("/notes", "2024/09", "13.md")]; !strings.Contains(thirteenth, "Already the 13th") {
+		t.Errorf("Expected the Tokyo note on the 13th, got:\n%s", thirteenth)
+	}
+
+	// The timestamp survives being read back
+	saved, err := SplitNotesFromFile(twelfth)
+	if err != nil {
+		t.Fatalf("SplitNotesFromFile failed: %v", err)
+	}
+	for _, note := range saved {
+		if note.Title == "Late Night" && note.Time != "2024-09-12T23:30:00-07:00" {
+			t.Errorf("Expected the saved time to keep its offset, got %q", note.Time)
+		}
+	}
+}
+
+func TestParseNotes_LineEndings(t *testing.T) {
+	lf := "---\ntitle: Windows Note\ndate: 2023-10-01\ntags:\n  - work\n---\nFirst line.\n\nSecond line.\n---\ntitle: Second\ndate: 2023-10-01\n---\nMore.\n"
+
+	for name, data := range map[string]string{
+		"CRLF": strings.ReplaceAll(lf, "\n", "\r\n"),
+		"CR":   strings.ReplaceAll(lf, "\n", "\r"),
+	} {
+		parsed := parseNotes(data, Options{})
+		if len(parsed.Errors) != 0 || len(parsed.Notes) != 2 {
+			t.Fatalf("%s: expected two notes, got %+v", name, parsed)
+		}
+		for _, note := range parsed.Notes {
+			if strings.Contains(note.Title, "\r") || strings.Contains(note.Content, "\r") || slices.ContainsFunc(note.Tags, func(tag string) bool { return strings.Contains(tag, "\r") }) {
+				t.Errorf("%s: expected no carriage returns, got %+v", name, note)
+			}
+		}
+		if parsed.Notes[0].Title != "Windows Note" || parsed.Notes[0].Content != "First line.\n\nSecond line." {
+			t.Errorf("%s: unexpected first note %+v", name, parsed.Notes[0])
+		}
+
+		// Saving gives the same files as the LF buffer
+		expected := NewMockFileSystem()
+		if _, err := ProcessNotesWithOptions(lf, expected, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+			t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+		}
+		got := NewMockFileSystem()
+		if _, err := ProcessNotesWithOptions(data, got, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+			t.Fatalf("%s: ProcessNotesWithOptions failed: %v", name, err)
+		}
+		if !maps.Equal(got.Files, expected.Files) {
+			t.Errorf("%s: expected the same files as the LF buffer.\nExpected: %q\nGot: %q", name, expected.Files, got.Files)
+		}
+	}
+}
+
+func TestProcessNotes_QuietLogging(t *testing.T) {
+	data := "---\ntitle: Undated\n---\nDated today.\n"
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	defer logging.SetLevel(logging.LevelInfo)
+
+	logging.SetLevel(logging.LevelError)
+	if _, err := ProcessNotesWithOptions(data, NewMockFileSystem(), Options{NotesDir: "/notes", DefaultToday: true, Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("Expected no per-note logging at the error level, got:\n%s", logs.String())
+	}
+
+	logging.SetLevel(logging.LevelInfo)
+	if _, err := ProcessNotesWithOptions(data, NewMockFileSystem(), Options{NotesDir: "/notes", DefaultToday: true, Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), `Dating note "Undated" today`) {
+		t.Errorf("Expected per-note logging by default, got:\n%s", logs.String())
+	}
+}
+
+func TestProcessNotes_Logger(t *testing.T) {
+	data := "---\ntitle: Undated\n---\nDated today.\n"
+
+	var global, logs bytes.Buffer
+	log.SetOutput(&global)
+	defer log.SetOutput(os.Stderr)
+
+	opts := Options{NotesDir: "/notes", DefaultToday: true, Now: fixedClock, Logger: logging.StdLogger{Log: log.New(&logs, "", 0)}}
+	if _, err := ProcessNotesWithOptions(data, NewMockFileSystem(), opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	for _, expected := range []string{`Dating note "Undated" today`, "Wrote note to file"} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("Expected %q on the configured logger, got:\n%s", expected, logs.String())
+		}
+	}
+	if global.Len() != 0 {
+		t.Errorf("Expected nothing on the default logger, got:\n%s", global.String())
+	}
+}
+
+func TestProcessNotes_JSONLoggerFields(t *testing.T) {
+	data := "---\ntitle: Standup\ndate: 2024-09-12\n---\nShip it.\n"
+
+	var logs bytes.Buffer
+	opts := Options{NotesDir: "/notes", Logger: logging.NewJSONLogger(&logs)}
+	if _, err := ProcessNotesWithOptions(data, NewMockFileSystem(), opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	expected := `"msg":"Wrote note to file /notes/2024/09/12.md","path":"/notes/2024/09/12.md"`
+	if !strings.Contains(logs.String(), expected) || !strings.Contains(logs.String(), `"title":"Standup"`) {
+		t.Errorf("Expected the write logged with title and path fields, got:\n%s", logs.String())
+	}
+}
+
+func TestProcessNotes_Permissions(t *testing.T) {
+	data := `---
+title: Private
+date: 2024-09-12
+---
+Kept to myself.
+`
+	fs := NewMockFileSystem()
+	opts := Options{NotesDir: "notes", FilePerm: 0o600, DirPerm: 0o700}
+	if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	notePath := filepath.Join("notes", "2024", "09", "12.md")
+	if perm := fs.Perms[notePath]; perm != 0o600 {
+		t.Errorf("Expected note created with 0600, got %o", perm)
+	}
+	if perm := fs.Perms[filepath.Join("notes", "2024", "09")]; perm != 0o700 {
+		t.Errorf("Expected directory created with 0700, got %o", perm)
+	}
+	if perm := fs.Perms[filepath.Join("notes", IndexFileName)]; perm != 0o600 {
+		t.Errorf("Expected index written with 0600, got %o", perm)
+	}
+
+	// Unset permissions keep the defaults
+	fs = NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "notes"}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if perm := fs.Perms[notePath]; perm != DefaultFilePerm {
+		t.Errorf("Expected note created with %o, got %o", DefaultFilePerm, perm)
+	}
+	if perm := fs.Perms[filepath.Join("notes", "2024", "09")]; perm != DefaultDirPerm {
+		t.Errorf("Expected directory created with %o, got %o", DefaultDirPerm, perm)
+	}
+}
+
+func TestProcessNotes_Backup(t *testing.T) {
+	data := `---
+title: Second
+date: 2024-09-12
+---
+Added later.
+---
+title: First
+date: 2024-09-13
+---
+A new day.
+`
+	fs := NewMockFileSystem()
+	existingPath := filepath.Join("notes", "2024", "09", "12.md")
+	newPath := filepath.Join("notes", "2024", "09", "13.md")
+	original := "---\ntitle: Earlier\ndate: 2024-09-12\n---\nAlready here.\n"
+	fs.Files[existingPath] = original
+
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "notes", Backup: true}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if backup, ok := fs.Files[existingPath+BackupSuffix]; !ok || backup != original {
+		t.Errorf("Expected a backup of the existing file's original contents, got %q", backup)
+	}
+	if !strings.Contains(fs.Files[existingPath], "Added later.") {
+		t.Errorf("Expected the note appended after the backup, got:\n%s", fs.Files[existingPath])
+	}
+	if _, ok := fs.Files[newPath+BackupSuffix]; ok {
+		t.Error("Expected no backup for a new file")
+	}
+
+	// Backups are off by default
+	fs = NewMockFileSystem()
+	fs.Files[existingPath] = original
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "notes"}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if _, ok := fs.Files[existingPath+BackupSuffix]; ok {
+		t.Error("Expected no backup without Backup set")
+	}
+}
+
+func TestOSFileSystem_CopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "12.md")
+	if err := os.WriteFile(src, []byte("original\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	dst := src + BackupSuffix
+	if err := (OSFileSystem{}).CopyFile(src, dst, 0o600); err != nil {
+		t.Fatalf("CopyFile failed: %v", err)
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil || string(data) != "original\n" {
+		t.Errorf("Expected the copy to match the original, got %q, %v", data, err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the copy created with 0600, got %v", info.Mode().Perm())
+	}
+
+	if err := (OSFileSystem{}).CopyFile(filepath.Join(dir, "missing.md"), dst, 0o600); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected ErrNotExist for a missing source, got %v", err)
+	}
+}
+
+func TestFormatNoteContent_AliasesRoundTrip(t *testing.T) {
+	data := `---
+title: Project Phoenix
+date: 2023-10-01
+aliases:
+  - phoenix
+  - PX
+---
+The rewrite.
+`
+	parsed := parseNotes(data, Options{})
+	if len(parsed.Notes) != 1 || len(parsed.Notes[0].unknownKeys) != 0 {
+		t.Fatalf("Expected one note with aliases as a known key, got %+v", parsed.Notes)
+	}
+	if !slices.Equal(parsed.Notes[0].Aliases, []string{"phoenix", "PX"}) {
+		t.Fatalf("Expected aliases to be parsed, got %v", parsed.Notes[0].Aliases)
+	}
+
+	formatted, err := formatNoteContent(parsed.Notes[0], Options{})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+	reparsed := parseNotes(formatted, Options{})
+	if len(reparsed.Notes) != 1 || !slices.Equal(reparsed.Notes[0].Aliases, []string{"phoenix", "PX"}) {
+		t.Errorf("Expected aliases to survive formatting, got:\n%s", formatted)
+	}
+
+	// Notes without aliases don't get an empty field
+	formatted, err = formatNoteContent(Note{Title: "Plain", Date: readDate("2023-10-01")}, Options{})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+	if strings.Contains(formatted, "aliases") {
+		t.Errorf("Expected no aliases field, got:\n%s", formatted)
+	}
+}
+
+func TestFormatNoteContent_DateLikeFields(t *testing.T) {
+	note := Note{
+		Title:   "date: 2023-09-30",
+		Date:    readDate("2023-10-01"),
+		Summary: "date: moved from 2023-09-30",
+		Content: "date: 2023-09-30 in the content is left alone.",
+	}
+
+	fullNote, err := formatNoteContent(note, Options{})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+	if !strings.Contains(fullNote, "\ndate: 2023-10-01\n") {
+		t.Errorf("Expected the date written unquoted, got:\n%s", fullNote)
+	}
+	if !strings.HasSuffix(fullNote, "---\ndate: 2023-09-30 in the content is left alone.\n\n") {
+		t.Errorf("Expected the content kept as written, got:\n%s", fullNote)
+	}
+
+	parsed := parseNotes(fullNote, Options{})
+	if len(parsed.Notes) != 1 {
+		t.Fatalf("Expected the note to parse back, got %v", parsed.Err())
+	}
+	got := parsed.Notes[0]
+	if got.Title != note.Title || got.Date.String() != note.Date.String() || got.Summary != note.Summary {
+		t.Errorf("Expected the date-like fields to round-trip, got title %q, date %q, summary %q", got.Title, got.Date, got.Summary)
+	}
+}
+
+func TestProcessNotes_QuotedDateLineInContent(t *testing.T) {
+	data := "---\ntitle: Migration\ndate: 2023-10-01\n---\nOld front matter:\ndate: \"2023-09-30\"\ndate: '2023-09-29'\n"
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	// Only the front matter date is written unquoted; quotes in the content stay
+	saved := fs.Files[filepath.Join("/notes", "2023", "10", "01.md")]
+	expected := "---\ntitle: Migration\ndate: 2023-10-01\ntags: []\nupdated: 2023-10-01T09:30:00Z\nwords: 7\nreading_minutes: 1\n---\nOld front matter:\ndate: \"2023-09-30\"\ndate: '2023-09-29'\n\n"
+	if saved != expected {
+		t.Errorf("Saved note mismatch.\nExpected:\n%q\nGot:\n%q", expected, saved)
+	}
+}
+
+func TestProcessNotes_Summary(t *testing.T) {
+	data := `---
+title: Standup
+date: 2024-09-12
+---
+Blocked on review.
+---
+title: Lunch
+date: 2024-09-12
+---
+Tacos.
+---
+title: Retro
+date: 2024-09-13
+---
+Went well.
+`
+	fs := NewMockFileSystem()
+	summary, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes"})
+	if err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	files := []string{filepath.Join("/notes", "2024", "09", "12.md"), filepath.Join("/notes", "2024", "09", "13.md")}
+	if summary.Written != 3 || summary.Skipped != 0 || !slices.Equal(summary.Files, files) || summary.Created != 2 || summary.Appended != 0 {
+		t.Errorf("Expected 3 notes written across 2 new files %v, got %+v", files, summary)
+	}
+}
+
+func TestProcessNotes_DuplicateDoesNotStopFile(t *testing.T) {
+	data := "---\ntitle: Standup\ndate: 2024-09-12\n---\nBlocked on review.\n"
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes"}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	// The duplicate is skipped and the note after it in the same file still saved
+	data += "---\ntitle: Retro\ndate: 2024-09-12\n---\nWent well.\n"
+	summary, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes"})
+	if err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	path := filepath.Join("/notes", "2024", "09", "12.md")
+	if summary.Written != 1 || summary.Skipped != 1 {
+		t.Errorf("Expected 1 note written and 1 skipped, got %+v", summary)
+	}
+	if !strings.Contains(fs.Files[path], "Went well.") {
+		t.Errorf("Expected the note after the duplicate saved, got:\n%s", fs.Files[path])
+	}
+}
+
+func TestProcessNotes_SingleFrontMatterPerFile(t *testing.T) {
+	data := `---
+title: Standup
+date: 2023-10-01
+tags: [work]
+---
+Blocked on review.
+---
+title: Lunch
+date: 2023-10-01
+time: "12:30"
+tags: [food]
+---
+Tacos.
+`
+	fs := NewMockFileSystem()
+	opts := Options{NotesDir: "/notes", Now: fixedClock, SingleFrontMatterPerFile: true}
+	summary, err := ProcessNotesWithOptions(data, fs, opts)
+	if err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if summary.Written != 2 {
+		t.Errorf("Expected both notes written, got %+v", summary)
+	}
+
+	// The first note in the file gets front matter, the next one a section
+	path := filepath.Join("/notes", "2023", "10", "01.md")
+	saved := fs.Files[path]
+	expected := "---\ntitle: Standup\ndate: 2023-10-01\ntags:\n    - work\nupdated: 2023-10-01T09:30:00Z\nwords: 3\nreading_minutes: 1\n---\nBlocked on review.\n\n" +
+		"<!-- section -->\n## Lunch\n12:30\n\nTacos.\n\nTags: food\n\n"
+	if saved != expected {
+		t.Errorf("Saved file mismatch.\nExpected:\n%q\nGot:\n%q", expected, saved)
+	}
+
+	// Running again skips the section as a duplicate
+	summary, err = ProcessNotesWithOptions(data, fs, opts)
+	if err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if summary.Written != 0 || summary.Skipped != 2 || fs.Files[path] != expected {
+		t.Errorf("Expected both notes skipped, got %+v and:\n%s", summary, fs.Files[path])
+	}
+
+	// A later run adds a section with the separator, and leaves other days alone
+	opts.NoteSeparator = "***"
+	more := "---\ntitle: Retro\ndate: 2023-10-01\n---\nWent well.\n---\ntitle: Tomorrow\ndate: 2023-10-02\n---\nNew file.\n"
+	if _, err := ProcessNotesWithOptions(more, fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if !strings.HasSuffix(fs.Files[path], "Tags: food\n\n<!-- section -->\n***\n\n## Retro\n\nWent well.\n\n") {
+		t.Errorf("Expected the section after the separator, got:\n%s", fs.Files[path])
+	}
+	if next := fs.Files[filepath.Join("/notes", "2023", "10", "02.md")]; !strings.HasPrefix(next, "---\ntitle: Tomorrow\n") {
+		t.Errorf("Expected the first note in a new file to get front matter, got:\n%s", next)
+	}
+}
+
+func TestListNotes_Sections(t *testing.T) {
+	data := `---
+title: Standup
+date: 2023-10-01
+tags: [work]
+---
+Blocked on review.
+
+## Not a section
+Part of the standup.
+---
+title: Lunch
+date: 2023-10-01
+time: "12:30"
+tags: [food, friends]
+---
+Tacos.
+---
+title: Retro
+date: 2023-10-01
+---
+Went well.
+`
+	fs := NewMockFileSystem()
+	opts := Options{NotesDir: "/notes", Now: fixedClock, SingleFrontMatterPerFile: true, NoteSeparator: "***"}
+	if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	stored, err := ListNotes(fs, "/notes")
+	if err != nil {
+		t.Fatalf("ListNotes failed: %v", err)
+	}
+	if len(stored) != 3 {
+		t.Fatalf("Expected the note and both sections, got %+v", stored)
+	}
+
+	expected := []Note{
+		{Title: "Standup", Date: readDate("2023-10-01"), Tags: []string{"work"}, Content: "Blocked on review.\n\n## Not a section\nPart of the standup."},
+		{Title: "Lunch", Date: readDate("2023-10-01"), Time: "12:30", Tags: []string{"food", "friends"}, Content: "Tacos."},
+		{Title: "Retro", Date: readDate("2023-10-01"), Content: "Went well."},
+	}
+	for i, want := range expected {
+		got := stored[i].Note
+		if got.Title != want.Title || got.Date.String() != want.Date.String() || got.Time != want.Time ||
+			!slices.Equal(got.Tags, want.Tags) || got.Content != want.Content {
+			t.Errorf("Note %d mismatch.\nExpected: %+v\nGot: %+v", i, want, got)
+		}
+	}
+}
+
+func TestParseNotes_SectionsOnlyInSavedFiles(t *testing.T) {
+	data := "---\ntitle: Template\ndate: 2023-10-01\n---\nPaste this:\n<!-- section -->\n## Heading\nStarts here.\n"
+
+	// A marker typed into a buffer is part of the note
+	parsed := parseNotes(data, Options{})
+	if len(parsed.Notes) != 1 || !strings.HasSuffix(parsed.Notes[0].Content, "<!-- section -->\n## Heading\nStarts here.") {
+		t.Fatalf("Expected a single note keeping the marker, got %+v", parsed.Notes)
+	}
+
+	// A saved file splits it off, and a first line that isn't a time is content
+	saved, err := SplitNotesFromFile(data)
+	if err != nil || len(saved) != 2 {
+		t.Fatalf("Expected the note and its section, got %+v, %v", saved, err)
+	}
+	if saved[0].Content != "Paste this:" || saved[1].Time != "" || saved[1].Content != "Starts here." {
+		t.Errorf("Unexpected notes: %+v", saved)
+	}
+}
+
+func TestFormatNoteContent_NoFrontMatter(t *testing.T) {
+	note := Note{
+		Title:   "Meeting with Project Team",
+		Date:    readDate("2024-09-12"),
+		Time:    "14:30",
+		Tags:    []string{"work", "meeting"},
+		Content: "Finalized the sprint scope.",
+	}
+
+	withFrontMatter, err := formatNoteContent(note, Options{})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+	expected := `---
+title: Meeting with Project Team
+date: 2024-09-12
+time: "14:30"
+tags:
+    - work
+    - meeting
+words: 4
+reading_minutes: 1
+---
+Finalized the sprint scope.
+
+`
+	if withFrontMatter != expected {
+		t.Errorf("Front matter rendering mismatch.\nExpected:\n%s\nGot:\n%s", expected, withFrontMatter)
+	}
+
+	plain, err := formatNoteContent(note, Options{NoFrontMatter: true})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+	expected = `# Meeting with Project Team
+2024-09-12 14:30
+
+Finalized the sprint scope.
+
+Tags: work, meeting
+
+`
+	if plain != expected {
+		t.Errorf("Plain rendering mismatch.\nExpected:\n%s\nGot:\n%s", expected, plain)
+	}
+
+	// Undated, untagged notes leave those lines out
+	plain, err = formatNoteContent(Note{Title: "Idea", Content: "Later."}, Options{NoFrontMatter: true})
+	if err != nil {
+		t.Fatalf("formatNoteContent failed: %v", err)
+	}
+	if plain != "# Idea\n\nLater.\n\n" {
+		t.Errorf("Expected only the heading and content, got %q", plain)
+	}
+}
+
+func TestProcessNotes_NoFrontMatter(t *testing.T) {
+	data := "---\ntitle: Standup\ndate: 2024-09-12\ntags: [work]\n---\nBlocked on review.\n"
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotesWithOptions(data, fs, Options{NotesDir: "/notes", NoFrontMatter: true}); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	saved := fs.Files[filepath.Join("/notes", "2024", "09", "12.md")]
+	if saved != "# Standup\n2024-09-12\n\nBlocked on review.\n\nTags: work\n\n" {
+		t.Errorf("Expected the note saved without front matter at the usual path, got %q", saved)
+	}
+}
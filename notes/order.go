@@ -0,0 +1,180 @@
+package notes
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// timeLayouts are the layouts accepted for a note's optional time field.
+var timeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM"}
+
+// parseTime parses a note's time of day. A full RFC 3339 timestamp gives its
+// wall clock time in its own offset.
+func parseTime(value string) (time.Time, error) {
+	if ts, err := time.Parse(time.RFC3339, strings.TrimSpace(value)); err == nil {
+		return time.Date(0, time.January, 1, ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC), nil
+	}
+
+	var firstErr error
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, strings.TrimSpace(value))
+		if err == nil {
+			return t, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, firstErr
+}
+
+// normalizeTime rewrites a time of day as 24-hour time. RFC 3339 timestamps
+// are kept whole, and values that don't parse are returned unchanged.
+func normalizeTime(value string) string {
+	if ts, err := time.Parse(time.RFC3339, strings.TrimSpace(value)); err == nil {
+		return ts.Format(time.RFC3339)
+	}
+	if t, err := parseTime(value); err == nil {
+		return formatTime(t)
+	}
+	return value
+}
+
+// formatTime writes a time of day as 24-hour time, keeping seconds only when set.
+func formatTime(t time.Time) string {
+	if t.Second() != 0 {
+		return t.Format("15:04:05")
+	}
+	return t.Format("15:04")
+}
+
+// orderedEntry is one note's raw text in a daily file along with its sort key.
+type orderedEntry struct {
+	note    Note
+	text    string
+	time    time.Time
+	hasTime bool
+}
+
+// appendNoteInOrder adds note, formatted as fullNote, to the file at path.
+// Notes added to a file that already has content are preceded by the
+// configured separator. When any note in the file has a time, notes are
+// rewritten sorted by time, with untimed notes after them in the order they
+// were added. Each note's text is kept exactly as it was, and files without
+// times are simply appended to.
+func appendNoteInOrder(fs FileSystem, path string, note Note, fullNote string, opts Options) error {
+	data, err := fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && strings.TrimSpace(string(data)) == "") {
+		return fs.AppendToFile(path, fullNote, opts.filePerm())
+	}
+	if err != nil {
+		opts.logger().Errorf("Failed to read file %s: %v\n", path, err)
+		return err
+	}
+
+	existing := string(data)
+	if !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+
+	preamble, entries, err := orderedEntries(existing, opts)
+	if err != nil {
+		return err
+	}
+	entries = append(entries, newOrderedEntry(note, fullNote))
+
+	if slices.IsSortedFunc(entries, compareEntries) {
+		separator, err := opts.separator(note)
+		if err != nil {
+			return err
+		}
+		return fs.AppendToFile(path, separator+fullNote, opts.filePerm())
+	}
+
+	slices.SortStableFunc(entries, compareEntries)
+
+	var contents strings.Builder
+	contents.WriteString(preamble)
+	for i, entry := range entries {
+		if i > 0 {
+			separator, err := opts.separator(entry.note)
+			if err != nil {
+				return err
+			}
+			contents.WriteString(separator)
+		}
+		contents.WriteString(entry.text)
+	}
+
+	opts.logger().Debugf("Reordering notes by time in %s\n", path)
+	return fs.WriteFile(path, []byte(contents.String()), opts.filePerm())
+}
+
+// orderedEntries splits file contents into the text before the first note and
+// each note's raw text with its time. Separators written before a note are
+// removed from the end of the note ahead of it.
+func orderedEntries(data string, opts Options) (string, []orderedEntry, error) {
+	lines := scanLines(data)
+	blocks := splitNoteBlocks(lines)
+	if len(blocks) == 0 {
+		return data, nil, nil
+	}
+
+	preamble := joinLines(lines[:blocks[0].start])
+	entries := make([]orderedEntry, 0, len(blocks))
+	for _, block := range blocks {
+		var note Note
+		if err := yaml.Unmarshal([]byte(block.metadata(lines)), &note); err != nil {
+			note = Note{}
+		}
+		entries = append(entries, newOrderedEntry(note, joinLines(lines[block.start:block.next])))
+	}
+
+	for i := 1; i < len(entries); i++ {
+		separator, err := opts.separator(entries[i].note)
+		if err != nil {
+			return "", nil, err
+		}
+		entries[i-1].text = strings.TrimSuffix(entries[i-1].text, separator)
+	}
+
+	return preamble, entries, nil
+}
+
+// newOrderedEntry pairs a note's text with its parsed time, if it has one.
+func newOrderedEntry(note Note, text string) orderedEntry {
+	entry := orderedEntry{note: note, text: text}
+	if note.Time != "" {
+		if t, err := parseTime(note.Time); err == nil {
+			entry.time, entry.hasTime = t, true
+		}
+	}
+	return entry
+}
+
+// compareEntries orders timed notes by time ahead of untimed notes.
+func compareEntries(a, b orderedEntry) int {
+	switch {
+	case a.hasTime && b.hasTime:
+		return a.time.Compare(b.time)
+	case a.hasTime:
+		return -1
+	case b.hasTime:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// joinLines joins lines back into text, ending each with a newline.
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,158 @@
+package notes
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessNotes_OrdersByTime(t *testing.T) {
+	fs := NewMockFileSystem()
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	runs := []string{
+		"---\ntitle: Afternoon\ndate: 2023-10-01\ntime: 14:00\n---\nAfternoon notes.\n",
+		"---\ntitle: Morning\ndate: 2023-10-01\ntime: 9:00 AM\n---\nMorning notes.\n",
+		"---\ntitle: Late Morning\ndate: 2023-10-01\ntime: 11:30\n---\nLate morning notes.\n",
+	}
+
+	for _, data := range runs {
+		if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+			t.Fatalf("ProcessNotes failed: %v", err)
+		}
+	}
+
+	titles := []string{"title: Morning", "title: Late Morning", "title: Afternoon"}
+	last := -1
+	for _, title := range titles {
+		index := strings.Index(fs.Files[path], title)
+		if index <= last {
+			t.Fatalf("Expected notes in time order %v, got:\n%s", titles, fs.Files[path])
+		}
+		last = index
+	}
+	if !strings.Contains(fs.Files[path], `time: "09:00"`) {
+		t.Errorf("Expected time normalized to 24-hour form, got:\n%s", fs.Files[path])
+	}
+
+	// Processing the same notes again leaves the file as it was
+	before := fs.Files[path]
+	for _, data := range runs {
+		if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+			t.Fatalf("ProcessNotes failed: %v", err)
+		}
+	}
+	if fs.Files[path] != before {
+		t.Errorf("Expected reprocessing to leave the file unchanged.\nBefore:\n%s\nAfter:\n%s", before, fs.Files[path])
+	}
+}
+
+func TestAppendNoteInOrder_PreservesExistingText(t *testing.T) {
+	fs := NewMockFileSystem()
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	untimed := "---\ntitle:   Hand Edited\ndate: 2023-10-01\n---\n\n  Indented content, kept as written.\n\n\n"
+	evening := "---\ntitle: Evening\ndate: 2023-10-01\ntime: 20:00\n---\nEvening notes.\n\n"
+	fs.Files[path] = untimed + evening
+
+	noon := "---\ntitle: Noon\ndate: 2023-10-01\ntime: \"12:00\"\n---\nNoon notes.\n\n"
+	if err := appendNoteInOrder(fs, path, Note{Time: "12:00"}, noon, Options{}); err != nil {
+		t.Fatalf("appendNoteInOrder failed: %v", err)
+	}
+
+	expected := noon + evening + untimed
+	if fs.Files[path] != expected {
+		t.Errorf("File content mismatch.\nExpected:\n%s\nGot:\n%s", expected, fs.Files[path])
+	}
+}
+
+func TestAppendNoteInOrder_AppendsWithoutTimes(t *testing.T) {
+	fs := NewMockFileSystem()
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	fs.Files[path] = "---\ntitle: First\ndate: 2023-10-01\n---\nFirst."
+	writes := fs.Writes
+
+	second := "---\ntitle: Second\ndate: 2023-10-01\n---\nSecond.\n\n"
+	if err := appendNoteInOrder(fs, path, Note{}, second, Options{}); err != nil {
+		t.Fatalf("appendNoteInOrder failed: %v", err)
+	}
+
+	if fs.Files[path] != "---\ntitle: First\ndate: 2023-10-01\n---\nFirst."+second {
+		t.Errorf("Expected a plain append, got:\n%s", fs.Files[path])
+	}
+	if fs.Writes != writes+1 {
+		t.Errorf("Expected a single append, got %d writes", fs.Writes-writes)
+	}
+}
+
+func TestValidateNote_InvalidTime(t *testing.T) {
+	note := Note{Title: "Bad Time", Date: readDate("2023-10-01"), Time: "25:99"}
+	if err := validateNote(note, Options{}); err == nil {
+		t.Error("Expected error for invalid time, got none")
+	}
+}
+
+func TestProcessNotes_NoteSeparator(t *testing.T) {
+	fs := NewMockFileSystem()
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	opts := Options{NotesDir: "/notes", NoteSeparator: "***", Now: fixedClock}
+	data := "---\ntitle: First\ndate: 2023-10-01\n---\nFirst.\n---\ntitle: Second\ndate: 2023-10-01\n---\nSecond.\n"
+
+	if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	content := fs.Files[path]
+	if !strings.HasPrefix(content, "---\ntitle: First\n") {
+		t.Errorf("Expected no separator before the first note in a fresh file, got:\n%s", content)
+	}
+	if !strings.Contains(content, "First.\n\n***\n\n---\ntitle: Second\n") {
+		t.Errorf("Expected separator between notes, got:\n%s", content)
+	}
+
+	// The separator doesn't stop the first note being recognized as a duplicate
+	if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+	if fs.Files[path] != content {
+		t.Errorf("Expected reprocessing to leave the file unchanged.\nBefore:\n%s\nAfter:\n%s", content, fs.Files[path])
+	}
+}
+
+func TestAppendNoteInOrder_SeparatorTemplate(t *testing.T) {
+	fs := NewMockFileSystem()
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	opts := Options{NoteSeparator: "## {{.Time}}"}
+	evening := "---\ntitle: Evening\ndate: 2023-10-01\ntime: \"20:00\"\n---\nEvening notes.\n\n"
+	fs.Files[path] = evening
+
+	morning := "---\ntitle: Morning\ndate: 2023-10-01\ntime: \"08:00\"\n---\nMorning notes.\n\n"
+	if err := appendNoteInOrder(fs, path, Note{Time: "08:00"}, morning, opts); err != nil {
+		t.Fatalf("appendNoteInOrder failed: %v", err)
+	}
+	expected := morning + "## 20:00\n\n" + evening
+	if fs.Files[path] != expected {
+		t.Fatalf("File content mismatch.\nExpected:\n%s\nGot:\n%s", expected, fs.Files[path])
+	}
+
+	noon := "---\ntitle: Noon\ndate: 2023-10-01\ntime: \"12:00\"\n---\nNoon notes.\n\n"
+	if err := appendNoteInOrder(fs, path, Note{Time: "12:00"}, noon, opts); err != nil {
+		t.Fatalf("appendNoteInOrder failed: %v", err)
+	}
+	expected = morning + "## 12:00\n\n" + noon + "## 20:00\n\n" + evening
+	if fs.Files[path] != expected {
+		t.Errorf("File content mismatch.\nExpected:\n%s\nGot:\n%s", expected, fs.Files[path])
+	}
+}
+
+func TestAppendNoteInOrder_EmptyFileGetsNoSeparator(t *testing.T) {
+	fs := NewMockFileSystem()
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	fs.Files[path] = ""
+
+	note := "---\ntitle: Only\ndate: 2023-10-01\n---\nOnly note.\n\n"
+	if err := appendNoteInOrder(fs, path, Note{}, note, Options{NoteSeparator: "***"}); err != nil {
+		t.Fatalf("appendNoteInOrder failed: %v", err)
+	}
+	if fs.Files[path] != note {
+		t.Errorf("Expected no separator in an empty file, got:\n%s", fs.Files[path])
+	}
+}
@@ -0,0 +1,136 @@
+package notes
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// DefaultPathTemplate places notes in year and month directories with one file per day.
+const DefaultPathTemplate = "2006/01/02.md"
+
+// PathData is what a Go template path template is rendered against.
+type PathData struct {
+	Year      string // Four-digit year, e.g. "2024"
+	Month     string // Two-digit month, e.g. "09"
+	Day       string // Two-digit day of the month, e.g. "12"
+	Date      string // ISO date, e.g. "2024-09-12"
+	TitleSlug string // The title lowercased with punctuation removed and words hyphenated
+
+	date time.Time // Used to render time layout templates
+}
+
+// newPathData builds the template data for a note's date and title.
+func newPathData(date time.Time, title string) PathData {
+	return PathData{
+		Year:      date.Format("2006"),
+		Month:     date.Format("01"),
+		Day:       date.Format("02"),
+		Date:      date.Format(isoDateLayout),
+		TitleSlug: slugify(title),
+		date:      date,
+	}
+}
+
+// RenderPath renders a path template for a note's date and title. Templates
+// containing "{{" use Go template syntax over PathData; anything else is a Go
+// time layout such as "2006/01/02.md".
+func RenderPath(pathTemplate string, date time.Time, title string) (string, error) {
+	return renderPath(pathTemplate, newPathData(date, title))
+}
+
+func renderPath(pathTemplate string, data PathData) (string, error) {
+	if !strings.Contains(pathTemplate, "{{") {
+		return data.date.Format(pathTemplate), nil
+	}
+
+	tmpl, err := template.New("path").Parse(pathTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var path strings.Builder
+	if err := tmpl.Execute(&path, data); err != nil {
+		return "", err
+	}
+	return path.String(), nil
+}
+
+// DailyNotePath returns the file in opts.NotesDir that holds notes dated on
+// the given day. It fails when the path template gives each note its own file.
+func DailyNotePath(date time.Time, opts Options) (string, error) {
+	if usesTitleSlug(opts.pathTemplate()) {
+		return "", fmt.Errorf("path template %q gives each note its own file", opts.pathTemplate())
+	}
+	return renderNotePath(Note{}, newPathData(date, ""), opts)
+}
+
+// withSlug adds a note's sanitized slug to the end of a file name, before its
+// extension, so "2023/10/01.md" becomes "2023/10/01-standup-notes.md".
+func withSlug(path, slug string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + slugify(slug) + ext
+}
+
+// usesTitleSlug reports whether a path template gives each title its own file.
+func usesTitleSlug(pathTemplate string) bool {
+	return strings.Contains(pathTemplate, "{{") && strings.Contains(pathTemplate, ".TitleSlug")
+}
+
+// ValidatePathTemplate checks that a path template renders to a relative
+// Markdown file path that stays inside the notes directory and depends on
+// the note.
+func ValidatePathTemplate(pathTemplate string) error {
+	if pathTemplate == "" {
+		return errors.New("path template must not be empty")
+	}
+
+	first, err := RenderPath(pathTemplate, time.Date(2001, time.February, 3, 0, 0, 0, 0, time.UTC), "First Note")
+	if err != nil {
+		return fmt.Errorf("path template %q: %w", pathTemplate, err)
+	}
+	second, err := RenderPath(pathTemplate, time.Date(2012, time.November, 24, 0, 0, 0, 0, time.UTC), "Second Note")
+	if err != nil {
+		return fmt.Errorf("path template %q: %w", pathTemplate, err)
+	}
+
+	if first == second {
+		return fmt.Errorf("path template %q doesn't depend on the note; use date layout tokens such as 2006, 01, or 02, or template fields such as {{.Date}}", pathTemplate)
+	}
+	if filepath.Ext(first) != ".md" {
+		return fmt.Errorf("path template %q must end in .md, got %q", pathTemplate, first)
+	}
+	if filepath.IsAbs(first) || !filepath.IsLocal(filepath.FromSlash(first)) {
+		return fmt.Errorf("path template %q must be a relative path inside the notes directory, got %q", pathTemplate, first)
+	}
+
+	return nil
+}
+
+// slugify lowercases s, drops punctuation, and joins the remaining words with
+// hyphens. A title with no letters or digits becomes "untitled".
+func slugify(s string) string {
+	var slug strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingHyphen && slug.Len() > 0 {
+				slug.WriteRune('-')
+			}
+			pendingHyphen = false
+			slug.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			pendingHyphen = true
+		}
+	}
+
+	if slug.Len() == 0 {
+		return "untitled"
+	}
+	return slug.String()
+}
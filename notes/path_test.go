@@ -0,0 +1,77 @@
+package notes
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Meeting with Project Team": "meeting-with-project-team",
+		"  What's next?! (Q4)  ":    "whats-next-q4",
+		"snake_case and--dashes":    "snake-case-and-dashes",
+		"Café Résumé":               "café-résumé",
+		"!!!":                       "untitled",
+	}
+
+	for title, expected := range tests {
+		if got := slugify(title); got != expected {
+			t.Errorf("slugify(%q): expected %q, got %q", title, expected, got)
+		}
+	}
+}
+
+func TestRenderPath(t *testing.T) {
+	date := time.Date(2024, time.September, 12, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{template: DefaultPathTemplate, expected: "2024/09/12.md"},
+		{template: "{{.Year}}/{{.Month}}/{{.Day}}-{{.TitleSlug}}.md", expected: "2024/09/12-team-sync.md"},
+		{template: "{{.Date}}.md", expected: "2024-09-12.md"},
+	}
+
+	for _, tt := range tests {
+		got, err := RenderPath(tt.template, date, "Team Sync")
+		if err != nil {
+			t.Fatalf("RenderPath(%q) failed: %v", tt.template, err)
+		}
+		if got != tt.expected {
+			t.Errorf("RenderPath(%q): expected %q, got %q", tt.template, tt.expected, got)
+		}
+	}
+}
+
+func TestValidatePathTemplate(t *testing.T) {
+	valid := []string{DefaultPathTemplate, "2006-01-02.md", "{{.Year}}/{{.Month}}/{{.Day}}-{{.TitleSlug}}.md", "{{.TitleSlug}}.md"}
+	for _, template := range valid {
+		if err := ValidatePathTemplate(template); err != nil {
+			t.Errorf("Expected %q to be valid, got: %v", template, err)
+		}
+	}
+
+	invalid := []string{"", "notes.md", "{{.Year}/{{.Day}}.md", "{{.Weekday}}.md", "{{.Date}}.txt", "../{{.Date}}.md", "/{{.Date}}.md"}
+	for _, template := range invalid {
+		if err := ValidatePathTemplate(template); err == nil {
+			t.Errorf("Expected %q to be invalid", template)
+		}
+	}
+}
+
+func TestDailyNotePath(t *testing.T) {
+	date := time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	path, err := DailyNotePath(date, Options{NotesDir: "/notes"})
+	if err != nil {
+		t.Fatalf("DailyNotePath failed: %v", err)
+	}
+	if expected := filepath.Join("/notes", "2023", "10", "01.md"); path != expected {
+		t.Errorf("Expected %s, got %s", expected, path)
+	}
+
+	if _, err := DailyNotePath(date, Options{NotesDir: "/notes", PathTemplate: "{{.Date}}-{{.TitleSlug}}.md"}); err == nil {
+		t.Error("Expected an error for a template with one file per note")
+	}
+}
@@ -0,0 +1,59 @@
+package notes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PublishDraft files the notes in a draft file into the dated tree through
+// the same pipeline as ProcessNotesWithOptions, then deletes the draft. A
+// non-empty date replaces the dates in the draft; every note must end up with
+// one. In a dry run the draft is kept.
+func PublishDraft(fs FileSystem, path, date string, opts Options) error {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		opts.logger().Errorf("Failed to read draft %s: %v\n", path, err)
+		return err
+	}
+
+	drafts, err := SplitNotesFromFile(string(data))
+	if err != nil {
+		opts.logger().Errorf("Failed to parse draft %s: %v\n", path, err)
+		return err
+	}
+	if len(drafts) == 0 {
+		return fmt.Errorf("draft %s holds no notes", path)
+	}
+
+	var buffer strings.Builder
+	for _, note := range drafts {
+		note.Draft = false
+		if date != "" {
+			note.Date = readDate(date)
+		}
+		if note.Date.IsZero() {
+			return fmt.Errorf("draft %q has no date: pass --date YYYY-MM-DD or add a date: field to %s", note.Title, path)
+		}
+
+		formatted, err := formatNoteContent(note, opts)
+		if err != nil {
+			return err
+		}
+		buffer.WriteString(formatted)
+	}
+
+	if _, err := ProcessNotesWithOptions(buffer.String(), fs, opts); err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		opts.logger().Infof("[dry-run] Would remove draft %s\n", path)
+		return nil
+	}
+	if err := fs.RemoveFile(path); err != nil {
+		opts.logger().Errorf("Failed to remove draft %s: %v\n", path, err)
+		return err
+	}
+	opts.logger().Infof("Published draft %s\n", path)
+	return nil
+}
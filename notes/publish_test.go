@@ -0,0 +1,73 @@
+package notes
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+func TestPublishDraft(t *testing.T) {
+	draftPath := filepath.Join("/notes", DraftsDir, "half-finished-idea.md")
+	draft := "---\ntitle: Half-Finished Idea\ndate: \ntags:\n    - ideas\ndraft: true\nwords: 3\nreading_minutes: 1\n---\nNow it's ready.\n\n"
+
+	fs := NewMockFileSystem()
+	fs.Files[draftPath] = draft
+
+	// Without a date the draft stays where it is
+	err := PublishDraft(fs, draftPath, "", Options{NotesDir: "/notes", Now: fixedClock})
+	if err == nil || !strings.Contains(err.Error(), "pass --date YYYY-MM-DD") {
+		t.Errorf("Expected an error explaining how to date the draft, got %v", err)
+	}
+	if fs.Files[draftPath] != draft {
+		t.Errorf("Expected the draft to be kept, got:\n%s", fs.Files[draftPath])
+	}
+
+	if err := PublishDraft(fs, draftPath, "2023-10-01", Options{NotesDir: "/notes", Now: fixedClock}); err != nil {
+		t.Fatalf("PublishDraft failed: %v", err)
+	}
+
+	published := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]
+	expected := `---
+title: Half-Finished Idea
+date: 2023-10-01
+tags:
+    - ideas
+updated: 2023-10-01T09:30:00Z
+words: 3
+reading_minutes: 1
+---
+Now it's ready.
+
+`
+	if published != expected {
+		t.Errorf("Published note mismatch.\nExpected:\n%s\nGot:\n%s", expected, published)
+	}
+	if _, exists := fs.Files[draftPath]; exists {
+		t.Error("Expected the draft to be removed after publishing")
+	}
+}
+
+func TestPublishDraft_DryRunKeepsDraft(t *testing.T) {
+	draftPath := filepath.Join("/notes", DraftsDir, "idea.md")
+	fs := NewMockFileSystem()
+	fs.Files[draftPath] = "---\ntitle: Idea\ndate: 2023-10-01\ndraft: true\n---\nContent.\n"
+
+	var logs bytes.Buffer
+	opts := Options{NotesDir: "/notes", DryRun: true, Logger: logging.StdLogger{Log: log.New(&logs, "", 0)}}
+	if err := PublishDraft(fs, draftPath, "", opts); err != nil {
+		t.Fatalf("PublishDraft failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "Would remove draft "+draftPath) {
+		t.Errorf("Expected the dry run logged to the given logger, got:\n%s", logs.String())
+	}
+	if _, exists := fs.Files[draftPath]; !exists {
+		t.Error("Expected a dry run to keep the draft")
+	}
+	if len(fs.Files) != 1 {
+		t.Errorf("Expected nothing to be written in a dry run, got %v", fs.Files)
+	}
+}
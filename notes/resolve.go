@@ -0,0 +1,52 @@
+package notes
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+// ResolveAlias returns the saved notes under dir whose title or one of whose
+// aliases is name, ignoring case and surrounding whitespace, sorted by date.
+// Files that can't be parsed are skipped with a warning.
+func ResolveAlias(fs FileSystem, dir, name string) ([]StoredNote, error) {
+	name = strings.TrimSpace(name)
+	var matches []StoredNote
+
+	err := walkNoteFiles(fs, dir, func(path string, data []byte) error {
+		fileNotes, err := SplitNotesFromFile(string(data))
+		if err != nil {
+			logging.Warnf("Warning: skipping unparseable notes in %s: %v\n", path, err)
+		}
+
+		for _, note := range fileNotes {
+			if note.knownAs(name) {
+				matches = append(matches, StoredNote{Note: note, Path: path})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Date.String() < matches[j].Date.String()
+	})
+
+	return matches, nil
+}
+
+// knownAs reports whether name is the note's title or one of its aliases.
+func (n Note) knownAs(name string) bool {
+	if strings.EqualFold(strings.TrimSpace(n.Title), name) {
+		return true
+	}
+	for _, alias := range n.Aliases {
+		if strings.EqualFold(strings.TrimSpace(alias), name) {
+			return true
+		}
+	}
+	return false
+}
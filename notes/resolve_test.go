@@ -0,0 +1,46 @@
+package notes
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveAlias(t *testing.T) {
+	fs := NewMockFileSystem()
+	first := filepath.Join("notes", "2023", "10", "01.md")
+	second := filepath.Join("notes", "2023", "10", "02.md")
+	fs.Files[first] = "---\ntitle: Project Phoenix\ndate: 2023-10-01\naliases: [phoenix, PX]\n---\nThe rewrite.\n"
+	fs.Files[second] = "---\ntitle: Phoenix Retro\ndate: 2023-10-02\naliases: [px]\n---\nHow it went.\n---\ntitle: Lunch\ndate: 2023-10-02\n---\nTacos.\n"
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{name: "phoenix", expected: []string{first}},
+		{name: "Project Phoenix", expected: []string{first}},
+		{name: " px ", expected: []string{first, second}},
+		{name: "lunch", expected: []string{second}},
+		{name: "icarus", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := ResolveAlias(fs, "notes", tt.name)
+			if err != nil {
+				t.Fatalf("ResolveAlias failed: %v", err)
+			}
+			var paths []string
+			for _, match := range matches {
+				paths = append(paths, match.Path)
+			}
+			if len(paths) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, paths)
+			}
+			for i := range paths {
+				if paths[i] != tt.expected[i] {
+					t.Errorf("Expected %v, got %v", tt.expected, paths)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package notes
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+// snippetRadius is how many characters of context are kept on each side of a match.
+const snippetRadius = 30
+
+// SearchFilter selects saved notes by text, tag, and date range. Zero values match everything.
+type SearchFilter struct {
+	Query      string    // Text to find in note content; front matter is not searched
+	IgnoreCase bool      // Match Query regardless of case
+	Tags       []string  // Notes matching any of these tags, compared case-insensitively
+	From       time.Time // Earliest note date, inclusive
+	To         time.Time // Latest note date, inclusive
+}
+
+// SearchResult is a saved note matching a search, with a snippet for each content line matching the query.
+type SearchResult struct {
+	StoredNote
+	Snippets []string
+}
+
+// SearchNotes returns the saved notes under dir matching the filter, sorted by date.
+// Files are read one at a time rather than all up front, and files that can't be
+// parsed are skipped with a warning.
+func SearchNotes(fs FileSystem, dir string, filter SearchFilter) ([]SearchResult, error) {
+	var matches []SearchResult
+
+	err := walkNoteFiles(fs, dir, func(path string, data []byte) error {
+		fileNotes, err := SplitNotesFromFile(string(data))
+		if err != nil {
+			logging.Warnf("Warning: skipping unparseable notes in %s: %v\n", path, err)
+		}
+
+		for _, note := range fileNotes {
+			if !filter.matches(note) {
+				continue
+			}
+
+			snippets := filter.snippets(note.Content)
+			if filter.Query != "" && len(snippets) == 0 {
+				continue
+			}
+			matches = append(matches, SearchResult{
+				StoredNote: StoredNote{Note: note, Path: path},
+				Snippets:   snippets,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Date.String() < matches[j].Date.String()
+	})
+
+	return matches, nil
+}
+
+// matches reports whether the note satisfies every criterion in the filter.
+func (f SearchFilter) matches(note Note) bool {
+	if !f.From.IsZero() || !f.To.IsZero() {
+		date := note.Date.Time
+		if date.IsZero() {
+			return false
+		}
+		if !f.From.IsZero() && date.Before(f.From) {
+			return false
+		}
+		if !f.To.IsZero() && date.After(f.To) {
+			return false
+		}
+	}
+
+	return f.matchesTags(note.Tags)
+}
+
+// matchesTags reports whether any of the note's tags is one of the filter's tags.
+func (f SearchFilter) matchesTags(tags []string) bool {
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, want := range f.Tags {
+		for _, tag := range tags {
+			if strings.EqualFold(tag, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// snippets returns a snippet around the first match of the query on each matching content line.
+func (f SearchFilter) snippets(content string) []string {
+	if f.Query == "" {
+		return nil
+	}
+
+	query := f.Query
+	if f.IgnoreCase {
+		query = strings.ToLower(query)
+	}
+
+	var snippets []string
+	for _, line := range strings.Split(content, "\n") {
+		haystack := line
+		if f.IgnoreCase {
+			haystack = strings.ToLower(line)
+		}
+
+		// Lowercasing can change byte lengths, so locate the match in runes
+		index := strings.Index(haystack, query)
+		if index < 0 {
+			continue
+		}
+		start := utf8.RuneCountInString(haystack[:index])
+		length := utf8.RuneCountInString(query)
+		snippets = append(snippets, snippetAround(line, start, length))
+	}
+	return snippets
+}
+
+// snippetAround trims line to the match at rune offset start plus some context on each side.
+func snippetAround(line string, start, length int) string {
+	runes := []rune(line)
+
+	from := max(start-snippetRadius, 0)
+	to := min(start+length+snippetRadius, len(runes))
+
+	snippet := strings.TrimSpace(string(runes[from:to]))
+	if from > 0 {
+		snippet = "..." + snippet
+	}
+	if to < len(runes) {
+		snippet += "..."
+	}
+	return snippet
+}
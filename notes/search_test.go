@@ -0,0 +1,169 @@
+package notes
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func seedSearchNotes(t *testing.T) *MockFileSystem {
+	t.Helper()
+
+	data := `---
+title: Go Meetup
+date: 2024-03-10
+tags:
+  - GoLang
+  - events
+---
+Talked about generics.
+---
+title: Grocery List
+date: 2024-03-10
+tags:
+  - personal
+---
+Eggs and milk.
+---
+title: Old Go Note
+date: 2023-12-31
+tags:
+  - golang
+---
+Last year's note.
+---
+title: Work Planning
+date: 2024-06-01
+tags:
+  - work
+---
+Quarterly goals.
+`
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+		t.Fatalf("ProcessNotes failed: %v", err)
+	}
+	return fs
+}
+
+func TestSearchNotes_TagCaseInsensitive(t *testing.T) {
+	fs := seedSearchNotes(t)
+
+	results, err := SearchNotes(fs, "/notes", SearchFilter{Tags: []string{"golang"}})
+	if err != nil {
+		t.Fatalf("SearchNotes failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d: %+v", len(results), results)
+	}
+	if results[0].Title != "Old Go Note" || results[1].Title != "Go Meetup" {
+		t.Errorf("Expected results sorted by date, got %q then %q", results[0].Title, results[1].Title)
+	}
+
+	expectedPath := filepath.Join("/notes", "2024/03", "10.md")
+	if results[1].Path != expectedPath {
+		t.Errorf("Expected path %s, got %s", expectedPath, results[1].Path)
+	}
+}
+
+func TestSearchNotes_AnyOfTagsAndDateRange(t *testing.T) {
+	fs := seedSearchNotes(t)
+
+	filter := SearchFilter{
+		Tags: []string{"golang", "WORK"},
+		From: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	results, err := SearchNotes(fs, "/notes", filter)
+	if err != nil {
+		t.Fatalf("SearchNotes failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d: %+v", len(results), results)
+	}
+	if results[0].Title != "Go Meetup" || results[1].Title != "Work Planning" {
+		t.Errorf("Unexpected results: %q, %q", results[0].Title, results[1].Title)
+	}
+}
+
+func TestSearchNotes_InclusiveBounds(t *testing.T) {
+	fs := seedSearchNotes(t)
+
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	results, err := SearchNotes(fs, "/notes", SearchFilter{From: day, To: day})
+	if err != nil {
+		t.Fatalf("SearchNotes failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Errorf("Expected both notes on the boundary day, got %d", len(results))
+	}
+}
+
+func TestSearchNotes_FullText(t *testing.T) {
+	fs := seedSearchNotes(t)
+	path := filepath.Join("/notes", "2024/06", "15.md")
+	fs.Files[path] = `---
+title: Long Note
+date: 2024-06-15
+tags:
+  - work
+---
+This line is intentionally quite long so that the GENERICS keyword ends up in the middle of it with context trimmed.
+Nothing here.
+Generics again.
+`
+
+	results, err := SearchNotes(fs, "/notes", SearchFilter{Query: "generics", IgnoreCase: true})
+	if err != nil {
+		t.Fatalf("SearchNotes failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d: %+v", len(results), results)
+	}
+	if results[0].Title != "Go Meetup" || results[1].Title != "Long Note" {
+		t.Errorf("Unexpected results: %q, %q", results[0].Title, results[1].Title)
+	}
+
+	expectedSnippets := []string{
+		"...onally quite long so that the GENERICS keyword ends up in the middle...",
+		"Generics again.",
+	}
+	if !slices.Equal(results[1].Snippets, expectedSnippets) {
+		t.Errorf("Snippet mismatch.\nExpected: %q\nGot:      %q", expectedSnippets, results[1].Snippets)
+	}
+}
+
+func TestSearchNotes_FullTextCaseSensitive(t *testing.T) {
+	fs := seedSearchNotes(t)
+
+	results, err := SearchNotes(fs, "/notes", SearchFilter{Query: "Generics"})
+	if err != nil {
+		t.Fatalf("SearchNotes failed: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("Expected case-sensitive search to miss, got %d results", len(results))
+	}
+}
+
+func TestSearchNotes_FullTextWithTag(t *testing.T) {
+	fs := seedSearchNotes(t)
+
+	results, err := SearchNotes(fs, "/notes", SearchFilter{Query: "note", IgnoreCase: true, Tags: []string{"golang"}})
+	if err != nil {
+		t.Fatalf("SearchNotes failed: %v", err)
+	}
+
+	// The title "Go Meetup" doesn't count, only content is searched
+	if len(results) != 1 || results[0].Title != "Old Go Note" {
+		t.Fatalf("Expected only Old Go Note, got %+v", results)
+	}
+	if !slices.Equal(results[0].Snippets, []string{"Last year's note."}) {
+		t.Errorf("Unexpected snippets: %q", results[0].Snippets)
+	}
+}
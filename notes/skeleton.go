@@ -0,0 +1,121 @@
+package notes
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Skeleton returns the front matter for a new note with the given title,
+// date, and tags, followed by an empty line for its content. It is meant to be
+// added to the buffer and filled in before processing. The date may be a
+// keyword such as "yesterday", which is resolved against the current day.
+func Skeleton(title, date string, tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	day, err := skeletonDate(date)
+	if err != nil {
+		return "", err
+	}
+	frontMatter := struct {
+		Title string   `yaml:"title"`
+		Date  Date     `yaml:"date"`
+		Tags  []string `yaml:"tags"`
+	}{Title: title, Date: day, Tags: tags}
+
+	data, err := yaml.Marshal(frontMatter)
+	if err != nil {
+		logging.Errorf("Failed to encode YAML front matter")
+		return "", err
+	}
+
+	return fmt.Sprintf("---\n%s---\n\n", data), nil
+}
+
+// skeletonDate resolves a new note's date, which may be a keyword such as
+// "yesterday", against the current day.
+func skeletonDate(date string) (Date, error) {
+	resolved, err := resolveDateKeyword(date, now())
+	if err != nil {
+		return Date{}, err
+	}
+	day, err := ParseDate(resolved)
+	if err != nil {
+		logging.Errorf("Invalid date: %s", date)
+		return Date{}, err
+	}
+	return day, nil
+}
+
+// SkeletonData is what a note template is rendered with.
+type SkeletonData struct {
+	Title string   // Title of the new note
+	Date  string   // Date of the new note as 2006-01-02
+	Tags  []string // Tags of the new note, empty if none were given
+}
+
+// RenderSkeleton is Skeleton rendering noteTemplate, a Go template over
+// SkeletonData, in place of the built-in front matter. The yaml function
+// writes a value as inline YAML, quoting it if needed, e.g.
+// "---\ntitle: {{yaml .Title}}\ndate: {{.Date}}\ntags: {{yaml .Tags}}\n---\n\n".
+// An empty template gives the same result as Skeleton.
+func RenderSkeleton(noteTemplate, title, date string, tags []string) (string, error) {
+	if noteTemplate == "" {
+		return Skeleton(title, date, tags)
+	}
+
+	tmpl, err := parseNoteTemplate(noteTemplate)
+	if err != nil {
+		logging.Errorf("Invalid note template: %v", err)
+		return "", err
+	}
+	day, err := skeletonDate(date)
+	if err != nil {
+		return "", err
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	var skeleton strings.Builder
+	if err := tmpl.Execute(&skeleton, SkeletonData{Title: title, Date: day.String(), Tags: tags}); err != nil {
+		logging.Errorf("Failed to render note template: %v", err)
+		return "", err
+	}
+	return skeleton.String(), nil
+}
+
+// ValidateNoteTemplate checks that a note template parses and renders.
+func ValidateNoteTemplate(noteTemplate string) error {
+	if noteTemplate == "" {
+		return nil
+	}
+	tmpl, err := parseNoteTemplate(noteTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(&strings.Builder{}, SkeletonData{Title: "Sample Note", Date: "2001-02-03", Tags: []string{"sample"}})
+}
+
+func parseNoteTemplate(noteTemplate string) (*template.Template, error) {
+	return template.New("template").Funcs(template.FuncMap{"yaml": inlineYAML}).Parse(noteTemplate)
+}
+
+// inlineYAML writes value as a single line of YAML, such as Standup, "a: b",
+// or [work, daily].
+func inlineYAML(value any) (string, error) {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return "", err
+	}
+	node.Style |= yaml.FlowStyle
+	data, err := yaml.Marshal(&node)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
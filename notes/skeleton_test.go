@@ -0,0 +1,78 @@
+package notes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSkeleton(t *testing.T) {
+	skeleton, err := Skeleton("Standup", "2023-10-01", []string{"work", "daily"})
+	if err != nil {
+		t.Fatalf("Skeleton failed: %v", err)
+	}
+
+	expected := "---\ntitle: Standup\ndate: 2023-10-01\ntags:\n    - work\n    - daily\n---\n\n"
+	if skeleton != expected {
+		t.Errorf("Skeleton mismatch.\nExpected:\n%q\nGot:\n%q", expected, skeleton)
+	}
+
+	parsed := parseNotes(skeleton, Options{})
+	if len(parsed.Errors) != 0 || len(parsed.Notes) != 1 {
+		t.Fatalf("Expected the skeleton to parse as one note, got %+v", parsed)
+	}
+	note := parsed.Notes[0]
+	if note.Title != "Standup" || note.Date.String() != "2023-10-01" || !reflect.DeepEqual(note.Tags, []string{"work", "daily"}) {
+		t.Errorf("Unexpected parsed note %+v", note)
+	}
+	if err := validateNote(note, Options{Now: fixedClock}); err != nil {
+		t.Errorf("Expected the skeleton to be valid, got %v", err)
+	}
+
+	// Without tags the list is still there to fill in
+	skeleton, err = Skeleton("Untagged", "2023-10-01", nil)
+	if err != nil {
+		t.Fatalf("Skeleton failed: %v", err)
+	}
+	if parsed := parseNotes(skeleton, Options{}); len(parsed.Notes) != 1 || parsed.Notes[0].Title != "Untagged" {
+		t.Errorf("Expected an untagged skeleton to parse, got %+v from %q", parsed, skeleton)
+	}
+}
+
+func TestRenderSkeleton(t *testing.T) {
+	noteTemplate := "---\ntitle: {{yaml .Title}}\ndate: {{.Date}}\ntags: {{yaml .Tags}}\n---\n\n"
+	skeleton, err := RenderSkeleton(noteTemplate, "Standup: Monday", "2023-10-01", []string{"work", "daily"})
+	if err != nil {
+		t.Fatalf("RenderSkeleton failed: %v", err)
+	}
+	expected := "---\ntitle: 'Standup: Monday'\ndate: 2023-10-01\ntags: [work, daily]\n---\n\n"
+	if skeleton != expected {
+		t.Errorf("RenderSkeleton mismatch.\nExpected:\n%q\nGot:\n%q", expected, skeleton)
+	}
+	parsed := parseNotes(skeleton, Options{})
+	if len(parsed.Notes) != 1 || parsed.Notes[0].Title != "Standup: Monday" || !reflect.DeepEqual(parsed.Notes[0].Tags, []string{"work", "daily"}) {
+		t.Errorf("Expected the rendered skeleton to parse, got %+v", parsed)
+	}
+
+	if skeleton, err := RenderSkeleton(noteTemplate, "", "2023-10-01", nil); err != nil || skeleton != "---\ntitle: \"\"\ndate: 2023-10-01\ntags: []\n---\n\n" {
+		t.Errorf("Expected empty title and tags to render as empty values, got %q, %v", skeleton, err)
+	}
+
+	// An empty template is the built-in skeleton
+	builtIn, _ := Skeleton("Standup", "2023-10-01", nil)
+	if skeleton, err := RenderSkeleton("", "Standup", "2023-10-01", nil); err != nil || skeleton != builtIn {
+		t.Errorf("Expected the built-in skeleton, got %q, %v", skeleton, err)
+	}
+}
+
+func TestValidateNoteTemplate(t *testing.T) {
+	for _, valid := range []string{"", "---\ntitle: {{.Title}}\n---\n", "date: {{.Date}}\ntags: {{yaml .Tags}}\n"} {
+		if err := ValidateNoteTemplate(valid); err != nil {
+			t.Errorf("ValidateNoteTemplate(%q) failed: %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"{{.Title", "{{.Name}}", "{{json .Tags}}"} {
+		if err := ValidateNoteTemplate(invalid); err == nil {
+			t.Errorf("Expected ValidateNoteTemplate(%q) to fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package notes
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultWordsPerMinute is the reading speed assumed when none is configured.
+const defaultWordsPerMinute = 200
+
+// ComputeStats returns the number of words in content and the minutes needed
+// to read them at 200 words per minute. Fenced code blocks are not counted.
+func ComputeStats(content string) (words int, minutes int) {
+	words = countWords(content)
+	return words, readingMinutes(words, defaultWordsPerMinute)
+}
+
+// countWords counts whitespace-separated words outside fenced code blocks.
+// Markdown markers such as list bullets contain no letters or digits and are skipped.
+func countWords(content string) int {
+	words := 0
+	fence := ""
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		for _, field := range strings.Fields(line) {
+			if strings.IndexFunc(field, isWordRune) >= 0 {
+				words++
+			}
+		}
+	}
+	return words
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// readingMinutes rounds the reading time up to whole minutes, so any words
+// at all take at least a minute.
+func readingMinutes(words, wordsPerMinute int) int {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = defaultWordsPerMinute
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
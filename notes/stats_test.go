@@ -0,0 +1,43 @@
+package notes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestComputeStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		words   int
+		minutes int
+	}{
+		{name: "empty", content: "", words: 0, minutes: 0},
+		{name: "short", content: "Had a productive discussion.\n\n- Finalized the scope.", words: 7, minutes: 1},
+		{name: "long", content: strings.Repeat("word ", 401), words: 401, minutes: 3},
+		{
+			name:    "fenced code excluded",
+			content: "Before the code.\n```go\nfunc main() { fmt.Println(\"not counted\") }\n```\n~~~\nalso not counted\n~~~\nAfter.",
+			words:   4,
+			minutes: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words, minutes := ComputeStats(tt.content)
+			if words != tt.words || minutes != tt.minutes {
+				t.Errorf("Expected %d words and %d minutes, got %d and %d", tt.words, tt.minutes, words, minutes)
+			}
+		})
+	}
+}
+
+func TestReadingMinutes_WordsPerMinute(t *testing.T) {
+	if minutes := readingMinutes(250, 100); minutes != 3 {
+		t.Errorf("Expected 3 minutes at 100 wpm, got %d", minutes)
+	}
+	if minutes := readingMinutes(250, 0); minutes != 2 {
+		t.Errorf("Expected fallback to %d wpm giving 2 minutes, got %d", defaultWordsPerMinute, minutes)
+	}
+}
@@ -0,0 +1,90 @@
+package notes
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+)
+
+// StoredNote is a note read back from a file in the notes directory.
+type StoredNote struct {
+	Note
+	Path string `json:"path"`
+	Err  error  `json:"-"` // Set when the file's front matter could not be parsed
+}
+
+// SplitNotesFromFile splits the contents of a saved note file back into the
+// notes ProcessNotes appended to it. The blank lines formatNoteContent adds
+// after each note are not part of its content. If some notes can't be parsed,
+// the rest are returned along with an error describing the failures.
+func SplitNotesFromFile(data string) ([]Note, error) {
+	result := parseSavedNotes(data, Options{})
+	return result.Notes, result.Err()
+}
+
+// ListNotes reads every markdown file under dir and returns the notes they
+// contain sorted by date. Files that can't be parsed are returned with Err set
+// so callers can report them without aborting.
+func ListNotes(fs FileSystem, dir string) ([]StoredNote, error) {
+	var stored []StoredNote
+
+	err := walkNoteFiles(fs, dir, func(path string, data []byte) error {
+		fileNotes, err := SplitNotesFromFile(string(data))
+		if err != nil {
+			logging.Warnf("Warning: failed to parse notes in %s: %v\n", path, err)
+			stored = append(stored, StoredNote{Path: path, Err: err})
+		}
+
+		for _, note := range fileNotes {
+			stored = append(stored, StoredNote{Note: note, Path: path})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(stored, func(i, j int) bool {
+		// Unparseable files are listed after all notes
+		if (stored[i].Err == nil) != (stored[j].Err == nil) {
+			return stored[i].Err == nil
+		}
+		return stored[i].Date.String() < stored[j].Date.String()
+	})
+
+	return stored, nil
+}
+
+// walkNoteFiles calls fn with the path and contents of each markdown file under
+// dir, one file at a time. A missing dir is treated as empty.
+func walkNoteFiles(fs FileSystem, dir string, fn func(path string, data []byte) error) error {
+	paths, err := fs.ListFiles(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		logging.Errorf("Failed to list files in %s: %v\n", dir, err)
+		return err
+	}
+
+	for _, path := range paths {
+		if filepath.Ext(path) != ".md" {
+			continue
+		}
+
+		data, err := fs.ReadFile(path)
+		if err != nil {
+			logging.Errorf("Failed to read file %s: %v\n", path, err)
+			return err
+		}
+
+		if err := fn(path, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
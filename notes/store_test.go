@@ -0,0 +1,122 @@
+package notes
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestListNotes(t *testing.T) {
+	data := `---
+title: Later Note
+date: 2023-10-02
+tags:
+  - work
+---
+Later content.
+---
+title: Morning Note
+date: 2023-10-01
+---
+Morning content.
+---
+title: Evening Note
+date: 2023-10-01
+tags:
+  - personal
+---
+Evening content.
+`
+
+	fs := NewMockFileSystem()
+	if _, err := ProcessNotes(data, "/notes", fs); err != nil {
+		t.Fatalf("ProcessNotes failed: %v", err)
+	}
+
+	malformedPath := filepath.Join("/notes", "2023/09", "30.md")
+	fs.Files[malformedPath] = "---\ntitle: [unclosed\n---\nBroken.\n"
+	fs.Files[filepath.Join("/notes", "README.txt")] = "not a note"
+
+	stored, err := ListNotes(fs, "/notes")
+	if err != nil {
+		t.Fatalf("ListNotes failed: %v", err)
+	}
+
+	if len(stored) != 4 {
+		t.Fatalf("Expected 4 entries, got %d: %+v", len(stored), stored)
+	}
+
+	expectedTitles := []string{"Morning Note", "Evening Note", "Later Note"}
+	for i, title := range expectedTitles {
+		if stored[i].Title != title {
+			t.Errorf("Expected entry %d to be %q, got %q", i, title, stored[i].Title)
+		}
+		if stored[i].Err != nil {
+			t.Errorf("Expected entry %d to parse, got error: %v", i, stored[i].Err)
+		}
+	}
+
+	sameDayPath := filepath.Join("/notes", "2023/10", "01.md")
+	if stored[0].Path != sameDayPath || stored[1].Path != sameDayPath {
+		t.Errorf("Expected both same-day notes from %s, got %s and %s", sameDayPath, stored[0].Path, stored[1].Path)
+	}
+
+	if stored[3].Path != malformedPath || stored[3].Err == nil {
+		t.Errorf("Expected malformed file %s to be listed last with an error, got %+v", malformedPath, stored[3])
+	}
+}
+
+func TestListNotes_MissingDir(t *testing.T) {
+	stored, err := ListNotes(NewMockFileSystem(), "/missing")
+	if err != nil {
+		t.Fatalf("ListNotes failed: %v", err)
+	}
+	if len(stored) != 0 {
+		t.Errorf("Expected no notes, got %d", len(stored))
+	}
+}
+
+func TestSplitNotesFromFile(t *testing.T) {
+	first := Note{
+		Title:   "First Note",
+		Date:    readDate("2023-10-01"),
+		Tags:    []string{"work"},
+		Content: "First line.\n\nSecond paragraph.",
+	}
+	second := Note{
+		Title:   "Second Note",
+		Date:    readDate("2023-10-01"),
+		Content: "- a list item\n- another item",
+	}
+
+	fs := NewMockFileSystem()
+	path := filepath.Join("/notes", "2023/10", "01.md")
+	for _, note := range []Note{first, second} {
+		fullNote, err := formatNoteContent(note, Options{})
+		if err != nil {
+			t.Fatalf("formatNoteContent failed: %v", err)
+		}
+		if err := fs.AppendToFile(path, fullNote, DefaultFilePerm); err != nil {
+			t.Fatalf("AppendToFile failed: %v", err)
+		}
+	}
+
+	notes, err := SplitNotesFromFile(fs.Files[path])
+	if err != nil {
+		t.Fatalf("SplitNotesFromFile failed: %v", err)
+	}
+
+	if len(notes) != 2 {
+		t.Fatalf("Expected 2 notes, got %d", len(notes))
+	}
+
+	for i, expected := range []Note{first, second} {
+		got := notes[i]
+		if got.Title != expected.Title || got.Date.String() != expected.Date.String() || !slices.Equal(got.Tags, expected.Tags) {
+			t.Errorf("Note %d metadata mismatch: expected %+v, got %+v", i, expected, got)
+		}
+		if got.Content != expected.Content {
+			t.Errorf("Note %d content mismatch.\nExpected:\n%q\nGot:\n%q", i, expected.Content, got.Content)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package notes
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jasonmichels/chrononoteai/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// tagSeparator splits hierarchical tags such as "work/projectX" into levels.
+const tagSeparator = "/"
+
+// TagNode is one level of the tag hierarchy. Count is the number of notes
+// using this tag or any tag beneath it.
+type TagNode struct {
+	Name     string
+	Count    int
+	Children []*TagNode // Sorted by name
+}
+
+// CountTags returns how many saved notes under dir use each tag. A tag listed
+// more than once in a single note is counted once for that note.
+func CountTags(fs FileSystem, dir string) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	err := walkNoteFiles(fs, dir, func(path string, data []byte) error {
+		fileNotes, err := SplitNotesFromFile(string(data))
+		if err != nil {
+			logging.Warnf("Warning: skipping unparseable notes in %s: %v\n", path, err)
+		}
+
+		for _, note := range fileNotes {
+			seen := make(map[string]bool, len(note.Tags))
+			for _, tag := range note.Tags {
+				if tag == "" || seen[tag] {
+					continue
+				}
+				seen[tag] = true
+				counts[tag]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// mergeTags appends the default tags to a note's tags, dropping any tag that
+// matches an earlier one ignoring case. Tags are returned unchanged when
+// there are no defaults.
+func mergeTags(tags, defaults []string) []string {
+	if len(defaults) == 0 {
+		return tags
+	}
+
+	merged := make([]string, 0, len(tags)+len(defaults))
+	seen := make(map[string]bool, len(tags)+len(defaults))
+	for _, tag := range slices.Concat(tags, defaults) {
+		key := strings.ToLower(tag)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, tag)
+	}
+	return merged
+}
+
+// normalizeTags trims whitespace from each tag, optionally lowercases it, and
+// drops empty tags and tags that match an earlier one ignoring case, keeping
+// the first spelling seen.
+func normalizeTags(tags []string, lowercase bool) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if lowercase {
+			tag = strings.ToLower(tag)
+		}
+		key := strings.ToLower(tag)
+		if tag == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// yamlIndicatorChars change how a tag is read when they start it unquoted in
+// front matter. Inside a tag they are plain text, so tags like "c#", "don't"
+// and "me@home" are fine.
+const yamlIndicatorChars = "-?:,[]{}#&*!|>'\"%@`"
+
+// yamlUnsafeTag describes what keeps tag from being written as a plain YAML
+// scalar in a block list, or returns "" when it is safe.
+func yamlUnsafeTag(tag string) string {
+	if tag != "" && strings.ContainsRune(yamlIndicatorChars, rune(tag[0])) {
+		return fmt.Sprintf("starts with %q", tag[:1])
+	}
+	for _, seq := range []string{": ", " #"} {
+		if strings.Contains(tag, seq) {
+			return fmt.Sprintf("contains %q", seq)
+		}
+	}
+	if strings.HasSuffix(tag, ":") {
+		return `ends with ":"`
+	}
+	return ""
+}
+
+// validateTags checks each tag against opts.TagPattern and rejects tags that
+// would break unquoted YAML unless opts.SanitizeTags is set, in which case
+// formatNoteContent quotes them.
+func validateTags(tags []string, opts Options) error {
+	for _, tag := range tags {
+		if reason := yamlUnsafeTag(tag); reason != "" && !opts.SanitizeTags {
+			return fmt.Errorf("invalid tag %q: it %s, which breaks YAML front matter", tag, reason)
+		}
+		if opts.TagPattern != nil && !opts.TagPattern.MatchString(tag) {
+			return fmt.Errorf("invalid tag %q: must match %s", tag, opts.TagPattern)
+		}
+	}
+	return nil
+}
+
+// quoteUnsafeTags double quotes the items of a tags sequence node that
+// yamlUnsafeTag reports, so they read back the same whatever the list style.
+func quoteUnsafeTags(tags *yaml.Node) {
+	if tags == nil {
+		return
+	}
+	for _, item := range tags.Content {
+		if yamlUnsafeTag(item.Value) != "" {
+			item.Style = yaml.DoubleQuotedStyle
+		}
+	}
+}
+
+// TagTree reads every saved note under dir and builds the hierarchy of their
+// tags, splitting each tag on "/". The root node has no name and counts the
+// notes that have any tags.
+func TagTree(fs FileSystem, dir string) (*TagNode, error) {
+	var noteTags [][]string
+
+	err := walkNoteFiles(fs, dir, func(path string, data []byte) error {
+		fileNotes, err := SplitNotesFromFile(string(data))
+		if err != nil {
+			logging.Warnf("Warning: skipping unparseable notes in %s: %v\n", path, err)
+		}
+		for _, note := range fileNotes {
+			noteTags = append(noteTags, note.Tags)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buildTagTree(noteTags), nil
+}
+
+// buildTagTree counts each note once at every level its tags reach.
+func buildTagTree(noteTags [][]string) *TagNode {
+	root := &TagNode{}
+	for _, tags := range noteTags {
+		counted := make(map[*TagNode]bool)
+		for _, tag := range tags {
+			node := root
+			for _, part := range strings.Split(tag, tagSeparator) {
+				part = strings.TrimSpace(part)
+				if part == "" {
+					continue
+				}
+				if !counted[node] {
+					counted[node] = true
+					node.Count++
+				}
+				node = node.child(part)
+			}
+			if node != root && !counted[node] {
+				counted[node] = true
+				node.Count++
+			}
+		}
+	}
+	return root
+}
+
+// child returns the child with the given name, adding it in sorted position if missing.
+func (n *TagNode) child(name string) *TagNode {
+	i, found := slices.BinarySearchFunc(n.Children, name, func(child *TagNode, name string) int {
+		return cmp.Compare(child.Name, name)
+	})
+	if !found {
+		n.Children = slices.Insert(n.Children, i, &TagNode{Name: name})
+	}
+	return n.Children[i]
+}
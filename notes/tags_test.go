@@ -0,0 +1,200 @@
+package notes
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCountTags(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.Files[filepath.Join("/notes", "2023/10", "01.md")] = `---
+title: Duplicate Tags
+date: 2023-10-01
+tags:
+  - golang
+  - golang
+  - work
+---
+Content.
+---
+title: No Tags
+date: 2023-10-01
+---
+Content.
+---
+title: Empty Tags
+date: 2023-10-01
+tags: []
+---
+Content.
+`
+	fs.Files[filepath.Join("/notes", "2023/10", "02.md")] = `---
+title: Another Go Note
+date: 2023-10-02
+tags:
+  - golang
+---
+Content.
+`
+	fs.Files[filepath.Join("/notes", "2023/10", "03.md")] = "---\ntitle: [unclosed\n---\nBroken.\n"
+
+	counts, err := CountTags(fs, "/notes")
+	if err != nil {
+		t.Fatalf("CountTags failed: %v", err)
+	}
+
+	expected := map[string]int{"golang": 2, "work": 1}
+	if len(counts) != len(expected) {
+		t.Fatalf("Expected %d tags, got %v", len(expected), counts)
+	}
+	for tag, count := range expected {
+		if counts[tag] != count {
+			t.Errorf("Expected tag %q to have count %d, got %d", tag, count, counts[tag])
+		}
+	}
+}
+
+func TestBuildTagTree(t *testing.T) {
+	root := buildTagTree([][]string{
+		{"work/projectX", "work/projectY"},
+		{"work/projectX/design", "personal/health"},
+		{"work", "golang"},
+		{"personal/health", "personal/health"},
+		{},
+	})
+
+	expected := map[string]int{
+		"":                     4,
+		"work":                 3,
+		"work/projectX":        2,
+		"work/projectX/design": 1,
+		"work/projectY":        1,
+		"personal":             2,
+		"personal/health":      2,
+		"golang":               1,
+	}
+
+	counts := make(map[string]int)
+	var walk func(prefix string, node *TagNode)
+	walk = func(prefix string, node *TagNode) {
+		counts[prefix] = node.Count
+		for _, child := range node.Children {
+			walk(strings.TrimPrefix(prefix+"/"+child.Name, "/"), child)
+		}
+	}
+	walk("", root)
+
+	if len(counts) != len(expected) {
+		t.Errorf("Expected %d nodes, got %v", len(expected), counts)
+	}
+	for path, count := range expected {
+		if counts[path] != count {
+			t.Errorf("Expected %q to have count %d, got %d", path, count, counts[path])
+		}
+	}
+
+	var names []string
+	for _, child := range root.Children {
+		names = append(names, child.Name)
+	}
+	if strings.Join(names, ",") != "golang,personal,work" {
+		t.Errorf("Expected children sorted by name, got %v", names)
+	}
+}
+
+func TestTagTree_KeepsRawTags(t *testing.T) {
+	fs := NewMockFileSystem()
+	fs.Files[filepath.Join("/notes", "2023/10", "01.md")] = "---\ntitle: Nested\ndate: 2023-10-01\ntags:\n  - work/projectX\n---\nContent.\n"
+
+	stored, err := ListNotes(fs, "/notes")
+	if err != nil || len(stored) != 1 || stored[0].Tags[0] != "work/projectX" {
+		t.Fatalf("Expected the raw tag to be kept, got %+v (%v)", stored, err)
+	}
+
+	root, err := TagTree(fs, "/notes")
+	if err != nil {
+		t.Fatalf("TagTree failed: %v", err)
+	}
+	if len(root.Children) != 1 || root.Children[0].Name != "work" || root.Children[0].Children[0].Name != "projectX" {
+		t.Errorf("Unexpected tree: %+v", root.Children)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		defaults []string
+		expected []string
+	}{
+		{name: "no defaults", tags: []string{"work", "Work"}, expected: []string{"work", "Work"}},
+		{name: "untagged note", defaults: []string{"acme"}, expected: []string{"acme"}},
+		{name: "appended", tags: []string{"meeting"}, defaults: []string{"acme"}, expected: []string{"meeting", "acme"}},
+		{name: "already present ignoring case", tags: []string{"ACME", "meeting"}, defaults: []string{"acme", "Acme"}, expected: []string{"ACME", "meeting"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeTags(tt.tags, tt.defaults); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProcessNotes_DefaultTags(t *testing.T) {
+	data := "---\ntitle: Standup\ndate: 2023-10-01\ntags:\n  - Acme\n  - meeting\n---\nContent.\n---\ntitle: Untagged\ndate: 2023-10-02\n---\nContent.\n"
+
+	fs := NewMockFileSystem()
+	opts := Options{NotesDir: "/notes", DefaultTags: []string{"acme"}, InlineSingleTag: true, Now: fixedClock}
+	if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if saved := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]; !strings.Contains(saved, "tags:\n    - Acme\n    - meeting\n") {
+		t.Errorf("Expected the note's own tags without a duplicate default, got:\n%s", saved)
+	}
+	if saved := fs.Files[filepath.Join("/notes", "2023/10", "02.md")]; !strings.Contains(saved, "tags: [acme]\n") {
+		t.Errorf("Expected the default tag in the front matter, got:\n%s", saved)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		tags      []string
+		lowercase bool
+		expected  []string
+	}{
+		{name: "nil", expected: nil},
+		{name: "whitespace", tags: []string{" golang ", "golang", "\twork\n", "  "}, expected: []string{"golang", "work"}},
+		{name: "case collisions lowercased", tags: []string{"Golang", "golang", " GOLANG "}, lowercase: true, expected: []string{"golang"}},
+		{name: "case preserved", tags: []string{"Golang", "golang", "Work"}, expected: []string{"Golang", "Work"}},
+		{name: "first seen order", tags: []string{"work", "Meeting", "WORK", "acme"}, lowercase: true, expected: []string{"work", "meeting", "acme"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeTags(tt.tags, tt.lowercase); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProcessNotes_NormalizesTags(t *testing.T) {
+	data := "---\ntitle: Go Notes\ndate: 2023-10-01\ntags:\n  - Golang\n  - golang\n  - ' golang '\n  - Work\n---\nContent.\n"
+
+	fs := NewMockFileSystem()
+	opts := Options{NotesDir: "/notes", LowercaseTags: true, TagPattern: regexp.MustCompile(`^[\p{L}\p{N}_/-]+$`), Now: fixedClock}
+	if _, err := ProcessNotesWithOptions(data, fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if saved := fs.Files[filepath.Join("/notes", "2023/10", "01.md")]; !strings.Contains(saved, "tags:\n    - golang\n    - work\n") {
+		t.Errorf("Expected normalized tags in the front matter, got:\n%s", saved)
+	}
+}
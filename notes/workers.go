@@ -0,0 +1,61 @@
+package notes
+
+import (
+	"context"
+	"sync"
+)
+
+// fileNotes are the notes headed for one file, in the order they are saved.
+type fileNotes struct {
+	path  string
+	notes []Note
+}
+
+// forEachFile calls fn for each file on up to workers goroutines, so no two
+// calls touch the same file. The first error cancels the context passed to the
+// remaining calls, stops new files from starting, and is returned. Canceling
+// parent does the same, returning its error if no call failed first.
+func forEachFile(parent context.Context, files []fileNotes, workers int, fn func(ctx context.Context, file fileNotes) error) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		skipped  bool
+	)
+	jobs := make(chan fileNotes)
+	for range min(max(workers, 1), len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				if err := fn(ctx, file); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, file := range files {
+		select {
+		case jobs <- file:
+		case <-ctx.Done():
+			skipped = true
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Files skipped because parent was canceled count as a failure too
+	if firstErr == nil && skipped {
+		return parent.Err()
+	}
+	return firstErr
+}
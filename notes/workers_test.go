@@ -0,0 +1,116 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachFile_BoundsWorkers(t *testing.T) {
+	var files []fileNotes
+	for i := range 20 {
+		files = append(files, fileNotes{path: fmt.Sprintf("%02d.md", i)})
+	}
+
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	err := forEachFile(context.Background(), files, 3, func(ctx context.Context, file fileNotes) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		seen[file.path]++
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("forEachFile failed: %v", err)
+	}
+
+	if peak.Load() > 3 {
+		t.Errorf("Expected at most 3 files at once, got %d", peak.Load())
+	}
+	if len(seen) != len(files) {
+		t.Errorf("Expected every file to be handled, got %d of %d", len(seen), len(files))
+	}
+	for path, count := range seen {
+		if count != 1 {
+			t.Errorf("Expected %s to be handled once, got %d", path, count)
+		}
+	}
+}
+
+func TestForEachFile_FirstErrorCancels(t *testing.T) {
+	var files []fileNotes
+	for i := range 50 {
+		files = append(files, fileNotes{path: fmt.Sprintf("%02d.md", i)})
+	}
+
+	failure := errors.New("disk full")
+	var started atomic.Int32
+	err := forEachFile(context.Background(), files, 2, func(ctx context.Context, file fileNotes) error {
+		started.Add(1)
+		if file.path == "00.md" {
+			return failure
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the first error to be returned, got %v", err)
+	}
+	if started.Load() == int32(len(files)) {
+		t.Error("Expected remaining files not to start after the error")
+	}
+}
+
+func TestProcessNotes_Concurrent(t *testing.T) {
+	var buffer strings.Builder
+	for day := 1; day <= 20; day++ {
+		for n := 1; n <= 3; n++ {
+			fmt.Fprintf(&buffer, "---\ntitle: Note %d\ndate: 2023-10-%02d\n---\nNote %d of day %d.\n", n, day, n, day)
+		}
+	}
+
+	fs := NewMockFileSystem()
+	var mu sync.Mutex
+	var written int
+	opts := Options{NotesDir: "/notes", Concurrency: 4, Now: fixedClock, OnWrite: func(path string, note Note) {
+		mu.Lock()
+		written++
+		mu.Unlock()
+	}}
+	if _, err := ProcessNotesWithOptions(buffer.String(), fs, opts); err != nil {
+		t.Fatalf("ProcessNotesWithOptions failed: %v", err)
+	}
+
+	if written != 60 {
+		t.Errorf("Expected 60 notes written, got %d", written)
+	}
+	for day := 1; day <= 20; day++ {
+		content := fs.Files[filepath.Join("/notes", "2023/10", fmt.Sprintf("%02d.md", day))]
+		first, second, third := strings.Index(content, "title: Note 1\n"), strings.Index(content, "title: Note 2\n"), strings.Index(content, "title: Note 3\n")
+		if first < 0 || second < first || third < second {
+			t.Errorf("Expected day %d to hold its notes in buffer order, got:\n%s", day, content)
+		}
+	}
+}
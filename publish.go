@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runPublish moves a draft into the dated tree. The draft is given as a path,
+// or as a file name within the drafts folder with or without its extension.
+func runPublish(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	date := flags.String("date", "", "Date to file the draft under (YYYY-MM-DD); required if the draft has none")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		logging.Errorf("publish requires one draft file, e.g. chrononoteai publish [--date YYYY-MM-DD] my-idea.md")
+		return errors.New("missing draft file")
+	}
+
+	path, err := resolveDraftPath(cfg, fs, flags.Arg(0))
+	if err != nil {
+		logging.Errorf("Error finding draft %s: %v", flags.Arg(0), err)
+		return err
+	}
+
+	opts, err := processOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := notes.PublishDraft(fs, path, *date, opts); err != nil {
+		logging.Errorf("Error publishing draft: %v", err)
+		return err
+	}
+	return nil
+}
+
+// resolveDraftPath finds the draft named on the command line, trying it as a
+// path first and then within the drafts folder.
+func resolveDraftPath(cfg *config.Config, fs notes.FileSystem, name string) (string, error) {
+	candidates := []string{name, filepath.Join(cfg.NotesDir, notes.DraftsDir, name)}
+	if filepath.Ext(name) == "" {
+		candidates = append(candidates, filepath.Join(cfg.NotesDir, notes.DraftsDir, name+".md"))
+	}
+
+	for _, candidate := range candidates {
+		exists, err := fs.Exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("no such draft")
+}
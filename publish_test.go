@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestRunPublish(t *testing.T) {
+	notesDir := t.TempDir()
+	draftPath := filepath.Join(notesDir, notes.DraftsDir, "idea.md")
+	if err := os.MkdirAll(filepath.Dir(draftPath), 0o755); err != nil {
+		t.Fatalf("Failed to create drafts dir: %v", err)
+	}
+	if err := os.WriteFile(draftPath, []byte("---\ntitle: Idea\ndraft: true\n---\nContent.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write draft: %v", err)
+	}
+
+	cfg := &config.Config{NotesDir: notesDir}
+	if err := runPublish(cfg, notes.OSFileSystem{}, []string{"idea"}); err == nil {
+		t.Error("Expected an error publishing an undated draft without --date")
+	}
+	if err := runPublish(cfg, notes.OSFileSystem{}, []string{"--date", "2023-10-01", "idea"}); err != nil {
+		t.Fatalf("runPublish failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(notesDir, "2023", "10", "01.md"))
+	if err != nil {
+		t.Fatalf("Expected the draft to be filed by date: %v", err)
+	}
+	if !strings.Contains(string(data), "title: Idea\n") || strings.Contains(string(data), "draft:") {
+		t.Errorf("Expected the published note without the draft flag, got:\n%s", data)
+	}
+	if _, err := os.Stat(draftPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the draft to be removed, got %v", err)
+	}
+
+	if err := runPublish(cfg, notes.OSFileSystem{}, []string{"missing"}); err == nil {
+		t.Error("Expected an error for a missing draft")
+	}
+}
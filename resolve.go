@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// errNoteNotFound is returned by resolve when no note has the given title or alias.
+var errNoteNotFound = errors.New("no note found")
+
+// runResolve prints the path of each file holding a note whose title or
+// aliases match the name given as arguments.
+func runResolve(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	if len(args) == 0 {
+		logging.Errorf("Usage: chrononoteai resolve <alias>")
+		return errors.New("missing alias")
+	}
+	return resolveAlias(os.Stdout, fs, cfg.NotesDir, strings.Join(args, " "))
+}
+
+// resolveAlias writes the path of each file holding a note known as name to w,
+// once per file.
+func resolveAlias(w io.Writer, fs notes.FileSystem, dir, name string) error {
+	matches, err := notes.ResolveAlias(fs, dir, name)
+	if err != nil {
+		logging.Errorf("Error resolving %q: %v", name, err)
+		return err
+	}
+	if len(matches) == 0 {
+		logging.Errorf("No note found with title or alias %q", name)
+		return errNoteNotFound
+	}
+
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		if seen[match.Path] {
+			continue
+		}
+		seen[match.Path] = true
+		fmt.Fprintln(w, match.Path)
+	}
+	return nil
+}
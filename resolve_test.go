@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestResolveAliasCommand(t *testing.T) {
+	notesDir := t.TempDir()
+	path := filepath.Join(notesDir, "2023", "10", "01.md")
+	content := "---\ntitle: Project Phoenix\ndate: 2023-10-01\naliases: [phoenix]\n---\nThe rewrite.\n---\ntitle: Phoenix Kickoff\ndate: 2023-10-01\naliases: [phoenix]\n---\nDay one.\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create notes dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	var out strings.Builder
+	if err := resolveAlias(&out, notes.OSFileSystem{}, notesDir, "Phoenix"); err != nil {
+		t.Fatalf("resolveAlias failed: %v", err)
+	}
+	if out.String() != path+"\n" {
+		t.Errorf("Expected the file printed once, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := resolveAlias(&out, notes.OSFileSystem{}, notesDir, "icarus"); !errors.Is(err, errNoteNotFound) {
+		t.Errorf("Expected errNoteNotFound for an unknown alias, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected nothing printed for a miss, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runSearch prints saved notes matching an optional text query and the tag and date range flags.
+func runSearch(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	var tags config.StringList
+	flags.Var(&tags, "tag", "Tag to match, may be repeated to match any of several tags")
+	from := flags.String("from", "", "Earliest note date (YYYY-MM-DD)")
+	to := flags.String("to", "", "Latest note date (YYYY-MM-DD)")
+	ignoreCase := flags.Bool("ignore-case", false, "Match the query regardless of case")
+
+	query, err := parseInterspersed(flags, args)
+	if err != nil {
+		return err
+	}
+
+	filter := notes.SearchFilter{
+		Query:      strings.Join(query, " "),
+		IgnoreCase: *ignoreCase,
+		Tags:       tags,
+	}
+	if filter.From, err = parseDateFlag("from", *from); err != nil {
+		return err
+	}
+	if filter.To, err = parseDateFlag("to", *to); err != nil {
+		return err
+	}
+
+	results, err := notes.SearchNotes(fs, cfg.NotesDir, filter)
+	if err != nil {
+		logging.Errorf("Error searching notes: %v", err)
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tTITLE\tPATH")
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Date, result.Title, result.Path)
+		for _, snippet := range result.Snippets {
+			fmt.Fprintf(w, "\t  %s\t\n", snippet)
+		}
+	}
+
+	return w.Flush()
+}
+
+// parseInterspersed parses flags that may appear before or after positional
+// arguments and returns the positional arguments in order.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseDateFlag parses an optional YYYY-MM-DD flag value, returning the zero time when unset.
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		logging.Errorf("Invalid --%s date: %s", name, value)
+		return time.Time{}, err
+	}
+	return date, nil
+}
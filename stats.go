@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runStats prints a summary of journaling habits across saved notes, as text or JSON.
+func runStats(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "Print the stats as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	stats, err := notes.ComputeJournalStats(fs, cfg.NotesDir, time.Now())
+	if err != nil {
+		logging.Errorf("Error computing stats: %v", err)
+		return err
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Total notes:\t%d\n", stats.TotalNotes)
+	fmt.Fprintf(w, "Longest streak:\t%d days\n", stats.LongestStreak)
+	fmt.Fprintf(w, "Current streak:\t%d days\n", stats.CurrentStreak)
+
+	months := make([]string, 0, len(stats.NotesPerMonth))
+	for month := range stats.NotesPerMonth {
+		months = append(months, month)
+	}
+	slices.Sort(months)
+
+	fmt.Fprintln(w, "\nMONTH\tNOTES")
+	for _, month := range months {
+		fmt.Fprintf(w, "%s\t%d\n", month, stats.NotesPerMonth[month])
+	}
+
+	fmt.Fprintln(w, "\nTAG\tNOTES")
+	for _, tag := range stats.TopTags {
+		fmt.Fprintf(w, "%s\t%d\n", tag.Tag, tag.Count)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runListTags prints every tag used across saved notes with its note count, most used first.
+func runListTags(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	counts, err := notes.CountTags(fs, cfg.NotesDir)
+	if err != nil {
+		logging.Errorf("Error counting tags: %v", err)
+		return err
+	}
+
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	slices.SortFunc(tags, func(a, b string) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COUNT\tTAG")
+	for _, tag := range tags {
+		fmt.Fprintf(w, "%d\t%s\n", counts[tag], tag)
+	}
+
+	return w.Flush()
+}
+
+// runTags prints the tag hierarchy, splitting tags such as "work/projectX" on
+// "/", with the number of notes at each level.
+func runTags(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	root, err := notes.TagTree(fs, cfg.NotesDir)
+	if err != nil {
+		logging.Errorf("Error building tag tree: %v", err)
+		return err
+	}
+
+	printTagTree(os.Stdout, root.Children, 0)
+	return nil
+}
+
+// printTagTree writes each node and its children indented by depth.
+func printTagTree(w io.Writer, nodes []*notes.TagNode, depth int) {
+	for _, node := range nodes {
+		fmt.Fprintf(w, "%s%s (%d)\n", strings.Repeat("  ", depth), node.Name, node.Count)
+		printTagTree(w, node.Children, depth+1)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestPrintTagTree(t *testing.T) {
+	tree := []*notes.TagNode{
+		{Name: "personal", Count: 1},
+		{Name: "work", Count: 3, Children: []*notes.TagNode{
+			{Name: "projectX", Count: 2, Children: []*notes.TagNode{{Name: "design", Count: 1}}},
+		}},
+	}
+
+	var out strings.Builder
+	printTagTree(&out, tree, 0)
+
+	expected := "personal (1)\nwork (3)\n  projectX (2)\n    design (1)\n"
+	if out.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// runToday prints the notes saved for today, or for the day given by --date.
+func runToday(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	flags := flag.NewFlagSet("today", flag.ContinueOnError)
+	dateFlag := flags.String("date", "", "Day to show (YYYY-MM-DD); defaults to today")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	date := time.Now()
+	if *dateFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *dateFlag)
+		if err != nil {
+			logging.Errorf("Invalid --date: %s", *dateFlag)
+			return err
+		}
+		date = parsed
+	}
+
+	return printDay(os.Stdout, cfg, fs, date)
+}
+
+// printDay writes the contents of the daily file for date to w.
+func printDay(w io.Writer, cfg *config.Config, fs notes.FileSystem, date time.Time) error {
+	path, err := notes.DailyNotePath(date, notes.Options{NotesDir: cfg.NotesDir, PathTemplate: cfg.PathTemplate})
+	if err != nil {
+		logging.Errorf("Error finding the notes for %s: %v", date.Format("2006-01-02"), err)
+		return err
+	}
+
+	data, err := fs.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(w, "No notes for %s\n", date.Format("2006-01-02"))
+		return nil
+	}
+	if err != nil {
+		logging.Errorf("Error reading %s: %v", path, err)
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
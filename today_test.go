@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestPrintDay(t *testing.T) {
+	notesDir := t.TempDir()
+	path := filepath.Join(notesDir, "2023", "10", "01.md")
+	content := "---\ntitle: First Day\ndate: 2023-10-01\n---\nEarlier.\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("Failed to create notes dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write note: %v", err)
+	}
+
+	cfg := &config.Config{NotesDir: notesDir, PathTemplate: notes.DefaultPathTemplate}
+
+	var out strings.Builder
+	if err := printDay(&out, cfg, notes.OSFileSystem{}, time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("printDay failed: %v", err)
+	}
+	if out.String() != content {
+		t.Errorf("Expected the day's file to be printed, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := printDay(&out, cfg, notes.OSFileSystem{}, time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("printDay failed: %v", err)
+	}
+	if out.String() != "No notes for 2023-10-02\n" {
+		t.Errorf("Expected a message for a day without notes, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import "fmt"
+
+// Build metadata, set at build time with
+// -ldflags "-X main.Version=... -X main.Commit=... -X main.BuildDate=...".
+var Version, Commit, BuildDate string
+
+// versionString describes the running build, filling in placeholders for
+// metadata that wasn't set at build time.
+func versionString() string {
+	return fmt.Sprintf("chrononoteai %s (commit %s, built %s)",
+		valueOr(Version, "dev"), valueOr(Commit, "unknown"), valueOr(BuildDate, "unknown"))
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestVersionString(t *testing.T) {
+	if got, expected := versionString(), "chrononoteai dev (commit unknown, built unknown)"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	Version, Commit, BuildDate = "1.2.0", "abc1234", "2024-09-12T10:00:00Z"
+	t.Cleanup(func() { Version, Commit, BuildDate = "", "", "" })
+
+	if got, expected := versionString(), "chrononoteai 1.2.0 (commit abc1234, built 2024-09-12T10:00:00Z)"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
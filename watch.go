@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/logging"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+// watchDebounce is how long the buffer must go without changes before it is
+// processed, so an editor's burst of writes is handled once.
+var watchDebounce = 500 * time.Millisecond
+
+// watchSettle is how long to wait before reading the buffer again to check an
+// editor has finished with it, e.g. one that writes the file and then truncates it.
+var watchSettle = 100 * time.Millisecond
+
+// runWatch processes the buffer whenever it is saved, until interrupted.
+func runWatch(cfg *config.Config, fs notes.FileSystem, args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return watchBuffer(ctx, cfg, fs)
+}
+
+// watchBuffer processes the buffer whenever it is saved with notes in it,
+// until ctx is canceled. The buffer's directory is watched rather than the
+// file itself, so changes are still seen after the buffer is replaced by an
+// editor's atomic save or recreated after it is cleared.
+func watchBuffer(ctx context.Context, cfg *config.Config, fs notes.FileSystem) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		logging.Errorf("Error starting file watcher: %v", err)
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(filepath.Dir(cfg.BufferFile)); err != nil {
+		logging.Errorf("Error watching %s: %v", cfg.BufferFile, err)
+		return err
+	}
+
+	// Changes are coalesced; one pending change is enough to process again
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+
+	bufferFile := filepath.Clean(cfg.BufferFile)
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == bufferFile && event.Has(fsnotify.Write|fsnotify.Create) {
+					notify()
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logging.Warnf("Warning: file watcher error: %v", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	logging.Infof("Watching %s for changes, press Ctrl-C to stop.", cfg.BufferFile)
+
+	// Notes already in the buffer are processed straight away
+	notify()
+	cycle := 0
+	debounce(ctx, changes, watchDebounce, func() {
+		cycle++
+		processIfNotEmpty(ctx, cfg, fs, cycle)
+	})
+
+	logging.Infof("Stopped watching %s.", cfg.BufferFile)
+	return nil
+}
+
+// processIfNotEmpty processes the buffer once it has settled, unless it holds
+// only whitespace, as it does right after being cleared. Errors are logged and
+// watching goes on.
+func processIfNotEmpty(ctx context.Context, cfg *config.Config, fs notes.FileSystem, cycle int) {
+	data, err := settledBuffer(ctx, fs, cfg.BufferFile)
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.Warnf("Warning: can't read buffer file: %v", err)
+		}
+		return
+	}
+	if bufferEmpty(data) {
+		logging.Debugf("Watch cycle %d: buffer file is empty, nothing to process.", cycle)
+		return
+	}
+
+	logging.Infof("Watch cycle %d: processing %s", cycle, cfg.BufferFile)
+	started := time.Now()
+	if err := processBuffer(ctx, cfg, fs); err != nil {
+		logging.Warnf("Watch cycle %d failed after %s, waiting for the next change.", cycle, time.Since(started).Round(time.Millisecond))
+		return
+	}
+	logging.Infof("Watch cycle %d finished in %s.", cycle, time.Since(started).Round(time.Millisecond))
+}
+
+// settledBuffer reads the buffer until two reads watchSettle apart agree, so
+// a save that is still in progress isn't processed half-written.
+func settledBuffer(ctx context.Context, fs notes.FileSystem, path string) ([]byte, error) {
+	data, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(watchSettle):
+		}
+
+		again, err := fs.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(data, again) {
+			return data, nil
+		}
+		data = again
+	}
+}
+
+// debounce calls fn once events have been quiet for delay, however many
+// arrive before then. It returns when ctx is canceled or events is closed.
+func debounce(ctx context.Context, events <-chan struct{}, delay time.Duration, fn func()) {
+	timer := time.NewTimer(delay)
+	timer.Stop()
+	defer timer.Stop()
+
+	var fire <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+			timer.Reset(delay)
+			fire = timer.C
+		case <-fire:
+			fire = nil
+			fn()
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jasonmichels/chrononoteai/config"
+	"github.com/jasonmichels/chrononoteai/notes"
+)
+
+func TestDebounce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan struct{})
+	var calls atomic.Int32
+	done := make(chan struct{})
+	go func() {
+		debounce(ctx, events, 50*time.Millisecond, func() { calls.Add(1) })
+		close(done)
+	}()
+
+	// A burst of saves is processed once
+	for range 5 {
+		events <- struct{}{}
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Expected one call after a burst of events, got %d", got)
+	}
+
+	// A later save is processed again
+	events <- struct{}{}
+	time.Sleep(150 * time.Millisecond)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Expected a second call after another event, got %d", got)
+	}
+
+	// Canceling drops a pending call and stops
+	events <- struct{}{}
+	cancel()
+	<-done
+	time.Sleep(100 * time.Millisecond)
+	if got := calls.Load(); got != 2 {
+		t.Errorf("Expected no call after cancellation, got %d", got)
+	}
+}
+
+func TestWatchBuffer_RecreatedBuffer(t *testing.T) {
+	originalDebounce, originalSettle := watchDebounce, watchSettle
+	watchDebounce, watchSettle = 20*time.Millisecond, 5*time.Millisecond
+	t.Cleanup(func() { watchDebounce, watchSettle = originalDebounce, originalSettle })
+
+	tempDir := t.TempDir()
+	bufferFile := filepath.Join(tempDir, "buffer.md")
+	if err := os.WriteFile(bufferFile, []byte("---\ntitle: First\ndate: 2023-10-01\n---\nBefore watching.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+
+	notesDir := filepath.Join(tempDir, "notes")
+	cfg := &config.Config{BufferFile: bufferFile, NotesDir: notesDir, ClearBuffer: true}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watchBuffer(ctx, cfg, notes.OSFileSystem{}) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("watchBuffer failed: %v", err)
+		}
+	}()
+
+	noteFile := filepath.Join(notesDir, "2023/10", "01.md")
+	waitForContent(t, noteFile, "Before watching.")
+	waitForEmpty(t, bufferFile)
+
+	// The buffer is removed and recreated, as some editors do when saving
+	if err := os.Remove(bufferFile); err != nil {
+		t.Fatalf("Failed to remove buffer file: %v", err)
+	}
+	if err := os.WriteFile(bufferFile, []byte("---\ntitle: Second\ndate: 2023-10-01\n---\nAfter recreating.\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write buffer file: %v", err)
+	}
+	waitForContent(t, noteFile, "After recreating.")
+}
+
+// savingFileSystem returns each of reads in turn, then the last one forever,
+// like a buffer an editor is still saving.
+type savingFileSystem struct {
+	notes.OSFileSystem
+	reads []string
+	count int
+}
+
+func (fs *savingFileSystem) ReadFile(path string) ([]byte, error) {
+	read := fs.reads[min(fs.count, len(fs.reads)-1)]
+	fs.count++
+	return []byte(read), nil
+}
+
+func TestSettledBuffer(t *testing.T) {
+	original := watchSettle
+	watchSettle = time.Millisecond
+	t.Cleanup(func() { watchSettle = original })
+
+	fs := &savingFileSystem{reads: []string{"---\ntitle: Half", "", "---\ntitle: Whole\n---\n", "---\ntitle: Whole\n---\n"}}
+	data, err := settledBuffer(context.Background(), fs, "buffer.md")
+	if err != nil {
+		t.Fatalf("settledBuffer failed: %v", err)
+	}
+	if string(data) != "---\ntitle: Whole\n---\n" {
+		t.Errorf("Expected the settled contents, got %q", data)
+	}
+	if fs.count != 4 {
+		t.Errorf("Expected reading to stop once two reads agree, got %d reads", fs.count)
+	}
+}
+
+// waitForContent fails the test if path doesn't contain want within a few seconds.
+// waitForEmpty polls until the file at path exists and is empty, like a
+// buffer once it has been cleared.
+func waitForEmpty(t *testing.T, path string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(path); err == nil && len(data) == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %s to be cleared", path)
+}
+
+func waitForContent(t *testing.T, path, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(path); err == nil && strings.Contains(string(data), want) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %q in %s", want, path)
+}